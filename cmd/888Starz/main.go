@@ -2,11 +2,14 @@ package main
 
 import (
 	"context"
+	"flag"
 	"github.com/Amore14rn/888Starz_test/internal/app"
 	"github.com/Amore14rn/888Starz_test/internal/config"
 	"github.com/Amore14rn/888Starz_test/pkg/common/logging"
 )
 
+const migrateCommand = "migrate"
+
 func main() {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -16,6 +19,15 @@ func main() {
 
 	ctx = logging.ContextWithLogger(ctx, logging.NewLogger())
 
+	// 888Starz [-config path] migrate up|down|status|redo
+	if flag.Arg(0) == migrateCommand {
+		if err := app.Migrate(ctx, cfg, flag.Arg(1)); err != nil {
+			logging.WithError(ctx, err).Fatal("app.Migrate")
+		}
+
+		return
+	}
+
 	a, err := app.NewApp(ctx, cfg)
 	if err != nil {
 		logging.WithError(ctx, err).Fatal("app.NewApp")
@@ -4,22 +4,53 @@ import (
 	"context"
 	"fmt"
 	"github.com/Amore14rn/888Starz_test/internal/config"
+	"github.com/Amore14rn/888Starz_test/internal/controllers/http/binding"
+	"github.com/Amore14rn/888Starz_test/internal/controllers/http/middleware"
+	lb "github.com/Amore14rn/888Starz_test/internal/controllers/http/v1/audit"
+	ab "github.com/Amore14rn/888Starz_test/internal/controllers/http/v1/auth"
+	cb "github.com/Amore14rn/888Starz_test/internal/controllers/http/v1/cart"
+	ob "github.com/Amore14rn/888Starz_test/internal/controllers/http/v1/order"
 	pb "github.com/Amore14rn/888Starz_test/internal/controllers/http/v1/product"
 	ub "github.com/Amore14rn/888Starz_test/internal/controllers/http/v1/user"
+	wb "github.com/Amore14rn/888Starz_test/internal/controllers/http/v1/webhook"
+	"github.com/Amore14rn/888Starz_test/internal/dal/postgres/migrations"
+	apd "github.com/Amore14rn/888Starz_test/internal/domain/audit/dao"
+	sad "github.com/Amore14rn/888Starz_test/internal/domain/audit/service"
+	cpd "github.com/Amore14rn/888Starz_test/internal/domain/cart/dao"
+	scd "github.com/Amore14rn/888Starz_test/internal/domain/cart/service"
+	ipd "github.com/Amore14rn/888Starz_test/internal/domain/idempotency/dao"
+	sid "github.com/Amore14rn/888Starz_test/internal/domain/idempotency/service"
+	opd "github.com/Amore14rn/888Starz_test/internal/domain/orders/dao"
+	sod "github.com/Amore14rn/888Starz_test/internal/domain/orders/service"
+	xpd "github.com/Amore14rn/888Starz_test/internal/domain/outbox/dao"
+	xpp "github.com/Amore14rn/888Starz_test/internal/domain/outbox/publisher"
+	sxd "github.com/Amore14rn/888Starz_test/internal/domain/outbox/service"
+	policy_audit "github.com/Amore14rn/888Starz_test/internal/domain/policy/audit"
+	policy_auth "github.com/Amore14rn/888Starz_test/internal/domain/policy/auth"
+	policy_cart "github.com/Amore14rn/888Starz_test/internal/domain/policy/cart"
+	policy_order "github.com/Amore14rn/888Starz_test/internal/domain/policy/orders"
 	policy_product "github.com/Amore14rn/888Starz_test/internal/domain/policy/products"
 	policy_user "github.com/Amore14rn/888Starz_test/internal/domain/policy/user"
+	policy_webhook "github.com/Amore14rn/888Starz_test/internal/domain/policy/webhooks"
 	ppd "github.com/Amore14rn/888Starz_test/internal/domain/products/dao"
 	spd "github.com/Amore14rn/888Starz_test/internal/domain/products/service"
 	"github.com/Amore14rn/888Starz_test/internal/domain/user/dao"
 	"github.com/Amore14rn/888Starz_test/internal/domain/user/service"
+	wpd "github.com/Amore14rn/888Starz_test/internal/domain/webhooks/dao"
+	swd "github.com/Amore14rn/888Starz_test/internal/domain/webhooks/service"
 	"github.com/Amore14rn/888Starz_test/pkg/common/core/clock"
 	"github.com/Amore14rn/888Starz_test/pkg/common/core/closer"
 	"github.com/Amore14rn/888Starz_test/pkg/common/core/identity"
+	"github.com/Amore14rn/888Starz_test/pkg/common/core/password"
+	"github.com/Amore14rn/888Starz_test/pkg/common/core/sweeper"
+	"github.com/Amore14rn/888Starz_test/pkg/common/core/token"
+	"github.com/Amore14rn/888Starz_test/pkg/common/core/validator"
 	"github.com/Amore14rn/888Starz_test/pkg/common/logging"
 	"github.com/Amore14rn/888Starz_test/pkg/errors"
 	"github.com/Amore14rn/888Starz_test/pkg/graceful"
 	"github.com/Amore14rn/888Starz_test/pkg/metric"
 	psql "github.com/Amore14rn/888Starz_test/pkg/postgresql"
+	"github.com/Amore14rn/888Starz_test/pkg/tracing"
 	"github.com/gin-gonic/gin"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"golang.org/x/sync/errgroup"
@@ -33,81 +64,247 @@ type App struct {
 	pgClient   *pgxpool.Pool
 	router     *gin.Engine
 	httpServer *http.Server
+	workers    []worker
+}
+
+// worker is a background job running until the context is done.
+type worker interface {
+	Run(ctx context.Context) error
 }
 
 func NewApp(ctx context.Context, cfg *config.Config) (App, error) {
 	logging.L(ctx).Info("router initializing")
 
-	router := gin.Default()
+	registry := metric.NewRegistry()
 
-	logging.WithFields(ctx,
-		logging.StringField("username", cfg.Postgres.User),
-		logging.StringField("password", "<REMOVED>"),
-		logging.StringField("host", cfg.Postgres.Host),
-		logging.StringField("port", cfg.Postgres.Port),
-		logging.StringField("database", cfg.Postgres.Database),
-	).Info("PostgreSQL initializing")
+	httpMetrics, err := metric.NewHTTP(registry)
+	if err != nil {
+		return App{}, errors.Wrap(err, "metric.NewHTTP")
+	}
 
-	pgDsn := fmt.Sprintf(
-		"postgres://%s:%s@%s:%s/%s",
-		cfg.Postgres.User,
-		cfg.Postgres.Password,
-		cfg.Postgres.Host,
-		cfg.Postgres.Port,
-		cfg.Postgres.Database,
-	)
+	router := gin.Default()
+	router.Use(httpMetrics.Middleware())
+	router.Use(middleware.Errors())
 
-	pgClient, err := psql.NewClient(ctx, 5, 3*time.Second, pgDsn, false)
+	pgClient, err := newPgClient(ctx, cfg)
 	if err != nil {
-		return App{}, errors.Wrap(err, "psql.NewClient")
+		return App{}, err
 	}
 
 	closer.AddN(pgClient)
 
-	logging.L(ctx).Info("heartbeat metric initializing")
+	if err = registry.Register(metric.NewPool(pgClient)); err != nil {
+		return App{}, errors.Wrap(err, "registry.Register")
+	}
+
+	if cfg.Postgres.AutoMigrate {
+		logging.L(ctx).Info("applying migrations")
 
-	metricHandler := metric.Handler{}
+		if err = migrations.Run(ctx, pgClient, migrations.CommandUp); err != nil {
+			return App{}, errors.Wrap(err, "migrations.Run")
+		}
+	}
+
+	tracing.NewLocal()
+
+	if err = validator.New(binding.DateFormat); err != nil {
+		return App{}, errors.Wrap(err, "validator.New")
+	}
+
+	logging.L(ctx).Info("metrics initializing")
+
+	metricHandler := metric.Handler{Gatherer: registry}
 	metricHandler.Register(router)
 
 	cl := clock.New()
 	generator := identity.NewGenerator()
 
+	router.Use(middleware.RequestID(generator))
+
+	hasher, err := password.New(password.Config{
+		Algorithm: cfg.Password.Algorithm,
+		Bcrypt:    password.BcryptParams{Cost: cfg.Password.BcryptCost},
+		Argon2id: password.Argon2idParams{
+			Memory:      cfg.Password.Argon2Memory,
+			Iterations:  cfg.Password.Argon2Iterations,
+			Parallelism: cfg.Password.Argon2Parallelism,
+		},
+	})
+	if err != nil {
+		return App{}, errors.Wrap(err, "password.New")
+	}
+
+	tokenManager, err := token.NewManager(token.Config{
+		Algorithm:  cfg.Auth.Algorithm,
+		Secret:     cfg.Auth.Secret,
+		PrivateKey: cfg.Auth.PrivateKey,
+		Issuer:     cfg.Auth.Issuer,
+		AccessTTL:  cfg.Auth.AccessTokenTTL,
+		RefreshTTL: cfg.Auth.RefreshTokenTTL,
+	})
+	if err != nil {
+		return App{}, errors.Wrap(err, "token.NewManager")
+	}
+
+	authMiddleware := middleware.Auth(tokenManager, cl)
+	optionalAuth := middleware.OptionalAuth(tokenManager, cl)
+
+	// DAOs share the manager so that they join a transaction started by a policy
+	txManager := psql.NewTxManager(pgClient, cfg.Postgres.TxRetries)
+
+	//Idempotency service
+	idempotencyStorage := ipd.NewIdempotencyDAO(txManager)
+	idempotencyService := sid.NewIdempotencyService(idempotencyStorage)
+	idempotent := middleware.Idempotency(idempotencyService, cl, cfg.Idempotency.TTL, cfg.Idempotency.Lease)
+	idempotencySweeper := sweeper.New("idempotency keys", idempotencyService.DeleteExpired, cl, cfg.Idempotency.SweepInterval)
+
+	//Audit service
+	auditStorage := apd.NewAuditDAO(txManager)
+	auditService := sad.NewAuditService(auditStorage)
+	auditPolicy := policy_audit.NewAuditPolicy(auditService)
+	auditController := lb.NewAuditHandler(auditPolicy)
+
+	router.GET("/audit", authMiddleware, auditController.All)
+
+	//Outbox service
+	outboxStorage := xpd.NewOutboxDAO(txManager)
+	outboxService := sxd.NewOutboxService(outboxStorage)
+	outboxSweeper := sweeper.New("outbox", func(ctx context.Context, now time.Time) (int64, error) {
+		return outboxService.DeletePublished(ctx, now.Add(-cfg.Outbox.Retention))
+	}, cl, cfg.Outbox.SweepInterval)
+
+	//Order service
+	orderStorage := opd.NewOrderDAO(txManager, outboxStorage, auditStorage)
+	orderService := sod.NewOrderService(orderStorage)
+	orderMetrics, err := metric.NewOrders(registry)
+	if err != nil {
+		return App{}, errors.Wrap(err, "metric.NewOrders")
+	}
+	orderPolicy := policy_order.NewOrderPolicy(orderService, generator, cl, orderMetrics)
+	orderController := ob.NewOrderHandler(orderPolicy)
+
 	//User service
-	userStorage := dao.NewUserStorage(pgClient)
+	userStorage := dao.NewUserStorage(txManager, auditStorage)
 	userService := service.NewUserService(userStorage)
-	userPolicy := policy_user.NewUserPolicy(userService, generator, cl)
+	userPolicy := policy_user.NewUserPolicy(userService, generator, cl, hasher)
 	userController := ub.NewUserHandler(userPolicy)
+	userPurger := sweeper.New("deleted users", func(ctx context.Context, now time.Time) (int64, error) {
+		return userService.PurgeDeleted(ctx, now.Add(-cfg.SoftDelete.Retention), now)
+	}, cl, cfg.SoftDelete.PurgeInterval)
+
+	//Auth service
+	authPolicy := policy_auth.NewAuthPolicy(userPolicy, tokenManager, cl)
+	authController := ab.NewAuthHandler(authPolicy)
 
 	logging.L(ctx).Info("handlers initializing")
+	authGroup := router.Group("/auth")
+	{
+		authGroup.POST("/login", authController.Login)
+		authGroup.POST("/refresh", authController.Refresh)
+	}
+
 	userGroup := router.Group("/user")
 	{
 		userGroup.POST("/create", userController.CreateUser)
-		userGroup.GET("/all", userController.All)
-		userGroup.GET("/get/:id", userController.GetUser)
+		userGroup.GET("/all", optionalAuth, userController.All)
+		userGroup.GET("/get/:id", optionalAuth, userController.GetUser)
 		userGroup.POST("/get/:name", userController.GetUserByName)
-		userGroup.PATCH("/update", userController.UpdateUser)
-		userGroup.DELETE("/delete/:id", userController.DeleteUser)
-		userGroup.POST("/create-order", userController.CreateOrder)
+		userGroup.PATCH("/update", authMiddleware, userController.UpdateUser)
+		userGroup.DELETE("/delete/:id", authMiddleware, userController.DeleteUser)
+		userGroup.PATCH("/:id/role", authMiddleware, userController.UpdateRole)
+		userGroup.POST("/create-order", authMiddleware, idempotent, orderController.CreateOrder)
+		userGroup.GET("/:id/orders", authMiddleware, orderController.UserOrders)
 	}
 
 	//Product service
-	productStorage := ppd.NewProductDAO(pgClient)
+	productStorage := ppd.NewProductDAO(txManager, outboxStorage, auditStorage)
 	productService := spd.NewProductService(productStorage)
 	productPolicy := policy_product.NewProductPolicy(productService, generator, cl)
 	productController := pb.NewProductHandler(productPolicy)
+	productPurger := sweeper.New("deleted products", func(ctx context.Context, now time.Time) (int64, error) {
+		return productService.PurgeDeleted(ctx, now.Add(-cfg.SoftDelete.Retention), now)
+	}, cl, cfg.SoftDelete.PurgeInterval)
 
 	productGroup := router.Group("/product")
 	{
-		productGroup.POST("/create", productController.CreateProduct)
-		productGroup.GET("/all", productController.All)
-		productGroup.GET("/get/:id", productController.GetProduct)
-		productGroup.PATCH("/update", productController.UpdateProduct)
-		productGroup.DELETE("/delete/:id", productController.DeleteProduct)
+		productGroup.POST("/create", authMiddleware, idempotent, productController.CreateProduct)
+		productGroup.GET("/all", optionalAuth, productController.All)
+		productGroup.GET("/tags", productController.Tags)
+		productGroup.GET("/search", productController.Search)
+		productGroup.GET("/get/:id", optionalAuth, productController.GetProduct)
+		productGroup.PATCH("/update", authMiddleware, productController.UpdateProduct)
+		productGroup.DELETE("/delete/:id", authMiddleware, productController.DeleteProduct)
+		productGroup.POST("/:id/restore", authMiddleware, productController.RestoreProduct)
+		productGroup.GET("/:id/price-history", productController.PriceHistory)
 	}
 
+	orderGroup := router.Group("/order", authMiddleware)
+	{
+		orderGroup.POST("/create", idempotent, orderController.CreateOrder)
+		orderGroup.GET("/get/:id", orderController.GetOrder)
+		orderGroup.GET("/:id", orderController.GetOrder)
+		orderGroup.POST("/:id/transition", orderController.TransitionOrder)
+		orderGroup.POST("/:id/cancel", orderController.CancelOrder)
+	}
+
+	//Cart service
+	cartStorage := cpd.NewCartDAO(txManager)
+	cartService := scd.NewCartService(cartStorage)
+	cartPolicy := policy_cart.NewCartPolicy(cartService, orderPolicy, txManager, generator, cl, orderMetrics, cfg.Cart.TTL)
+	cartController := cb.NewCartHandler(cartPolicy)
+	cartSweeper := sweeper.New("carts", cartService.DeleteExpired, cl, cfg.Cart.SweepInterval)
+
+	cartGroup := router.Group("/cart", authMiddleware)
+	{
+		cartGroup.GET("", cartController.GetCart)
+		cartGroup.POST("/items", cartController.AddItem)
+		cartGroup.PATCH("/items/:product_id", cartController.UpdateItem)
+		cartGroup.DELETE("/items/:product_id", cartController.RemoveItem)
+		cartGroup.POST("/checkout", idempotent, cartController.Checkout)
+	}
+
+	//Webhook service
+	webhookStorage := wpd.NewWebhookDAO(txManager)
+	webhookService := swd.NewWebhookService(webhookStorage)
+	webhookPolicy := policy_webhook.NewWebhookPolicy(webhookService, generator, cl)
+	webhookController := wb.NewWebhookHandler(webhookPolicy)
+	webhookDispatcher := swd.NewDispatcher(webhookService, cl, swd.DispatcherConfig{
+		Interval:    cfg.Webhooks.Interval,
+		BatchSize:   cfg.Webhooks.BatchSize,
+		Lease:       cfg.Webhooks.Lease,
+		Timeout:     cfg.Webhooks.Timeout,
+		MaxAttempts: cfg.Webhooks.MaxAttempts,
+		MinBackoff:  cfg.Webhooks.MinBackoff,
+		MaxBackoff:  cfg.Webhooks.MaxBackoff,
+	})
+
+	webhookGroup := router.Group("/webhooks", authMiddleware)
+	{
+		webhookGroup.POST("", webhookController.CreateSubscription)
+		webhookGroup.GET("", webhookController.Subscriptions)
+		webhookGroup.DELETE("/:id", webhookController.DeleteSubscription)
+		webhookGroup.GET("/:id/deliveries", webhookController.Deliveries)
+		webhookGroup.GET("/:id/deliveries/:delivery_id", webhookController.GetDelivery)
+		webhookGroup.POST("/:id/deliveries/:delivery_id/redeliver", webhookController.Redeliver)
+	}
+
+	publishers := []sxd.Publisher{swd.NewSubscriptionPublisher(webhookService, cl)}
+	if cfg.Outbox.WebhookURL != "" {
+		publishers = append(publishers, xpp.NewWebhook(cfg.Outbox.WebhookURL, cfg.Outbox.WebhookTimeout))
+	}
+
+	outboxRelay := sxd.NewRelay(outboxService, sxd.NewFanout(publishers...), cl, sxd.RelayConfig{
+		Interval:   cfg.Outbox.Interval,
+		BatchSize:  cfg.Outbox.BatchSize,
+		Lease:      cfg.Outbox.Lease,
+		MinBackoff: cfg.Outbox.MinBackoff,
+		MaxBackoff: cfg.Outbox.MaxBackoff,
+	})
+
 	return App{
-		cfg:    cfg,
-		router: router,
+		cfg:     cfg,
+		router:  router,
+		workers: []worker{cartSweeper, idempotencySweeper, outboxSweeper, productPurger, userPurger, outboxRelay, webhookDispatcher},
 	}, nil
 
 }
@@ -117,6 +314,12 @@ func (a *App) Run(ctx context.Context) error {
 	grp.Go(func() error {
 		return a.startHTTP(ctx)
 	})
+	for _, w := range a.workers {
+		w := w
+		grp.Go(func() error {
+			return w.Run(ctx)
+		})
+	}
 	return grp.Wait()
 }
 
@@ -135,7 +338,7 @@ func (a *App) startHTTP(ctx context.Context) error {
 		logger.With(logging.ErrorField(err)).Fatal("failed to create listener")
 	}
 
-	handler := a.router
+	handler := tracing.Middleware(logging.Middleware(a.router))
 
 	a.httpServer = &http.Server{
 		Handler:        handler,
@@ -159,3 +362,29 @@ func (a *App) startHTTP(ctx context.Context) error {
 
 	return err
 }
+
+func newPgClient(ctx context.Context, cfg *config.Config) (*pgxpool.Pool, error) {
+	logging.WithFields(ctx,
+		logging.StringField("username", cfg.Postgres.User),
+		logging.StringField("password", "<REMOVED>"),
+		logging.StringField("host", cfg.Postgres.Host),
+		logging.StringField("port", cfg.Postgres.Port),
+		logging.StringField("database", cfg.Postgres.Database),
+	).Info("PostgreSQL initializing")
+
+	pgDsn := fmt.Sprintf(
+		"postgres://%s:%s@%s:%s/%s",
+		cfg.Postgres.User,
+		cfg.Postgres.Password,
+		cfg.Postgres.Host,
+		cfg.Postgres.Port,
+		cfg.Postgres.Database,
+	)
+
+	pgClient, err := psql.NewClient(ctx, 5, 3*time.Second, pgDsn, false)
+	if err != nil {
+		return nil, errors.Wrap(err, "psql.NewClient")
+	}
+
+	return pgClient, nil
+}
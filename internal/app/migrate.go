@@ -0,0 +1,27 @@
+package app
+
+import (
+	"context"
+	"github.com/Amore14rn/888Starz_test/internal/config"
+	"github.com/Amore14rn/888Starz_test/internal/dal/postgres/migrations"
+	"github.com/Amore14rn/888Starz_test/pkg/common/logging"
+	"github.com/Amore14rn/888Starz_test/pkg/errors"
+)
+
+// Migrate applies a single migrate command (up, down, status or redo)
+// against the configured database without starting the HTTP server.
+func Migrate(ctx context.Context, cfg *config.Config, command string) error {
+	pgClient, err := newPgClient(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer pgClient.Close()
+
+	logging.WithFields(ctx, logging.StringField("command", command)).Info("running migrations")
+
+	if err = migrations.Run(ctx, pgClient, command); err != nil {
+		return errors.Wrap(err, "migrations.Run")
+	}
+
+	return nil
+}
@@ -18,6 +18,13 @@ var (
 	ErrNotFound       = NewAppError(http.StatusNotFound, "00103", "not found")
 	ErrUnauthorized   = NewAppError(http.StatusUnauthorized, "00104", "unauthorized")
 	ErrForbidden      = NewAppError(http.StatusForbidden, "00105", "access forbidden")
+	ErrAlreadyExists  = NewAppError(http.StatusConflict, "00106", "already exists")
+	ErrConflict       = NewAppError(http.StatusConflict, "00107", "conflicts with related data")
+	ErrOutOfStock     = NewAppError(http.StatusConflict, "00108", "out of stock")
+	ErrInvalidState   = NewAppError(http.StatusConflict, "00109", "invalid state transition")
+	ErrKeyReused      = NewAppError(http.StatusUnprocessableEntity, "00110", "idempotency key reused with a different request")
+	ErrPrecondition   = NewAppError(http.StatusPreconditionFailed, "00111", "resource was modified, fetch it again")
+	ErrNoPrecondition = NewAppError(http.StatusPreconditionRequired, "00112", "If-Match header is required")
 )
 
 type ErrorFields map[string]string
@@ -35,6 +42,24 @@ func (e *AppError) WithFields(fields ErrorFields) {
 	e.Fields = fields
 }
 
+// WithDetails returns a copy of e carrying fields, leaving e itself untouched
+// so that shared errors like ErrForbidden can be decorated per request.
+func (e *AppError) WithDetails(fields ErrorFields) *AppError {
+	c := *e
+	c.Fields = fields
+
+	return &c
+}
+
+// WithCause returns a copy of e wrapping cause. The message and code sent to
+// the client stay the same while logs and errors.Is/As see the underlying error.
+func (e *AppError) WithCause(cause error) *AppError {
+	c := *e
+	c.Err = cause
+
+	return &c
+}
+
 func NewAppError(transportCode int, code, message string) *AppError {
 	return &AppError{
 		Err:           fmt.Errorf(message),
@@ -58,12 +83,22 @@ func (e *AppError) Error() string {
 
 func (e *AppError) Unwrap() error { return e.Err }
 
+// Is matches any AppError with the same code, so copies made by WithDetails
+// still satisfy errors.Is against the original error.
+func (e *AppError) Is(target error) bool {
+	t, ok := target.(*AppError)
+
+	return ok && t.Code == e.Code
+}
+
 func (e *AppError) Marshal(ctx context.Context) []byte {
+	// Work on a copy, e is usually one of the shared errors above.
+	out := *e
 	if span := trace.SpanContextFromContext(ctx); span.HasTraceID() {
-		e.TraceID = span.TraceID().String()
+		out.TraceID = span.TraceID().String()
 	}
 
-	bytes, err := json.Marshal(e)
+	bytes, err := json.Marshal(out)
 	if err != nil {
 		return nil
 	}
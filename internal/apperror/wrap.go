@@ -0,0 +1,54 @@
+package apperror
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Amore14rn/888Starz_test/internal/dal"
+	"github.com/Amore14rn/888Starz_test/pkg/common/core/pagination"
+)
+
+// Wrap annotates err with message and translates DAL errors into the matching
+// AppError, keeping err reachable through errors.Is and errors.As. AppErrors
+// are returned as they are, anything else is only annotated.
+func Wrap(err error, message string) error {
+	if err == nil {
+		return nil
+	}
+
+	var appErr *AppError
+	if errors.As(err, &appErr) {
+		return err
+	}
+
+	wrapped := fmt.Errorf("%s: %w", message, err)
+
+	switch {
+	case errors.Is(err, dal.ErrNotFound):
+		return ErrNotFound.WithCause(wrapped)
+	case errors.Is(err, dal.ErrVersionMismatch):
+		return ErrPrecondition.WithCause(wrapped)
+	case errors.Is(err, dal.ErrUniqueViolation):
+		return constraintError(ErrAlreadyExists, wrapped, err)
+	case errors.Is(err, dal.ErrForeignKeyViolation):
+		return constraintError(ErrConflict, wrapped, err)
+	case errors.Is(err, dal.ErrCheckViolation):
+		return constraintError(ErrValidation, wrapped, err)
+	case errors.Is(err, pagination.ErrInvalidCursor):
+		return ErrValidation.WithCause(wrapped).WithDetails(ErrorFields{"cursor": pagination.ErrInvalidCursor.Error()})
+	}
+
+	return wrapped
+}
+
+// constraintError names the violated constraint in the fields of base.
+func constraintError(base *AppError, wrapped, err error) *AppError {
+	appErr := base.WithCause(wrapped)
+
+	var dalErr *dal.DALError
+	if errors.As(err, &dalErr) && dalErr.Constraint != "" {
+		appErr.Fields = ErrorFields{"constraint": dalErr.Constraint}
+	}
+
+	return appErr
+}
@@ -0,0 +1,75 @@
+package apperror
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/Amore14rn/888Starz_test/internal/dal"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWrapTranslatesDALErrors(t *testing.T) {
+	tests := map[string]struct {
+		err        error
+		want       *AppError
+		constraint string
+	}{
+		"no rows": {
+			err:  dal.FromPg(pgx.ErrNoRows),
+			want: ErrNotFound,
+		},
+		"unique violation": {
+			err:        dal.FromPg(&pgconn.PgError{Code: dal.CodeUniqueViolation, ConstraintName: "users_pkey"}),
+			want:       ErrAlreadyExists,
+			constraint: "users_pkey",
+		},
+		"foreign key violation": {
+			err:        dal.FromPg(&pgconn.PgError{Code: dal.CodeForeignKeyViolation, ConstraintName: "order_products_product_id_fkey"}),
+			want:       ErrConflict,
+			constraint: "order_products_product_id_fkey",
+		},
+		"version mismatch": {
+			err:  dal.ErrVersionMismatch,
+			want: ErrPrecondition,
+		},
+		"check violation": {
+			err:        dal.FromPg(&pgconn.PgError{Code: dal.CodeCheckViolation, ConstraintName: "products_quantity_non_negative"}),
+			want:       ErrValidation,
+			constraint: "products_quantity_non_negative",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := Wrap(tt.err, "Error when doing something")
+
+			var appErr *AppError
+			if assert.ErrorAs(t, err, &appErr) {
+				assert.Equal(t, tt.want.Code, appErr.Code)
+				assert.Equal(t, tt.want.TransportCode, appErr.TransportCode)
+				if tt.constraint != "" {
+					assert.Equal(t, tt.constraint, appErr.Fields["constraint"])
+				}
+			}
+			assert.ErrorIs(t, err, tt.want)
+			assert.ErrorIs(t, err, tt.err, "the DAL error stays reachable")
+			assert.Empty(t, tt.want.Fields, "shared error must not be mutated")
+		})
+	}
+}
+
+func TestWrapKeepsOtherErrors(t *testing.T) {
+	cause := errors.New("boom")
+
+	err := Wrap(cause, "Error when doing something")
+	assert.ErrorIs(t, err, cause)
+	assert.False(t, errors.As(err, new(*AppError)))
+
+	assert.Same(t, ErrForbidden, Wrap(ErrForbidden, "Error when doing something"))
+	assert.NoError(t, Wrap(nil, "Error when doing something"))
+
+	assert.Equal(t, http.StatusNotFound, ErrNotFound.WithCause(cause).TransportCode)
+}
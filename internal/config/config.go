@@ -11,9 +11,16 @@ import (
 )
 
 type Config struct {
-	IsDevelopment bool     `yaml:"is-development" env:"IS-DEVELOPMENT" env-default:"false"`
-	Server        Server   `yaml:"server"`
-	Postgres      Postgres `yaml:"postgres"`
+	IsDevelopment bool        `yaml:"is-development" env:"IS-DEVELOPMENT" env-default:"false"`
+	Server        Server      `yaml:"server"`
+	Postgres      Postgres    `yaml:"postgres"`
+	Password      Password    `yaml:"password"`
+	Auth          Auth        `yaml:"auth"`
+	Cart          Cart        `yaml:"cart"`
+	Idempotency   Idempotency `yaml:"idempotency"`
+	Outbox        Outbox      `yaml:"outbox"`
+	Webhooks      Webhooks    `yaml:"webhooks"`
+	SoftDelete    SoftDelete  `yaml:"soft_delete"`
 }
 
 type Server struct {
@@ -25,11 +32,65 @@ type Server struct {
 }
 
 type Postgres struct {
-	Host     string `yaml:"host"`
-	Port     string `yaml:"port"`
-	User     string `yaml:"user"`
-	Password string `yaml:"password"`
-	Database string `yaml:"database"`
+	Host        string `yaml:"host"`
+	Port        string `yaml:"port"`
+	User        string `yaml:"user"`
+	Password    string `yaml:"password"`
+	Database    string `yaml:"database"`
+	AutoMigrate bool   `yaml:"auto_migrate" env:"POSTGRES_AUTO_MIGRATE" env-default:"false"`
+	TxRetries   int    `yaml:"tx_retries" env:"POSTGRES_TX_RETRIES" env-default:"3"`
+}
+
+type Password struct {
+	Algorithm         string `yaml:"algorithm" env:"PASSWORD_ALGORITHM" env-default:"bcrypt"`
+	BcryptCost        int    `yaml:"bcrypt_cost" env:"PASSWORD_BCRYPT_COST" env-default:"10"`
+	Argon2Memory      uint32 `yaml:"argon2_memory" env:"PASSWORD_ARGON2_MEMORY" env-default:"65536"`
+	Argon2Iterations  uint32 `yaml:"argon2_iterations" env:"PASSWORD_ARGON2_ITERATIONS" env-default:"3"`
+	Argon2Parallelism uint8  `yaml:"argon2_parallelism" env:"PASSWORD_ARGON2_PARALLELISM" env-default:"2"`
+}
+
+type Auth struct {
+	Algorithm       string        `yaml:"algorithm" env:"AUTH_ALGORITHM" env-default:"HS256"`
+	Secret          string        `yaml:"secret" env:"AUTH_SECRET"`
+	PrivateKey      string        `yaml:"private_key" env:"AUTH_PRIVATE_KEY"`
+	Issuer          string        `yaml:"issuer" env:"AUTH_ISSUER" env-default:"888starz"`
+	AccessTokenTTL  time.Duration `yaml:"access_token_ttl" env:"AUTH_ACCESS_TOKEN_TTL" env-default:"15m"`
+	RefreshTokenTTL time.Duration `yaml:"refresh_token_ttl" env:"AUTH_REFRESH_TOKEN_TTL" env-default:"720h"`
+}
+
+type Cart struct {
+	TTL           time.Duration `yaml:"ttl" env:"CART_TTL" env-default:"72h"`
+	SweepInterval time.Duration `yaml:"sweep_interval" env:"CART_SWEEP_INTERVAL" env-default:"10m"`
+}
+
+type Idempotency struct {
+	TTL           time.Duration `yaml:"ttl" env:"IDEMPOTENCY_TTL" env-default:"24h"`
+	Lease         time.Duration `yaml:"lease" env:"IDEMPOTENCY_LEASE" env-default:"1m"`
+	SweepInterval time.Duration `yaml:"sweep_interval" env:"IDEMPOTENCY_SWEEP_INTERVAL" env-default:"1h"`
+}
+
+// Outbox configures the relay of domain events to webhook subscriptions and,
+// when WebhookURL is set, to that URL as well.
+type Outbox struct {
+	WebhookURL     string        `yaml:"webhook_url" env:"OUTBOX_WEBHOOK_URL"`
+	WebhookTimeout time.Duration `yaml:"webhook_timeout" env:"OUTBOX_WEBHOOK_TIMEOUT" env-default:"10s"`
+	Interval       time.Duration `yaml:"interval" env:"OUTBOX_INTERVAL" env-default:"1s"`
+	BatchSize      int           `yaml:"batch_size" env:"OUTBOX_BATCH_SIZE" env-default:"100"`
+	Lease          time.Duration `yaml:"lease" env:"OUTBOX_LEASE" env-default:"1m"`
+	MinBackoff     time.Duration `yaml:"min_backoff" env:"OUTBOX_MIN_BACKOFF" env-default:"1s"`
+	MaxBackoff     time.Duration `yaml:"max_backoff" env:"OUTBOX_MAX_BACKOFF" env-default:"10m"`
+	Retention      time.Duration `yaml:"retention" env:"OUTBOX_RETENTION" env-default:"168h"`
+	SweepInterval  time.Duration `yaml:"sweep_interval" env:"OUTBOX_SWEEP_INTERVAL" env-default:"1h"`
+}
+
+type Webhooks struct {
+	Interval    time.Duration `yaml:"interval" env:"WEBHOOKS_INTERVAL" env-default:"1s"`
+	BatchSize   int           `yaml:"batch_size" env:"WEBHOOKS_BATCH_SIZE" env-default:"50"`
+	Lease       time.Duration `yaml:"lease" env:"WEBHOOKS_LEASE" env-default:"1m"`
+	Timeout     time.Duration `yaml:"timeout" env:"WEBHOOKS_TIMEOUT" env-default:"10s"`
+	MaxAttempts int           `yaml:"max_attempts" env:"WEBHOOKS_MAX_ATTEMPTS" env-default:"10"`
+	MinBackoff  time.Duration `yaml:"min_backoff" env:"WEBHOOKS_MIN_BACKOFF" env-default:"10s"`
+	MaxBackoff  time.Duration `yaml:"max_backoff" env:"WEBHOOKS_MAX_BACKOFF" env-default:"1h"`
 }
 
 const (
@@ -80,3 +141,8 @@ func GetConfig() *Config {
 	})
 	return instance
 }
+
+type SoftDelete struct {
+	Retention     time.Duration `yaml:"retention" env:"SOFT_DELETE_RETENTION" env-default:"720h"`
+	PurgeInterval time.Duration `yaml:"purge_interval" env:"SOFT_DELETE_PURGE_INTERVAL" env-default:"24h"`
+}
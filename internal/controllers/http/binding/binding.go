@@ -0,0 +1,52 @@
+package binding
+
+import (
+	"github.com/Amore14rn/888Starz_test/internal/apperror"
+	"github.com/Amore14rn/888Starz_test/pkg/common/core/validator"
+	"github.com/gin-gonic/gin"
+	"time"
+)
+
+// DateFormat is the layout of fields with the "date" validation tag.
+const DateFormat = time.DateOnly
+
+// JSON decodes the request body into req and validates it together with the
+// extra validators (path parameters, for example). A body that can't be
+// decoded is reported as ErrBadRequest, invalid fields as one
+// validator.ValidationError listing all of them.
+func JSON(c *gin.Context, req interface{}, extra ...validator.Validator) error {
+	if err := c.ShouldBindJSON(req); err != nil {
+		return apperror.ErrBadRequest.WithCause(err)
+	}
+
+	return Validate(append([]validator.Validator{validator.StructValidator(req)}, extra...)...)
+}
+
+// Query decodes the query string into req and validates it like JSON does.
+func Query(c *gin.Context, req interface{}, extra ...validator.Validator) error {
+	if err := c.ShouldBindQuery(req); err != nil {
+		return apperror.ErrBadRequest.WithCause(err)
+	}
+
+	return Validate(append([]validator.Validator{validator.StructValidator(req)}, extra...)...)
+}
+
+// Validate runs validators as one chain.
+func Validate(validators ...validator.Validator) error {
+	return validator.ChainValidator(validators...).Validate()
+}
+
+// Date parses a date already checked by the "date" validation tag, an empty
+// value gives nil.
+func Date(value string) *time.Time {
+	if value == "" {
+		return nil
+	}
+
+	t, err := time.Parse(DateFormat, value)
+	if err != nil {
+		return nil
+	}
+
+	return &t
+}
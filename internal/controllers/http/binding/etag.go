@@ -0,0 +1,50 @@
+package binding
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/Amore14rn/888Starz_test/internal/apperror"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	HeaderETag    = "ETag"
+	HeaderIfMatch = "If-Match"
+)
+
+// ETag formats a row version as a strong entity tag, e.g. "3".
+func ETag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// SetETag sends the row version in the ETag header of the response.
+func SetETag(c *gin.Context, version int64) {
+	c.Header(HeaderETag, ETag(version))
+}
+
+// IfMatch returns the row version the client expects from the If-Match
+// header. A missing header (or "*", which would match any version) is
+// ErrNoPrecondition. A value that is not an ETag produced by ETag can't match
+// any version and is ErrPrecondition; weak tags never match because If-Match
+// uses the strong comparison.
+func IfMatch(c *gin.Context) (int64, error) {
+	value := strings.TrimSpace(c.GetHeader(HeaderIfMatch))
+	if value == "" || value == "*" {
+		return 0, apperror.ErrNoPrecondition
+	}
+
+	unquoted, ok := strings.CutPrefix(value, `"`)
+	if ok {
+		unquoted, ok = strings.CutSuffix(unquoted, `"`)
+	}
+
+	version, err := strconv.ParseInt(unquoted, 10, 64)
+	if !ok || err != nil || version < 1 {
+		return 0, apperror.ErrPrecondition.WithDetails(apperror.ErrorFields{
+			HeaderIfMatch: "does not match the current ETag",
+		})
+	}
+
+	return version, nil
+}
@@ -0,0 +1,48 @@
+package binding
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Amore14rn/888Starz_test/internal/apperror"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIfMatch(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := map[string]struct {
+		header  string
+		want    int64
+		wantErr error
+	}{
+		"etag":        {header: ETag(7), want: 7},
+		"missing":     {header: "", wantErr: apperror.ErrNoPrecondition},
+		"any":         {header: "*", wantErr: apperror.ErrNoPrecondition},
+		"weak":        {header: `W/"7"`, wantErr: apperror.ErrPrecondition},
+		"unquoted":    {header: "7", wantErr: apperror.ErrPrecondition},
+		"not version": {header: `"abc"`, wantErr: apperror.ErrPrecondition},
+		"zero":        {header: `"0"`, wantErr: apperror.ErrPrecondition},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(http.MethodPatch, "/", nil)
+			if tt.header != "" {
+				c.Request.Header.Set(HeaderIfMatch, tt.header)
+			}
+
+			version, err := IfMatch(c)
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, version)
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"strings"
+	"time"
+
+	"github.com/Amore14rn/888Starz_test/internal/apperror"
+	"github.com/Amore14rn/888Starz_test/pkg/common/auth"
+	"github.com/Amore14rn/888Starz_test/pkg/common/core/token"
+	"github.com/gin-gonic/gin"
+)
+
+const bearerPrefix = "Bearer "
+
+type TokenParser interface {
+	Parse(raw string, kind token.Kind, now time.Time) (token.Claims, error)
+}
+
+type Clock interface {
+	Now() time.Time
+}
+
+// Auth rejects requests without a valid access token and stores the
+// authenticated caller in the request context.
+func Auth(parser TokenParser, clock Clock) gin.HandlerFunc {
+	return authenticate(parser, clock, false)
+}
+
+// OptionalAuth lets anonymous requests through but still rejects an invalid
+// access token, so public routes can give more to authenticated callers.
+func OptionalAuth(parser TokenParser, clock Clock) gin.HandlerFunc {
+	return authenticate(parser, clock, true)
+}
+
+func authenticate(parser TokenParser, clock Clock, optional bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		header := c.GetHeader("Authorization")
+		if header == "" && optional {
+			c.Next()
+			return
+		}
+
+		if !strings.HasPrefix(header, bearerPrefix) {
+			_ = c.Error(apperror.ErrUnauthorized)
+			c.Abort()
+			return
+		}
+
+		claims, err := parser.Parse(strings.TrimPrefix(header, bearerPrefix), token.KindAccess, clock.Now())
+		if err != nil {
+			_ = c.Error(apperror.ErrUnauthorized.WithCause(err))
+			c.Abort()
+			return
+		}
+
+		ctx := auth.ContextWithPrincipal(c.Request.Context(), auth.Principal{
+			UserID: claims.Subject,
+			Role:   auth.Role(claims.Role),
+		})
+		c.Request = c.Request.WithContext(ctx)
+
+		c.Next()
+	}
+}
@@ -0,0 +1,132 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Amore14rn/888Starz_test/pkg/common/auth"
+	"github.com/Amore14rn/888Starz_test/pkg/common/core/token"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// staticParser accepts only the "good" token.
+type staticParser struct{}
+
+func (staticParser) Parse(raw string, _ token.Kind, _ time.Time) (token.Claims, error) {
+	if raw != "good" {
+		return token.Claims{}, errors.New("bad token")
+	}
+
+	return token.Claims{
+		RegisteredClaims: jwt.RegisteredClaims{Subject: "u1"},
+		Role:             string(auth.RoleAdmin),
+	}, nil
+}
+
+func TestOptionalAuth(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.Use(Errors())
+	router.GET("/", OptionalAuth(staticParser{}, &fixedClock{}), func(c *gin.Context) {
+		principal, ok := auth.PrincipalFromContext(c.Request.Context())
+		if !ok {
+			c.String(http.StatusOK, "anonymous")
+			return
+		}
+		c.String(http.StatusOK, principal.UserID)
+	})
+
+	tests := map[string]struct {
+		header   string
+		wantCode int
+		wantBody string
+	}{
+		"anonymous":     {header: "", wantCode: http.StatusOK, wantBody: "anonymous"},
+		"authenticated": {header: "Bearer good", wantCode: http.StatusOK, wantBody: "u1"},
+		"invalid token": {header: "Bearer bad", wantCode: http.StatusUnauthorized},
+		"not bearer":    {header: "Basic good", wantCode: http.StatusUnauthorized},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			rec := httptest.NewRecorder()
+
+			router.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.wantCode, rec.Code)
+			if tt.wantBody != "" {
+				assert.Equal(t, tt.wantBody, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestAuth(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	issuedAt := time.Date(2023, 9, 10, 12, 0, 0, 0, time.UTC)
+	manager, err := token.NewManager(token.Config{
+		Algorithm:  token.AlgorithmHS256,
+		Secret:     "secret",
+		Issuer:     "888starz",
+		AccessTTL:  time.Minute,
+		RefreshTTL: time.Hour,
+	})
+	require.NoError(t, err)
+
+	pair, err := manager.Issue("u1", string(auth.RoleManager), issuedAt)
+	require.NoError(t, err)
+
+	tests := map[string]struct {
+		header   string
+		now      time.Time
+		wantCode int
+		wantBody string
+	}{
+		"missing header": {now: issuedAt, wantCode: http.StatusUnauthorized},
+		"not bearer":     {header: "Basic " + pair.AccessToken, now: issuedAt, wantCode: http.StatusUnauthorized},
+		"bad token":      {header: "Bearer not-a-token", now: issuedAt, wantCode: http.StatusUnauthorized},
+		"refresh token":  {header: "Bearer " + pair.RefreshToken, now: issuedAt, wantCode: http.StatusUnauthorized},
+		"expired token":  {header: "Bearer " + pair.AccessToken, now: issuedAt.Add(2 * time.Minute), wantCode: http.StatusUnauthorized},
+		"valid token":    {header: "Bearer " + pair.AccessToken, now: issuedAt.Add(30 * time.Second), wantCode: http.StatusOK, wantBody: "u1 manager"},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			router := gin.New()
+			router.Use(Errors())
+			router.GET("/", Auth(manager, &fixedClock{now: tt.now}), func(c *gin.Context) {
+				principal, ok := auth.PrincipalFromContext(c.Request.Context())
+				if !ok {
+					c.String(http.StatusOK, "anonymous")
+					return
+				}
+				c.String(http.StatusOK, principal.UserID+" "+string(principal.Role))
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			rec := httptest.NewRecorder()
+
+			router.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.wantCode, rec.Code)
+			if tt.wantBody != "" {
+				assert.Equal(t, tt.wantBody, rec.Body.String())
+			}
+		})
+	}
+}
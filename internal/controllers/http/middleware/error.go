@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Amore14rn/888Starz_test/internal/apperror"
+	"github.com/Amore14rn/888Starz_test/pkg/common/core/validator"
+	"github.com/Amore14rn/888Starz_test/pkg/common/logging"
+	"github.com/Amore14rn/888Starz_test/pkg/tracing"
+	"github.com/gin-gonic/gin"
+)
+
+const contentTypeJSON = "application/json; charset=utf-8"
+
+// Errors renders the last error attached with c.Error as an AppError body
+// carrying its code, fields and the trace ID of the request. Errors that are
+// not AppErrors are reported to the client as ErrInternalSystem.
+func Errors() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+
+		ctx := c.Request.Context()
+		err := c.Errors.Last().Err
+		appErr := toAppError(err)
+
+		tracing.Error(ctx, err)
+		if appErr.TransportCode >= http.StatusInternalServerError {
+			logging.WithError(ctx, err).Error("request failed")
+		} else {
+			logging.WithError(ctx, err).Debug("request rejected")
+		}
+
+		c.Data(appErr.TransportCode, contentTypeJSON, appErr.Marshal(ctx))
+	}
+}
+
+func toAppError(err error) *apperror.AppError {
+	var appErr *apperror.AppError
+	if errors.As(err, &appErr) {
+		return appErr
+	}
+
+	var validationErr validator.ValidationError
+	if errors.As(err, &validationErr) {
+		return apperror.ErrValidation.WithCause(err).WithDetails(apperror.ErrorFields(validationErr.Fields))
+	}
+
+	return apperror.ErrInternalSystem.WithCause(err)
+}
@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Amore14rn/888Starz_test/internal/apperror"
+	"github.com/Amore14rn/888Starz_test/pkg/common/core/validator"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestErrors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := map[string]struct {
+		err        error
+		wantStatus int
+		wantCode   string
+		wantFields apperror.ErrorFields
+	}{
+		"app error": {
+			err:        apperror.ErrNotFound.WithCause(errors.New("no rows")),
+			wantStatus: http.StatusNotFound,
+			wantCode:   apperror.ErrNotFound.Code,
+		},
+		"validation error": {
+			err:        validator.ValidationError{Fields: validator.ErrorFields{"age": "too young"}},
+			wantStatus: http.StatusBadRequest,
+			wantCode:   apperror.ErrValidation.Code,
+			wantFields: apperror.ErrorFields{"age": "too young"},
+		},
+		"unknown error": {
+			err:        errors.New("connection refused"),
+			wantStatus: http.StatusInternalServerError,
+			wantCode:   apperror.ErrInternalSystem.Code,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			router := gin.New()
+			router.Use(Errors())
+			router.GET("/", func(c *gin.Context) {
+				_ = c.Error(tt.err)
+			})
+
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+
+			var body apperror.AppError
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+			assert.Equal(t, tt.wantCode, body.Code)
+			assert.Equal(t, tt.wantFields, body.Fields)
+			assert.NotContains(t, rec.Body.String(), "connection refused", "causes are not leaked to clients")
+		})
+	}
+}
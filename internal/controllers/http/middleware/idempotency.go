@@ -0,0 +1,170 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/Amore14rn/888Starz_test/internal/apperror"
+	"github.com/Amore14rn/888Starz_test/internal/domain/idempotency/model"
+	"github.com/Amore14rn/888Starz_test/pkg/common/auth"
+	"github.com/Amore14rn/888Starz_test/pkg/common/logging"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	HeaderIdempotencyKey = "Idempotency-Key"
+	HeaderReplayed       = "Idempotent-Replayed"
+
+	maxIdempotencyKeyLength = 255
+)
+
+type IdempotencyStore interface {
+	Reserve(ctx context.Context, key model.Key) (model.Record, bool, error)
+	Complete(ctx context.Context, key model.Key, response model.Response) error
+	Release(ctx context.Context, key model.Key) error
+}
+
+// Idempotency makes a route safe to retry when the client sends an
+// Idempotency-Key header. The first successful response is stored for ttl
+// and replayed to retries with the same key and body, reusing the key with a
+// different body is rejected with ErrKeyReused. Keys of failed requests are
+// released so that the client may retry them. Requests without the header
+// pass through.
+//
+// While the first request runs the key is only reserved for lease, a
+// reservation that was never completed, e.g. because the process died, is
+// given to the next request after that. lease has to exceed the time a
+// request may take.
+//
+// Keys are scoped by caller and route, so the middleware has to run after
+// Auth on authenticated routes.
+func Idempotency(store IdempotencyStore, clock Clock, ttl, lease time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(HeaderIdempotencyKey)
+		if key == "" {
+			c.Next()
+			return
+		}
+
+		if len(key) > maxIdempotencyKeyLength {
+			_ = c.Error(apperror.ErrValidation.WithDetails(apperror.ErrorFields{
+				HeaderIdempotencyKey: "must be at most 255 characters",
+			}))
+			c.Abort()
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			_ = c.Error(apperror.ErrBadRequest.WithCause(err))
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		ctx := c.Request.Context()
+		scope := idempotencyScope(c)
+		fp := fingerprint(c.Request, body)
+
+		reservation := model.NewKey(scope, key, fp, clock.Now(), lease, ttl)
+
+		record, reserved, err := store.Reserve(ctx, reservation)
+		if err != nil {
+			_ = c.Error(err)
+			c.Abort()
+			return
+		}
+
+		if !reserved {
+			replay(c, record, fp)
+			return
+		}
+
+		writer := &capturingWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+
+		c.Next()
+
+		// The client may have gone away meanwhile, which is what it retries
+		// for, the key must be settled anyway
+		ctx = context.WithoutCancel(ctx)
+
+		// Errors are rendered by the Errors middleware after this one returns,
+		// so the captured body of a failed request is never complete.
+		if len(c.Errors) > 0 || writer.Status() >= http.StatusInternalServerError {
+			if err = store.Release(ctx, reservation); err != nil {
+				logging.WithError(ctx, err).Error("failed to release idempotency key")
+			}
+			return
+		}
+
+		response := model.NewResponse(writer.Status(), writer.Header().Get("Content-Type"), writer.body.Bytes())
+		if err = store.Complete(ctx, reservation, response); err != nil {
+			logging.WithError(ctx, err).Error("failed to store idempotent response")
+		}
+	}
+}
+
+func replay(c *gin.Context, record model.Record, fingerprint string) {
+	if record.Fingerprint != fingerprint {
+		_ = c.Error(apperror.ErrKeyReused)
+		c.Abort()
+		return
+	}
+
+	if !record.Completed() {
+		_ = c.Error(apperror.ErrConflict.WithDetails(apperror.ErrorFields{
+			HeaderIdempotencyKey: "a request with this key is still in progress",
+		}))
+		c.Abort()
+		return
+	}
+
+	c.Header(HeaderReplayed, "true")
+	c.Data(record.Response.StatusCode, record.Response.ContentType, record.Response.Body)
+	c.Abort()
+}
+
+// idempotencyScope keeps keys of different callers and routes apart.
+func idempotencyScope(c *gin.Context) string {
+	var userID string
+	if principal, ok := auth.PrincipalFromContext(c.Request.Context()); ok {
+		userID = principal.UserID
+	}
+
+	return userID + " " + c.Request.Method + " " + c.FullPath()
+}
+
+func fingerprint(r *http.Request, body []byte) string {
+	h := sha256.New()
+	h.Write([]byte(r.Method))
+	h.Write([]byte{0})
+	h.Write([]byte(r.URL.RequestURI()))
+	h.Write([]byte{0})
+	h.Write(body)
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// capturingWriter keeps a copy of the response body so it can be stored.
+type capturingWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *capturingWriter) Write(b []byte) (int, error) {
+	w.body.Write(b)
+
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *capturingWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+
+	return w.ResponseWriter.WriteString(s)
+}
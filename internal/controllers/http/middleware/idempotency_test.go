@@ -0,0 +1,234 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Amore14rn/888Starz_test/internal/apperror"
+	"github.com/Amore14rn/888Starz_test/internal/dal"
+	"github.com/Amore14rn/888Starz_test/internal/domain/idempotency/model"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryStore mirrors IdempotencyDAO: a reservation holds the key until its
+// lease ends and is settled only by the request that made it.
+type memoryStore struct {
+	records map[string]model.Record
+	down    bool
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{records: map[string]model.Record{}}
+}
+
+func (s *memoryStore) Reserve(ctx context.Context, key model.Key) (model.Record, bool, error) {
+	if err := ctx.Err(); err != nil {
+		return model.Record{}, false, err
+	}
+
+	id := key.Scope + "|" + key.Key
+	if record, ok := s.records[id]; ok && record.ExpiresAt.After(key.CreatedAt) {
+		return record, false, nil
+	}
+
+	record := model.Record{
+		Scope:       key.Scope,
+		Key:         key.Key,
+		Fingerprint: key.Fingerprint,
+		CreatedAt:   key.CreatedAt,
+		ExpiresAt:   key.LeaseUntil,
+	}
+	s.records[id] = record
+
+	return record, true, nil
+}
+
+func (s *memoryStore) Complete(ctx context.Context, key model.Key, response model.Response) error {
+	record, err := s.reservation(ctx, key)
+	if err != nil {
+		return err
+	}
+
+	record.Response = &response
+	record.ExpiresAt = key.ExpiresAt
+	s.records[key.Scope+"|"+key.Key] = record
+
+	return nil
+}
+
+func (s *memoryStore) Release(ctx context.Context, key model.Key) error {
+	if _, err := s.reservation(ctx, key); err != nil {
+		return err
+	}
+
+	delete(s.records, key.Scope+"|"+key.Key)
+
+	return nil
+}
+
+func (s *memoryStore) reservation(ctx context.Context, key model.Key) (model.Record, error) {
+	if err := ctx.Err(); err != nil {
+		return model.Record{}, err
+	}
+	if s.down {
+		return model.Record{}, errors.New("store is down")
+	}
+
+	record, ok := s.records[key.Scope+"|"+key.Key]
+	if !ok || record.Completed() || !record.CreatedAt.Equal(key.CreatedAt) {
+		return model.Record{}, dal.ErrNotFound
+	}
+
+	return record, nil
+}
+
+type fixedClock struct {
+	now time.Time
+}
+
+func (c *fixedClock) Now() time.Time { return c.now }
+
+func TestIdempotency(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	store := newMemoryStore()
+	clock := &fixedClock{now: time.Date(2023, 9, 10, 12, 0, 0, 0, time.UTC)}
+
+	var calls int
+	router := gin.New()
+	router.Use(Errors())
+	router.POST("/orders", Idempotency(store, clock, time.Hour, time.Minute), func(c *gin.Context) {
+		calls++
+		if cancel, ok := c.Request.Context().Value(cancelKey{}).(context.CancelFunc); ok {
+			// The client disconnects before the handler is done
+			cancel()
+		}
+		if c.GetHeader("X-Fail") != "" {
+			_ = c.Error(apperror.ErrOutOfStock)
+			return
+		}
+		c.JSON(http.StatusCreated, gin.H{"call": calls})
+	})
+
+	send := func(key, body string, fail bool) *httptest.ResponseRecorder {
+		return serve(router, httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(body)), key, fail)
+	}
+
+	sendCancelled := func(key, body string, fail bool) *httptest.ResponseRecorder {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		ctx = context.WithValue(ctx, cancelKey{}, context.CancelFunc(cancel))
+
+		req := httptest.NewRequest(http.MethodPost, "/orders", strings.NewReader(body))
+
+		return serve(router, req.WithContext(ctx), key, fail)
+	}
+	first := send("k1", `{"a":1}`, false)
+	require.Equal(t, http.StatusCreated, first.Code)
+
+	t.Run("retry replays the first response", func(t *testing.T) {
+		rec := send("k1", `{"a":1}`, false)
+
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		assert.Equal(t, first.Body.String(), rec.Body.String())
+		assert.Equal(t, "true", rec.Header().Get(HeaderReplayed))
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("different body is rejected", func(t *testing.T) {
+		rec := send("k1", `{"a":2}`, false)
+
+		assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+		var body apperror.AppError
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+		assert.Equal(t, apperror.ErrKeyReused.Code, body.Code)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("failed request releases the key", func(t *testing.T) {
+		rec := send("k2", `{}`, true)
+		assert.Equal(t, http.StatusConflict, rec.Code)
+
+		rec = send("k2", `{}`, false)
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		assert.Empty(t, rec.Header().Get(HeaderReplayed))
+	})
+
+	t.Run("key is settled after the client went away", func(t *testing.T) {
+		sendCancelled("k3", `{}`, false)
+		before := calls
+
+		rec := send("k3", `{}`, false)
+
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		assert.Equal(t, "true", rec.Header().Get(HeaderReplayed))
+		assert.Equal(t, before, calls)
+
+		sendCancelled("k4", `{}`, true)
+
+		rec = send("k4", `{}`, false)
+
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		assert.Empty(t, rec.Header().Get(HeaderReplayed))
+		assert.Equal(t, before+2, calls)
+	})
+
+	t.Run("abandoned reservation is taken over after the lease", func(t *testing.T) {
+		store.down = true
+		send("k5", `{}`, false)
+		store.down = false
+
+		rec := send("k5", `{}`, false)
+		assert.Equal(t, http.StatusConflict, rec.Code)
+
+		clock.now = clock.now.Add(2 * time.Minute)
+
+		rec = send("k5", `{}`, false)
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		assert.Empty(t, rec.Header().Get(HeaderReplayed))
+
+		rec = send("k5", `{}`, false)
+		assert.Equal(t, "true", rec.Header().Get(HeaderReplayed))
+	})
+
+	t.Run("expired key is reserved again", func(t *testing.T) {
+		clock.now = clock.now.Add(2 * time.Hour)
+		before := calls
+
+		rec := send("k1", `{"a":2}`, false)
+
+		assert.Equal(t, http.StatusCreated, rec.Code)
+		assert.Equal(t, before+1, calls)
+	})
+
+	t.Run("no key passes through", func(t *testing.T) {
+		before := calls
+		send("", `{}`, false)
+		send("", `{}`, false)
+
+		assert.Equal(t, before+2, calls)
+	})
+}
+
+type cancelKey struct{}
+
+func serve(router *gin.Engine, req *http.Request, key string, fail bool) *httptest.ResponseRecorder {
+	if key != "" {
+		req.Header.Set(HeaderIdempotencyKey, key)
+	}
+	if fail {
+		req.Header.Set("X-Fail", "yes")
+	}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	return rec
+}
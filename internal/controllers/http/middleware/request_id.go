@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"github.com/Amore14rn/888Starz_test/pkg/common/core/requestid"
+	"github.com/gin-gonic/gin"
+)
+
+const maxRequestIDLength = 128
+
+type IdentityGenerator interface {
+	GenerateUUIDv4String() string
+}
+
+// RequestID keeps the X-Request-ID sent by the client, or generates one, and
+// stores it in the request context and the response header.
+func RequestID(identity IdentityGenerator) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(requestid.Header)
+		if !validRequestID(id) {
+			id = identity.GenerateUUIDv4String()
+		}
+
+		c.Request = c.Request.WithContext(requestid.NewContext(c.Request.Context(), id))
+		c.Header(requestid.Header, id)
+
+		c.Next()
+	}
+}
+
+// validRequestID accepts printable ASCII only, so the ID is safe to log and
+// to echo back in a header.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Amore14rn/888Starz_test/pkg/common/core/requestid"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+type staticIdentity string
+
+func (s staticIdentity) GenerateUUIDv4String() string { return string(s) }
+
+func TestRequestID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.Use(RequestID(staticIdentity("generated")))
+	router.GET("/", func(c *gin.Context) {
+		c.String(http.StatusOK, requestid.FromContext(c.Request.Context()))
+	})
+
+	tests := map[string]struct {
+		header string
+		want   string
+	}{
+		"kept":      {header: "abc-123", want: "abc-123"},
+		"missing":   {header: "", want: "generated"},
+		"too long":  {header: strings.Repeat("a", maxRequestIDLength+1), want: "generated"},
+		"not ascii": {header: "id with spaces", want: "generated"},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.header != "" {
+				req.Header.Set(requestid.Header, tt.header)
+			}
+			rec := httptest.NewRecorder()
+
+			router.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.want, rec.Body.String())
+			assert.Equal(t, tt.want, rec.Header().Get(requestid.Header))
+		})
+	}
+}
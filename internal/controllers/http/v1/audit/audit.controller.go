@@ -0,0 +1,36 @@
+package audit
+
+import (
+	"github.com/Amore14rn/888Starz_test/internal/controllers/http/binding"
+	"github.com/Amore14rn/888Starz_test/internal/domain/policy/audit"
+	"github.com/gin-gonic/gin"
+
+	"net/http"
+)
+
+type AuditHandler struct {
+	policy *audit.Policy
+}
+
+func NewAuditHandler(policy *audit.Policy) *AuditHandler {
+	return &AuditHandler{
+		policy: policy,
+	}
+}
+
+func (h *AuditHandler) All(c *gin.Context) {
+	var req AllEntriesRequest
+
+	if err := binding.Query(c, &req); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	allOutput, err := h.policy.All(c.Request.Context(), req.ToInput())
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"entries": allOutput.Entries, "next_cursor": allOutput.NextCursor})
+}
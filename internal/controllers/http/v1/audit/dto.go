@@ -0,0 +1,37 @@
+package audit
+
+import (
+	"github.com/Amore14rn/888Starz_test/internal/controllers/http/binding"
+	"github.com/Amore14rn/888Starz_test/internal/domain/audit/model"
+	"github.com/Amore14rn/888Starz_test/internal/domain/policy/audit"
+)
+
+// AllEntriesRequest filters the audit log. To is inclusive, the whole day is
+// part of the range.
+type AllEntriesRequest struct {
+	Entity string `form:"entity" json:"entity" validate:"omitempty,oneof=product user order"`
+	ID     string `form:"id" json:"id" validate:"omitempty,max=255"`
+	From   string `form:"from" json:"from" validate:"omitempty,date"`
+	To     string `form:"to" json:"to" validate:"omitempty,date"`
+	Limit  int    `form:"limit" json:"limit" validate:"omitempty,min=1,max=100"`
+	Cursor string `form:"cursor" json:"cursor" validate:"omitempty,max=512"`
+	Sort   string `form:"sort" json:"sort" validate:"omitempty,oneof=created_at -created_at"`
+}
+
+func (r AllEntriesRequest) ToInput() audit.AllEntriesInput {
+	input := audit.AllEntriesInput{
+		EntityType: model.EntityType(r.Entity),
+		EntityID:   r.ID,
+		From:       binding.Date(r.From),
+		Limit:      r.Limit,
+		Cursor:     r.Cursor,
+		Sort:       r.Sort,
+	}
+
+	if to := binding.Date(r.To); to != nil {
+		end := to.AddDate(0, 0, 1)
+		input.To = &end
+	}
+
+	return input
+}
@@ -0,0 +1,52 @@
+package auth
+
+import (
+	"github.com/Amore14rn/888Starz_test/internal/controllers/http/binding"
+	"github.com/Amore14rn/888Starz_test/internal/domain/policy/auth"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+type AuthHandler struct {
+	policy *auth.Policy
+}
+
+func NewAuthHandler(policy *auth.Policy) *AuthHandler {
+	return &AuthHandler{
+		policy: policy,
+	}
+}
+
+func (h *AuthHandler) Login(c *gin.Context) {
+	var req LoginRequest
+
+	if err := binding.JSON(c, &req); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	loginOutput, err := h.policy.Login(c.Request.Context(), req.ToInput())
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"tokens": loginOutput.Tokens})
+}
+
+func (h *AuthHandler) Refresh(c *gin.Context) {
+	var req RefreshRequest
+
+	if err := binding.JSON(c, &req); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	refreshOutput, err := h.policy.Refresh(c.Request.Context(), req.ToInput())
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"tokens": refreshOutput.Tokens})
+}
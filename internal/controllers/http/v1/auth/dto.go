@@ -0,0 +1,22 @@
+package auth
+
+import (
+	"github.com/Amore14rn/888Starz_test/internal/domain/policy/auth"
+)
+
+type LoginRequest struct {
+	ID       string `json:"id" validate:"required"`
+	Password string `json:"password" validate:"required"`
+}
+
+func (r LoginRequest) ToInput() auth.LoginInput {
+	return auth.NewLoginInput(r.ID, r.Password)
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+func (r RefreshRequest) ToInput() auth.RefreshInput {
+	return auth.NewRefreshInput(r.RefreshToken)
+}
@@ -0,0 +1,82 @@
+package cart
+
+import (
+	"github.com/Amore14rn/888Starz_test/internal/controllers/http/binding"
+	"github.com/Amore14rn/888Starz_test/internal/domain/policy/cart"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+type CartHandler struct {
+	policy *cart.Policy
+}
+
+func NewCartHandler(policy *cart.Policy) *CartHandler {
+	return &CartHandler{
+		policy: policy,
+	}
+}
+
+func (h *CartHandler) GetCart(c *gin.Context) {
+	cartOutput, err := h.policy.GetCart(c.Request.Context())
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"cart": cartOutput.Cart})
+}
+
+func (h *CartHandler) AddItem(c *gin.Context) {
+	var req AddItemRequest
+
+	if err := binding.JSON(c, &req); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	cartOutput, err := h.policy.AddItem(c.Request.Context(), req.ToInput())
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"cart": cartOutput.Cart})
+}
+
+func (h *CartHandler) UpdateItem(c *gin.Context) {
+	var req UpdateItemRequest
+
+	if err := binding.JSON(c, &req); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	cartOutput, err := h.policy.UpdateItem(c.Request.Context(), req.ToInput(c.Param("product_id")))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"cart": cartOutput.Cart})
+}
+
+func (h *CartHandler) RemoveItem(c *gin.Context) {
+	cartOutput, err := h.policy.RemoveItem(c.Request.Context(), cart.NewRemoveItemInput(c.Param("product_id")))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"cart": cartOutput.Cart})
+}
+
+func (h *CartHandler) Checkout(c *gin.Context) {
+	checkoutOutput, err := h.policy.Checkout(c.Request.Context())
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"order": checkoutOutput.Order})
+}
@@ -0,0 +1,22 @@
+package cart
+
+import (
+	"github.com/Amore14rn/888Starz_test/internal/domain/policy/cart"
+)
+
+type AddItemRequest struct {
+	ProductID string `json:"product_id" validate:"required,max=255"`
+	Quantity  int    `json:"quantity" validate:"gt=0,max=1000"`
+}
+
+func (r AddItemRequest) ToInput() cart.AddItemInput {
+	return cart.NewAddItemInput(r.ProductID, r.Quantity)
+}
+
+type UpdateItemRequest struct {
+	Quantity int `json:"quantity" validate:"gt=0,max=1000"`
+}
+
+func (r UpdateItemRequest) ToInput(productID string) cart.UpdateItemInput {
+	return cart.NewUpdateItemInput(productID, r.Quantity)
+}
@@ -0,0 +1,96 @@
+package order
+
+import (
+	"github.com/Amore14rn/888Starz_test/internal/controllers/http/binding"
+	"github.com/Amore14rn/888Starz_test/internal/domain/orders/model"
+	"github.com/Amore14rn/888Starz_test/internal/domain/policy/orders"
+)
+
+type CreateOrderRequest struct {
+	UserID   string             `json:"user_id" validate:"omitempty,uuid"`
+	Products []OrderLineRequest `json:"products" validate:"required,min=1,unique=ProductID,dive"`
+}
+
+type OrderLineRequest struct {
+	ProductID string `json:"product_id" validate:"required"`
+	Quantity  int    `json:"quantity" validate:"gt=0"`
+}
+
+func (r CreateOrderRequest) ToInput() orders.CreateOrderInput {
+	products := make([]model.OrderProduct, len(r.Products))
+	for i, line := range r.Products {
+		products[i] = model.OrderProduct{
+			ProductID: line.ProductID,
+			Quantity:  line.Quantity,
+		}
+	}
+
+	return orders.NewCreateOrderInput(r.UserID, products)
+}
+
+type TransitionOrderRequest struct {
+	Status string `json:"status" validate:"required,oneof=pending paid shipped delivered cancelled"`
+	Reason string `json:"reason" validate:"max=500"`
+}
+
+func (r TransitionOrderRequest) ToInput(id string) orders.TransitionOrderInput {
+	return orders.NewTransitionOrderInput(id, model.Status(r.Status), r.Reason)
+}
+
+// CancelOrderRequest cancels the listed lines, or the whole order when Lines
+// is empty. Quantity is the total number of units of the line to cancel.
+type CancelOrderRequest struct {
+	Lines  []CancelLineRequest `json:"lines" validate:"unique=ProductID,dive"`
+	Reason string              `json:"reason" validate:"max=500"`
+}
+
+type CancelLineRequest struct {
+	ProductID string `json:"product_id" validate:"required"`
+	Quantity  int    `json:"quantity" validate:"gt=0"`
+}
+
+func (r CancelOrderRequest) ToInput(id string) orders.CancelOrderInput {
+	lines := make([]model.CancelLine, len(r.Lines))
+	for i, line := range r.Lines {
+		lines[i] = model.CancelLine{
+			ProductID: line.ProductID,
+			Quantity:  line.Quantity,
+		}
+	}
+
+	return orders.NewCancelOrderInput(id, lines, r.Reason)
+}
+
+// UserOrdersRequest filters the order history. To is inclusive, the whole
+// day is part of the range.
+type UserOrdersRequest struct {
+	Statuses []string `form:"status" json:"status" validate:"dive,oneof=pending paid shipped delivered cancelled"`
+	From     string   `form:"from" json:"from" validate:"omitempty,date"`
+	To       string   `form:"to" json:"to" validate:"omitempty,date"`
+	Limit    int      `form:"limit" json:"limit" validate:"omitempty,min=1,max=100"`
+	Cursor   string   `form:"cursor" json:"cursor" validate:"omitempty,max=512"`
+	Sort     string   `form:"sort" json:"sort" validate:"omitempty,oneof=created_at -created_at"`
+}
+
+func (r UserOrdersRequest) ToInput(userID string) orders.UserOrdersInput {
+	statuses := make([]model.Status, len(r.Statuses))
+	for i, status := range r.Statuses {
+		statuses[i] = model.Status(status)
+	}
+
+	input := orders.UserOrdersInput{
+		UserID:   userID,
+		Statuses: statuses,
+		From:     binding.Date(r.From),
+		Limit:    r.Limit,
+		Cursor:   r.Cursor,
+		Sort:     r.Sort,
+	}
+
+	if to := binding.Date(r.To); to != nil {
+		end := to.AddDate(0, 0, 1)
+		input.To = &end
+	}
+
+	return input
+}
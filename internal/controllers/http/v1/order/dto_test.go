@@ -0,0 +1,60 @@
+package order
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/Amore14rn/888Starz_test/pkg/common/core/validator"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMain(m *testing.M) {
+	if err := validator.New(time.DateOnly); err != nil {
+		panic(err)
+	}
+
+	os.Exit(m.Run())
+}
+
+func TestCreateOrderRequestRejectsInvalidLines(t *testing.T) {
+	tests := map[string]struct {
+		lines []OrderLineRequest
+		field string
+	}{
+		"empty order":       {lines: nil, field: "products"},
+		"missing product":   {lines: []OrderLineRequest{{Quantity: 1}}, field: "products[0].product_id"},
+		"zero quantity":     {lines: []OrderLineRequest{{ProductID: "p1"}}, field: "products[0].quantity"},
+		"duplicate product": {lines: []OrderLineRequest{{ProductID: "p1", Quantity: 1}, {ProductID: "p1", Quantity: 2}}, field: "products"},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := validator.StructValidator(CreateOrderRequest{Products: tt.lines}).Validate()
+
+			var vErr validator.ValidationError
+			require.ErrorAs(t, err, &vErr)
+			assert.Contains(t, vErr.Fields, tt.field)
+		})
+	}
+}
+
+func TestCreateOrderRequestListsAllInvalidFields(t *testing.T) {
+	req := CreateOrderRequest{
+		UserID: "not-a-uuid",
+		Products: []OrderLineRequest{
+			{ProductID: "p1", Quantity: 1},
+			{Quantity: -1},
+		},
+	}
+
+	err := validator.StructValidator(req).Validate()
+
+	var vErr validator.ValidationError
+	require.ErrorAs(t, err, &vErr)
+	assert.Len(t, vErr.Fields, 3)
+	assert.Contains(t, vErr.Fields, "user_id")
+	assert.Contains(t, vErr.Fields, "products[1].product_id")
+	assert.Contains(t, vErr.Fields, "products[1].quantity")
+}
@@ -0,0 +1,110 @@
+package order
+
+import (
+	"github.com/Amore14rn/888Starz_test/internal/controllers/http/binding"
+	"github.com/Amore14rn/888Starz_test/internal/domain/policy/orders"
+	"github.com/Amore14rn/888Starz_test/pkg/common/core/validator"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+type OrderHandler struct {
+	policy *orders.Policy
+}
+
+func NewOrderHandler(policy *orders.Policy) *OrderHandler {
+	return &OrderHandler{
+		policy: policy,
+	}
+}
+
+func (h *OrderHandler) CreateOrder(c *gin.Context) {
+	var req CreateOrderRequest
+
+	if err := binding.JSON(c, &req); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	orderOutput, err := h.policy.CreateOrder(c.Request.Context(), req.ToInput())
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"order": orderOutput.Order})
+}
+
+func (h *OrderHandler) GetOrder(c *gin.Context) {
+	id := c.Param("id")
+
+	if err := binding.Validate(validator.UUIDValidator("id", id)); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	orderOutput, err := h.policy.GetOrder(c.Request.Context(), orders.NewGetOrderInput(id))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"order": orderOutput.Order})
+}
+
+func (h *OrderHandler) TransitionOrder(c *gin.Context) {
+	var req TransitionOrderRequest
+
+	id := c.Param("id")
+
+	if err := binding.JSON(c, &req, validator.UUIDValidator("id", id)); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	transitionOutput, err := h.policy.TransitionOrder(c.Request.Context(), req.ToInput(id))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"transition": transitionOutput.Change})
+}
+
+func (h *OrderHandler) CancelOrder(c *gin.Context) {
+	var req CancelOrderRequest
+
+	id := c.Param("id")
+
+	if err := binding.JSON(c, &req, validator.UUIDValidator("id", id)); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	cancelOutput, err := h.policy.CancelOrder(c.Request.Context(), req.ToInput(id))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"order": cancelOutput.Order})
+}
+
+func (h *OrderHandler) UserOrders(c *gin.Context) {
+	var req UserOrdersRequest
+
+	id := c.Param("id")
+
+	if err := binding.Query(c, &req, validator.UUIDValidator("id", id)); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	ordersOutput, err := h.policy.UserOrders(c.Request.Context(), req.ToInput(id))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"orders": ordersOutput.Orders, "next_cursor": ordersOutput.NextCursor})
+}
@@ -0,0 +1,95 @@
+package product
+
+import (
+	"github.com/Amore14rn/888Starz_test/internal/controllers/http/binding"
+	"github.com/Amore14rn/888Starz_test/internal/domain/policy/products"
+	"github.com/Amore14rn/888Starz_test/pkg/common/core/patch"
+	"github.com/Amore14rn/888Starz_test/pkg/common/core/validator"
+)
+
+type CreateProductRequest struct {
+	ID          string   `json:"id" validate:"omitempty,max=255"`
+	Description string   `json:"description" validate:"required,max=255"`
+	Quantity    int      `json:"quantity" validate:"gte=0"`
+	Price       float64  `json:"price" validate:"gte=0"`
+	Tags        []string `json:"tags" validate:"dive,max=64"`
+}
+
+func (r CreateProductRequest) ToInput() products.CreateProductInput {
+	return products.NewCreateProductInput(r.ID, r.Description, r.Quantity, r.Price, r.Tags)
+}
+
+// UpdateProductRequest is a JSON Merge Patch (RFC 7396) of a product: absent
+// members are left as they are, null tags remove all tags.
+type UpdateProductRequest struct {
+	ID          string                `json:"id" validate:"required,max=255"`
+	Description patch.Field[string]   `json:"description" validate:"omitempty,min=1,max=255"`
+	Quantity    patch.Field[int]      `json:"quantity" validate:"omitempty,gte=0"`
+	Price       patch.Field[float64]  `json:"price" validate:"omitempty,gte=0"`
+	Tags        patch.Field[[]string] `json:"tags" validate:"omitempty,dive,max=64"`
+}
+
+// notNull rejects null for members that can't be removed.
+func (r *UpdateProductRequest) notNull() validator.Validator {
+	return validator.NotNullValidator(map[string]validator.Nullable{
+		"description": &r.Description,
+		"quantity":    &r.Quantity,
+		"price":       &r.Price,
+	})
+}
+
+func (r UpdateProductRequest) ToInput() products.UpdateProductInput {
+	tags := r.Tags.Ptr()
+	if r.Tags.IsNull() {
+		tags = &[]string{}
+	}
+
+	return products.NewUpdateProductInput(r.ID, r.Description.Ptr(), r.Quantity.Ptr(), r.Price.Ptr(), tags)
+}
+
+type AllProductsRequest struct {
+	Tags         []string `form:"tag" json:"tag" validate:"dive,max=64"`
+	Match        string   `form:"match" json:"match" validate:"omitempty,oneof=any all"`
+	MinQuantity  *int     `form:"min_quantity" json:"min_quantity" validate:"omitempty,gte=0"`
+	MaxQuantity  *int     `form:"max_quantity" json:"max_quantity" validate:"omitempty,gte=0"`
+	CreatedAfter string   `form:"created_after" json:"created_after" validate:"omitempty,date"`
+	Limit        int      `form:"limit" json:"limit" validate:"omitempty,min=1,max=100"`
+	Cursor       string   `form:"cursor" json:"cursor" validate:"omitempty,max=512"`
+	Sort         string   `form:"sort" json:"sort" validate:"omitempty,oneof=created_at -created_at"`
+	// Удалённые продукты видят только администраторы
+	IncludeDeleted bool `form:"include_deleted" json:"include_deleted"`
+}
+
+type GetProductRequest struct {
+	IncludeDeleted bool `form:"include_deleted" json:"include_deleted"`
+}
+
+func (r GetProductRequest) ToInput(id string) products.GetProductInput {
+	input := products.NewGetProductInput(id)
+	input.IncludeDeleted = r.IncludeDeleted
+
+	return input
+}
+
+func (r AllProductsRequest) ToInput() products.AllProductsInput {
+	input := products.NewAllProductsInput(r.Tags, r.Match)
+	input.MinQuantity = r.MinQuantity
+	input.MaxQuantity = r.MaxQuantity
+	input.CreatedAfter = binding.Date(r.CreatedAfter)
+	input.Limit = r.Limit
+	input.Cursor = r.Cursor
+	input.Sort = r.Sort
+	input.IncludeDeleted = r.IncludeDeleted
+
+	return input
+}
+
+type SearchProductsRequest struct {
+	Query  string `form:"q" json:"q" validate:"required,max=200"`
+	Limit  int    `form:"limit" json:"limit" validate:"omitempty,min=1,max=100"`
+	Offset int    `form:"offset" json:"offset" validate:"omitempty,gte=0,max=10000"`
+}
+
+func (r SearchProductsRequest) ToInput() products.SearchProductsInput {
+	return products.NewSearchProductsInput(r.Query, r.Limit, r.Offset)
+}
@@ -1,6 +1,7 @@
 package product
 
 import (
+	"github.com/Amore14rn/888Starz_test/internal/controllers/http/binding"
 	"github.com/Amore14rn/888Starz_test/internal/domain/policy/products"
 	"github.com/gin-gonic/gin"
 
@@ -18,16 +19,16 @@ func NewProductHandler(policy *products.Policy) *ProductHandler {
 }
 
 func (h *ProductHandler) CreateProduct(c *gin.Context) {
-	var input products.CreateProductInput
+	var req CreateProductRequest
 
-	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if err := binding.JSON(c, &req); err != nil {
+		_ = c.Error(err)
 		return
 	}
 
-	productOutput, err := h.policy.CreateProduct(c.Request.Context(), input)
+	productOutput, err := h.policy.CreateProduct(c.Request.Context(), req.ToInput())
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		_ = c.Error(err)
 		return
 	}
 
@@ -35,62 +36,121 @@ func (h *ProductHandler) CreateProduct(c *gin.Context) {
 }
 
 func (h *ProductHandler) All(c *gin.Context) {
-	products, err := h.policy.All(c.Request.Context())
+	var req AllProductsRequest
+
+	if err := binding.Query(c, &req); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	allOutput, err := h.policy.All(c.Request.Context(), req.ToInput())
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"products": allOutput.Products, "next_cursor": allOutput.NextCursor})
+}
+
+func (h *ProductHandler) Search(c *gin.Context) {
+	var req SearchProductsRequest
+
+	if err := binding.Query(c, &req); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	searchOutput, err := h.policy.Search(c.Request.Context(), req.ToInput())
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		_ = c.Error(err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"products": products})
+	c.JSON(http.StatusOK, gin.H{"results": searchOutput.Results})
 }
 
 func (h *ProductHandler) GetProduct(c *gin.Context) {
-	var input products.GetProductInput
+	var req GetProductRequest
 
-	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if err := binding.Query(c, &req); err != nil {
+		_ = c.Error(err)
 		return
 	}
 
-	productOutput, err := h.policy.GetProduct(c.Request.Context(), input)
+	productOutput, err := h.policy.GetProduct(c.Request.Context(), req.ToInput(c.Param("id")))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		_ = c.Error(err)
 		return
 	}
 
+	binding.SetETag(c, productOutput.Product.Version)
 	c.JSON(http.StatusOK, gin.H{"product": productOutput.Product})
 }
 
 func (h *ProductHandler) UpdateProduct(c *gin.Context) {
-	var input products.UpdateProductInput
+	var req UpdateProductRequest
+
+	version, err := binding.IfMatch(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
 
-	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if err = binding.JSON(c, &req, req.notNull()); err != nil {
+		_ = c.Error(err)
 		return
 	}
 
+	input := req.ToInput()
+	input.Version = version
+
 	productOutput, err := h.policy.UpdateProduct(c.Request.Context(), input)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		_ = c.Error(err)
 		return
 	}
 
+	binding.SetETag(c, productOutput.Product.Version)
 	c.JSON(http.StatusOK, gin.H{"product": productOutput.Product})
 }
 
 func (h *ProductHandler) DeleteProduct(c *gin.Context) {
-	var input products.DeleteProductInput
-
-	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	productOutput, err := h.policy.DeleteProduct(c.Request.Context(), products.NewDeleteProductInput(c.Param("id")))
+	if err != nil {
+		_ = c.Error(err)
 		return
 	}
 
-	productOutput, err := h.policy.DeleteProduct(c.Request.Context(), input)
+	c.JSON(http.StatusOK, gin.H{"product": productOutput.Product})
+}
+
+func (h *ProductHandler) RestoreProduct(c *gin.Context) {
+	productOutput, err := h.policy.RestoreProduct(c.Request.Context(), products.NewRestoreProductInput(c.Param("id")))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		_ = c.Error(err)
 		return
 	}
 
+	binding.SetETag(c, productOutput.Product.Version)
 	c.JSON(http.StatusOK, gin.H{"product": productOutput.Product})
 }
+
+func (h *ProductHandler) PriceHistory(c *gin.Context) {
+	historyOutput, err := h.policy.PriceHistory(c.Request.Context(), products.NewGetPriceHistoryInput(c.Param("id")))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"history": historyOutput.History})
+}
+
+func (h *ProductHandler) Tags(c *gin.Context) {
+	tagsOutput, err := h.policy.Tags(c.Request.Context())
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"tags": tagsOutput.Tags})
+}
@@ -1 +1,143 @@
 package product
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Amore14rn/888Starz_test/internal/apperror"
+	"github.com/Amore14rn/888Starz_test/internal/controllers/http/middleware"
+	"github.com/Amore14rn/888Starz_test/pkg/common/core/validator"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+
+	if err := validator.New(time.DateOnly); err != nil {
+		panic(err)
+	}
+
+	os.Exit(m.Run())
+}
+
+// newRouter serves the handler without a policy, requests that pass
+// validation would panic, so only rejected requests can be tested.
+func newRouter() *gin.Engine {
+	h := NewProductHandler(nil)
+
+	router := gin.New()
+	router.Use(middleware.Errors())
+	router.POST("/product/create", h.CreateProduct)
+	router.GET("/product/all", h.All)
+	router.GET("/product/search", h.Search)
+	router.PATCH("/product/update", h.UpdateProduct)
+
+	return router
+}
+
+func decodeError(t *testing.T, rec *httptest.ResponseRecorder) apperror.AppError {
+	t.Helper()
+
+	var body apperror.AppError
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+
+	return body
+}
+
+func TestCreateProductListsAllInvalidFields(t *testing.T) {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/product/create", strings.NewReader(`{"quantity": -1, "price": -1}`))
+	newRouter().ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	body := decodeError(t, rec)
+	assert.Equal(t, apperror.ErrValidation.Code, body.Code)
+	assert.Len(t, body.Fields, 3)
+	assert.Contains(t, body.Fields, "description")
+	assert.Contains(t, body.Fields, "quantity")
+	assert.Contains(t, body.Fields, "price")
+}
+
+func TestCreateProductMalformedBody(t *testing.T) {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/product/create", strings.NewReader(`{"quantity": "many"`))
+	newRouter().ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Equal(t, apperror.ErrBadRequest.Code, decodeError(t, rec).Code)
+}
+
+func TestAllRejectsUnknownMatch(t *testing.T) {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/product/all?tag=a&match=some", nil)
+	newRouter().ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, decodeError(t, rec).Fields, "match")
+}
+
+func TestAllRejectsInvalidPage(t *testing.T) {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/product/all?limit=500&sort=price&created_after=yesterday", nil)
+	newRouter().ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	body := decodeError(t, rec)
+	assert.Contains(t, body.Fields, "limit")
+	assert.Contains(t, body.Fields, "sort")
+	assert.Contains(t, body.Fields, "created_after")
+}
+
+func TestSearchRequiresQuery(t *testing.T) {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/product/search?limit=0&offset=-1", nil)
+	newRouter().ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	body := decodeError(t, rec)
+	assert.Contains(t, body.Fields, "q")
+	assert.Contains(t, body.Fields, "offset")
+}
+
+func TestUpdateProductRequiresIfMatch(t *testing.T) {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPatch, "/product/update", strings.NewReader(`{"id": "p1", "description": "d", "quantity": 1}`))
+	newRouter().ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusPreconditionRequired, rec.Code)
+	assert.Equal(t, apperror.ErrNoPrecondition.Code, decodeError(t, rec).Code)
+}
+
+func TestUpdateProductRejectsForeignETag(t *testing.T) {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPatch, "/product/update", strings.NewReader(`{"id": "p1", "description": "d", "quantity": 1}`))
+	req.Header.Set("If-Match", `W/"3"`)
+	newRouter().ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusPreconditionFailed, rec.Code)
+}
+
+func TestUpdateProductRejectsNullMembers(t *testing.T) {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPatch, "/product/update", strings.NewReader(`{"id": "p1", "description": null, "quantity": -1, "tags": null}`))
+	req.Header.Set("If-Match", `"3"`)
+	newRouter().ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	body := decodeError(t, rec)
+	assert.Equal(t, apperror.ErrorFields{
+		"description": "must not be null",
+		"quantity":    "field validation for 'quantity' failed on the 'gte=0' tag",
+	}, body.Fields)
+}
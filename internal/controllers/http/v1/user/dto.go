@@ -0,0 +1,101 @@
+package user
+
+import (
+	"github.com/Amore14rn/888Starz_test/internal/controllers/http/binding"
+	"github.com/Amore14rn/888Starz_test/internal/domain/policy/user"
+	"github.com/Amore14rn/888Starz_test/pkg/common/core/patch"
+	"github.com/Amore14rn/888Starz_test/pkg/common/core/validator"
+)
+
+// Passwords need at least 8 characters with a digit and an uppercase letter.
+type CreateUserRequest struct {
+	FirstName string `json:"first_name" validate:"required,max=255"`
+	LastName  string `json:"last_name" validate:"required,max=255"`
+	Age       uint32 `json:"age" validate:"gte=18"`
+	IsMarried bool   `json:"is_married"`
+	Password  string `json:"password" validate:"required,min=8,containsany=0123456789,containsany=ABCDEFGHIJKLMNOPQRSTUVWXYZ"`
+}
+
+func (r CreateUserRequest) ToInput() user.CreateUserInput {
+	return user.NewCreateUserInput(
+		r.FirstName,
+		r.LastName,
+		r.FirstName+" "+r.LastName,
+		r.Age,
+		r.IsMarried,
+		r.Password,
+	)
+}
+
+// UpdateUserRequest is a JSON Merge Patch (RFC 7396) of the caller's profile:
+// absent members are left as they are, the password only changes when given.
+type UpdateUserRequest struct {
+	ID        string              `json:"id" validate:"omitempty,uuid"`
+	FirstName patch.Field[string] `json:"first_name" validate:"omitempty,min=1,max=255"`
+	LastName  patch.Field[string] `json:"last_name" validate:"omitempty,min=1,max=255"`
+	Age       patch.Field[uint32] `json:"age" validate:"omitempty,gte=18"`
+	IsMarried patch.Field[bool]   `json:"is_married"`
+	Password  patch.Field[string] `json:"password" validate:"omitempty,min=8,containsany=0123456789,containsany=ABCDEFGHIJKLMNOPQRSTUVWXYZ"`
+}
+
+// notNull rejects null, none of the members can be removed.
+func (r *UpdateUserRequest) notNull() validator.Validator {
+	return validator.NotNullValidator(map[string]validator.Nullable{
+		"first_name": &r.FirstName,
+		"last_name":  &r.LastName,
+		"age":        &r.Age,
+		"is_married": &r.IsMarried,
+		"password":   &r.Password,
+	})
+}
+
+func (r UpdateUserRequest) ToInput() user.UpdateUserInput {
+	return user.NewUpdateUserInput(
+		r.ID,
+		r.FirstName.Ptr(),
+		r.LastName.Ptr(),
+		r.Age.Ptr(),
+		r.IsMarried.Ptr(),
+		r.Password.Ptr(),
+	)
+}
+
+type UpdateRoleRequest struct {
+	Role string `json:"role" validate:"required,oneof=customer manager admin"`
+}
+
+type AllUsersRequest struct {
+	IsMarried    *bool   `form:"is_married" json:"is_married"`
+	MinAge       *uint32 `form:"min_age" json:"min_age"`
+	MaxAge       *uint32 `form:"max_age" json:"max_age"`
+	CreatedAfter string  `form:"created_after" json:"created_after" validate:"omitempty,date"`
+	Limit        int     `form:"limit" json:"limit" validate:"omitempty,min=1,max=100"`
+	Cursor       string  `form:"cursor" json:"cursor" validate:"omitempty,max=512"`
+	Sort         string  `form:"sort" json:"sort" validate:"omitempty,oneof=created_at -created_at"`
+	// Only admins may list deleted users
+	IncludeDeleted bool `form:"include_deleted" json:"include_deleted"`
+}
+
+type GetUserRequest struct {
+	IncludeDeleted bool `form:"include_deleted" json:"include_deleted"`
+}
+
+func (r GetUserRequest) ToInput(id string) user.GetUserInput {
+	input := user.NewGetUserInput(id)
+	input.IncludeDeleted = r.IncludeDeleted
+
+	return input
+}
+
+func (r AllUsersRequest) ToInput() user.AllUsersInput {
+	return user.AllUsersInput{
+		IsMarried:      r.IsMarried,
+		MinAge:         r.MinAge,
+		MaxAge:         r.MaxAge,
+		CreatedAfter:   binding.Date(r.CreatedAfter),
+		Limit:          r.Limit,
+		Cursor:         r.Cursor,
+		Sort:           r.Sort,
+		IncludeDeleted: r.IncludeDeleted,
+	}
+}
@@ -1,7 +1,9 @@
 package user
 
 import (
+	"github.com/Amore14rn/888Starz_test/internal/controllers/http/binding"
 	"github.com/Amore14rn/888Starz_test/internal/domain/policy/user"
+	"github.com/Amore14rn/888Starz_test/pkg/common/core/validator"
 	"github.com/gin-gonic/gin"
 	"net/http"
 )
@@ -17,16 +19,16 @@ func NewUserHandler(policy *user.Policy) *UserHandler {
 }
 
 func (h *UserHandler) CreateUser(c *gin.Context) {
-	var input user.CreateUserInput
+	var req CreateUserRequest
 
-	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if err := binding.JSON(c, &req); err != nil {
+		_ = c.Error(err)
 		return
 	}
 
-	userOutput, err := h.policy.CreateUser(c.Request.Context(), input)
+	userOutput, err := h.policy.CreateUser(c.Request.Context(), req.ToInput())
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		_ = c.Error(err)
 		return
 	}
 
@@ -34,43 +36,50 @@ func (h *UserHandler) CreateUser(c *gin.Context) {
 }
 
 func (h *UserHandler) All(c *gin.Context) {
-	users, err := h.policy.All(c.Request.Context())
+	var req AllUsersRequest
+
+	if err := binding.Query(c, &req); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	allOutput, err := h.policy.All(c.Request.Context(), req.ToInput())
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		_ = c.Error(err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"users": users})
+	c.JSON(http.StatusOK, gin.H{"users": allOutput.Users, "next_cursor": allOutput.NextCursor})
 }
 
 func (h *UserHandler) GetUser(c *gin.Context) {
-	var input user.GetUserInput
+	var req GetUserRequest
+	id := c.Param("id")
 
-	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if err := binding.Validate(validator.UUIDValidator("id", id)); err != nil {
+		_ = c.Error(err)
 		return
 	}
 
-	userOutput, err := h.policy.GetUser(c.Request.Context(), input)
+	if err := binding.Query(c, &req); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	userOutput, err := h.policy.GetUser(c.Request.Context(), req.ToInput(id))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		_ = c.Error(err)
 		return
 	}
 
+	binding.SetETag(c, userOutput.User.Version)
 	c.JSON(http.StatusOK, gin.H{"user": userOutput.User})
 }
 
 func (h *UserHandler) GetUserByName(c *gin.Context) {
-	var input user.GetUserByNameInput
-
-	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-
-	userOutput, err := h.policy.GetUserByName(c.Request.Context(), input)
+	userOutput, err := h.policy.GetUserByName(c.Request.Context(), user.NewGetUsersInput(c.Param("name")))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		_ = c.Error(err)
 		return
 	}
 
@@ -78,52 +87,63 @@ func (h *UserHandler) GetUserByName(c *gin.Context) {
 }
 
 func (h *UserHandler) UpdateUser(c *gin.Context) {
-	var input user.UpdateUserInput
+	var req UpdateUserRequest
+
+	version, err := binding.IfMatch(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
 
-	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if err = binding.JSON(c, &req, req.notNull()); err != nil {
+		_ = c.Error(err)
 		return
 	}
 
+	input := req.ToInput()
+	input.Version = version
+
 	userOutput, err := h.policy.UpdateUser(c.Request.Context(), input)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		_ = c.Error(err)
 		return
 	}
 
+	binding.SetETag(c, userOutput.User.Version)
 	c.JSON(http.StatusOK, gin.H{"user": userOutput.User})
 }
 
 func (h *UserHandler) DeleteUser(c *gin.Context) {
-	var input user.DeleteUserInput
+	id := c.Param("id")
 
-	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if err := binding.Validate(validator.UUIDValidator("id", id)); err != nil {
+		_ = c.Error(err)
 		return
 	}
 
-	err := h.policy.DeleteUser(c.Request.Context(), input)
+	err := h.policy.DeleteUser(c.Request.Context(), user.NewDeleteUserInput(id))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		_ = c.Error(err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{})
 }
 
-func (h *UserHandler) CreateOrder(c *gin.Context) {
-	var input user.CreateOrderInput
+func (h *UserHandler) UpdateRole(c *gin.Context) {
+	var req UpdateRoleRequest
+	id := c.Param("id")
 
-	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if err := binding.JSON(c, &req, validator.UUIDValidator("id", id)); err != nil {
+		_ = c.Error(err)
 		return
 	}
 
-	orderOutput, err := h.policy.CreateOrder(c.Request.Context(), input)
+	roleOutput, err := h.policy.UpdateRole(c.Request.Context(), user.NewUpdateRoleInput(id, req.Role))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		_ = c.Error(err)
 		return
 	}
 
-	c.JSON(http.StatusCreated, gin.H{"order": orderOutput.Order})
+	c.JSON(http.StatusOK, gin.H{"user": roleOutput.User})
 }
@@ -0,0 +1,23 @@
+package webhook
+
+import (
+	"github.com/Amore14rn/888Starz_test/internal/domain/policy/webhooks"
+)
+
+type CreateSubscriptionRequest struct {
+	URL        string   `json:"url" validate:"required,http_url,max=2048"`
+	EventTypes []string `json:"event_types" validate:"required,min=1,dive,oneof=order.created order.status_changed product.stock_changed product.price_changed"`
+	Secret     string   `json:"secret" validate:"omitempty,min=16,max=255"`
+}
+
+func (r CreateSubscriptionRequest) ToInput() webhooks.CreateSubscriptionInput {
+	return webhooks.NewCreateSubscriptionInput(r.URL, r.EventTypes, r.Secret)
+}
+
+type DeliveriesRequest struct {
+	Limit int `form:"limit" json:"limit" validate:"omitempty,min=1,max=100"`
+}
+
+func (r DeliveriesRequest) ToInput(subscriptionID string) webhooks.DeliveriesInput {
+	return webhooks.NewDeliveriesInput(subscriptionID, r.Limit)
+}
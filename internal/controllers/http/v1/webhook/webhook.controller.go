@@ -0,0 +1,117 @@
+package webhook
+
+import (
+	"github.com/Amore14rn/888Starz_test/internal/apperror"
+	"github.com/Amore14rn/888Starz_test/internal/controllers/http/binding"
+	"github.com/Amore14rn/888Starz_test/internal/domain/policy/webhooks"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+)
+
+type WebhookHandler struct {
+	policy *webhooks.Policy
+}
+
+func NewWebhookHandler(policy *webhooks.Policy) *WebhookHandler {
+	return &WebhookHandler{
+		policy: policy,
+	}
+}
+
+func (h *WebhookHandler) CreateSubscription(c *gin.Context) {
+	var req CreateSubscriptionRequest
+
+	if err := binding.JSON(c, &req); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	subOutput, err := h.policy.CreateSubscription(c.Request.Context(), req.ToInput())
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"subscription": subOutput.Subscription})
+}
+
+func (h *WebhookHandler) Subscriptions(c *gin.Context) {
+	subsOutput, err := h.policy.Subscriptions(c.Request.Context())
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"subscriptions": subsOutput.Subscriptions})
+}
+
+func (h *WebhookHandler) DeleteSubscription(c *gin.Context) {
+	subOutput, err := h.policy.DeleteSubscription(c.Request.Context(), webhooks.NewDeleteSubscriptionInput(c.Param("id")))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"subscription": subOutput.Subscription})
+}
+
+func (h *WebhookHandler) Deliveries(c *gin.Context) {
+	var req DeliveriesRequest
+
+	if err := binding.Query(c, &req); err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	deliveriesOutput, err := h.policy.Deliveries(c.Request.Context(), req.ToInput(c.Param("id")))
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"deliveries": deliveriesOutput.Deliveries})
+}
+
+func (h *WebhookHandler) GetDelivery(c *gin.Context) {
+	input, err := deliveryInput(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	deliveryOutput, err := h.policy.GetDelivery(c.Request.Context(), input)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"delivery": deliveryOutput.Delivery})
+}
+
+func (h *WebhookHandler) Redeliver(c *gin.Context) {
+	input, err := deliveryInput(c)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	deliveryOutput, err := h.policy.Redeliver(c.Request.Context(), input)
+	if err != nil {
+		_ = c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{"delivery": deliveryOutput.Delivery})
+}
+
+func deliveryInput(c *gin.Context) (webhooks.DeliveryInput, error) {
+	id, err := strconv.ParseInt(c.Param("delivery_id"), 10, 64)
+	if err != nil || id <= 0 {
+		return webhooks.DeliveryInput{}, apperror.ErrValidation.WithDetails(apperror.ErrorFields{
+			"delivery_id": "must be a positive number",
+		})
+	}
+
+	return webhooks.NewDeliveryInput(c.Param("id"), id), nil
+}
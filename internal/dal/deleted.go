@@ -0,0 +1,18 @@
+package dal
+
+import "context"
+
+type withDeletedKey struct{}
+
+// WithDeleted returns a context in which reads also return soft deleted rows.
+// Only admins may ask for them, the policies check the role before calling it.
+func WithDeleted(ctx context.Context) context.Context {
+	return context.WithValue(ctx, withDeletedKey{}, true)
+}
+
+// DeletedIncluded reports whether reads in ctx return soft deleted rows.
+func DeletedIncluded(ctx context.Context) bool {
+	included, _ := ctx.Value(withDeletedKey{}).(bool)
+
+	return included
+}
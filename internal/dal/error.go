@@ -2,12 +2,23 @@ package dal
 
 import (
 	"github.com/Amore14rn/888Starz_test/pkg/errors"
+	psql "github.com/Amore14rn/888Starz_test/pkg/postgresql"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// SQLSTATE codes of the integrity constraint violations translated by FromPg.
+const (
+	CodeForeignKeyViolation = "23503"
+	CodeUniqueViolation     = "23505"
+	CodeCheckViolation      = "23514"
 )
 
 type DALError struct {
-	Err     error  `json:"-"`
-	Message string `json:"message,omitempty"`
-	Code    string `json:"code,omitempty"`
+	Err        error  `json:"-"`
+	Message    string `json:"message,omitempty"`
+	Code       string `json:"code,omitempty"`
+	Constraint string `json:"constraint,omitempty"`
 }
 
 func NewAppError(code, message string) *DALError {
@@ -24,6 +35,58 @@ func (e *DALError) Error() string {
 
 func (e *DALError) Unwrap() error { return e.Err }
 
+// Is matches any DALError with the same code, so errors returned by FromPg
+// satisfy errors.Is against the sentinels below.
+func (e *DALError) Is(target error) bool {
+	t, ok := target.(*DALError)
+
+	return ok && t.Code == e.Code
+}
+
 var ErrNotFound = errors.New("not found")
 var ErrNothingInserted = errors.New("nothing inserted")
 var ErrIntegrityConstraintViolation = errors.New("unique violation")
+
+// ErrVersionMismatch is returned by conditional updates when the row was
+// changed since the caller read the expected version.
+var ErrVersionMismatch = errors.New("version mismatch")
+
+var (
+	ErrUniqueViolation     = NewAppError(CodeUniqueViolation, "unique violation")
+	ErrForeignKeyViolation = NewAppError(CodeForeignKeyViolation, "foreign key violation")
+	ErrCheckViolation      = NewAppError(CodeCheckViolation, "check violation")
+)
+
+// FromPg translates driver errors into DAL errors: pgx.ErrNoRows becomes
+// ErrNotFound and integrity constraint violations become a DALError carrying
+// the SQLSTATE and the violated constraint. Other errors are returned in the
+// readable form produced by psql.ParsePgError.
+func FromPg(err error) error {
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ErrNotFound
+	}
+
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return err
+	}
+
+	var sentinel *DALError
+	switch pgErr.Code {
+	case CodeUniqueViolation:
+		sentinel = ErrUniqueViolation
+	case CodeForeignKeyViolation:
+		sentinel = ErrForeignKeyViolation
+	case CodeCheckViolation:
+		sentinel = ErrCheckViolation
+	default:
+		return psql.ParsePgError(pgErr)
+	}
+
+	return &DALError{
+		Err:        psql.ParsePgError(pgErr),
+		Message:    sentinel.Message,
+		Code:       sentinel.Code,
+		Constraint: pgErr.ConstraintName,
+	}
+}
@@ -1,8 +1,19 @@
 package postgres
 
 const (
-	ProductTable      = "public.product"
-	UserTable         = "public.users"
-	OrderTable        = "public.order"
-	OrderProductTable = "public.order_product"
+	ProductTable          = "public.products"
+	ProductHistoryTable   = "public.product_history"
+	UserTable             = "public.users"
+	OrderTable            = "public.orders"
+	OrderProductTable     = "public.order_products"
+	OrderStatusTable      = "public.order_status_history"
+	UserOrderProductTable = "public.user_orders_products"
+	CartTable             = "public.carts"
+	CartItemTable         = "public.cart_items"
+	IdempotencyKeyTable   = "public.idempotency_keys"
+	OutboxTable           = "public.outbox"
+	WebhookTable          = "public.webhook_subscriptions"
+	WebhookDeliveryTable  = "public.webhook_deliveries"
+	WebhookAttemptTable   = "public.webhook_delivery_attempts"
+	AuditLogTable         = "public.audit_log"
 )
@@ -0,0 +1,18 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/Amore14rn/888Starz_test/internal/dal"
+	sq "github.com/Masterminds/squirrel"
+)
+
+// NotDeleted hides rows with column set unless ctx was marked with
+// dal.WithDeleted.
+func NotDeleted(ctx context.Context, statement sq.SelectBuilder, column string) sq.SelectBuilder {
+	if dal.DeletedIncluded(ctx) {
+		return statement
+	}
+
+	return statement.Where(sq.Eq{column: nil})
+}
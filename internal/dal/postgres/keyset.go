@@ -0,0 +1,46 @@
+package postgres
+
+import (
+	"github.com/Amore14rn/888Starz_test/pkg/common/core/pagination"
+	sq "github.com/Masterminds/squirrel"
+)
+
+// Paginate restricts statement to the page described by params, using the
+// (created_at, id) keyset of the selected table. One extra row is requested
+// so that NextCursor can tell whether another page follows.
+func Paginate(statement sq.SelectBuilder, params pagination.Params) (sq.SelectBuilder, error) {
+	return PaginateBy(statement, params, "created_at", "id")
+}
+
+// PaginateBy is Paginate for tables whose keyset is (timeColumn, idColumn).
+func PaginateBy(statement sq.SelectBuilder, params pagination.Params, timeColumn, idColumn string) (sq.SelectBuilder, error) {
+	order, cmp := "ASC", ">"
+	if params.Descending() {
+		order, cmp = "DESC", "<"
+	}
+
+	if params.Cursor != "" {
+		cursor, err := pagination.DecodeCursor(params.Cursor)
+		if err != nil {
+			return statement, err
+		}
+
+		statement = statement.Where(sq.Expr("("+timeColumn+", "+idColumn+") "+cmp+" (?, ?)", cursor.CreatedAt, cursor.ID))
+	}
+
+	return statement.
+		OrderBy(timeColumn+" "+order, idColumn+" "+order).
+		Limit(uint64(params.Limit) + 1), nil
+}
+
+// NextCursor drops the extra row fetched by Paginate and returns the cursor
+// of the following page, or an empty string on the last page.
+func NextCursor[T any](rows []T, params pagination.Params, key func(T) pagination.Cursor) ([]T, string) {
+	if len(rows) <= params.Limit {
+		return rows, ""
+	}
+
+	rows = rows[:params.Limit]
+
+	return rows, key(rows[len(rows)-1]).Encode()
+}
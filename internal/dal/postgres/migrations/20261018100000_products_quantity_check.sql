@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+UPDATE public.products SET quantity = 0 WHERE quantity IS NULL OR quantity < 0;
+
+ALTER TABLE public.products
+    ALTER COLUMN quantity SET DEFAULT 0,
+    ALTER COLUMN quantity SET NOT NULL,
+    ADD CONSTRAINT products_quantity_non_negative CHECK (quantity >= 0);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE public.products
+    DROP CONSTRAINT products_quantity_non_negative,
+    ALTER COLUMN quantity DROP NOT NULL,
+    ALTER COLUMN quantity DROP DEFAULT;
+-- +goose StatementEnd
@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE public.products ADD COLUMN price DOUBLE PRECISION NOT NULL DEFAULT 0;
+
+ALTER TABLE public.products ADD CONSTRAINT products_price_non_negative CHECK (price >= 0);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE public.products DROP CONSTRAINT products_price_non_negative;
+
+ALTER TABLE public.products DROP COLUMN price;
+-- +goose StatementEnd
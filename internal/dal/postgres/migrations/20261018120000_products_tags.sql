@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE public.products ADD COLUMN tags TEXT[] NOT NULL DEFAULT '{}';
+
+CREATE INDEX products_tags_idx ON public.products USING GIN (tags);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX public.products_tags_idx;
+
+ALTER TABLE public.products DROP COLUMN tags;
+-- +goose StatementEnd
@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE public.users ADD COLUMN role TEXT NOT NULL DEFAULT 'customer';
+
+ALTER TABLE public.users ADD CONSTRAINT users_role_valid CHECK (role IN ('customer', 'manager', 'admin'));
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE public.users DROP CONSTRAINT users_role_valid;
+
+ALTER TABLE public.users DROP COLUMN role;
+-- +goose StatementEnd
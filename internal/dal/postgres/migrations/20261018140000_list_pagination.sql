@@ -0,0 +1,25 @@
+-- +goose Up
+-- +goose StatementBegin
+UPDATE public.products SET created_at = now() WHERE created_at IS NULL;
+ALTER TABLE public.products ALTER COLUMN created_at SET DEFAULT now();
+ALTER TABLE public.products ALTER COLUMN created_at SET NOT NULL;
+
+UPDATE public.users SET created_at = now() WHERE created_at IS NULL;
+ALTER TABLE public.users ALTER COLUMN created_at SET DEFAULT now();
+ALTER TABLE public.users ALTER COLUMN created_at SET NOT NULL;
+
+CREATE INDEX products_created_at_id_idx ON public.products (created_at, id);
+CREATE INDEX users_created_at_id_idx ON public.users (created_at, id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX users_created_at_id_idx;
+DROP INDEX products_created_at_id_idx;
+
+ALTER TABLE public.users ALTER COLUMN created_at DROP NOT NULL;
+ALTER TABLE public.users ALTER COLUMN created_at DROP DEFAULT;
+
+ALTER TABLE public.products ALTER COLUMN created_at DROP NOT NULL;
+ALTER TABLE public.products ALTER COLUMN created_at DROP DEFAULT;
+-- +goose StatementEnd
@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+-- array_to_string is only STABLE, generated columns need an IMMUTABLE expression.
+CREATE FUNCTION public.products_tags_text(tags TEXT[]) RETURNS TEXT
+    LANGUAGE sql IMMUTABLE PARALLEL SAFE
+AS $$ SELECT array_to_string(tags, ' ') $$;
+
+ALTER TABLE public.products ADD COLUMN search_vector TSVECTOR GENERATED ALWAYS AS (
+    setweight(to_tsvector('simple', coalesce(description, '')), 'A') ||
+    setweight(to_tsvector('simple', public.products_tags_text(tags)), 'B')
+) STORED;
+
+CREATE INDEX products_search_vector_idx ON public.products USING GIN (search_vector);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX public.products_search_vector_idx;
+
+ALTER TABLE public.products DROP COLUMN search_vector;
+
+DROP FUNCTION public.products_tags_text(TEXT[]);
+-- +goose StatementEnd
@@ -0,0 +1,28 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE public.orders ADD COLUMN status TEXT NOT NULL DEFAULT 'pending';
+
+ALTER TABLE public.orders ADD CONSTRAINT orders_status_valid
+    CHECK (status IN ('pending', 'paid', 'shipped', 'delivered', 'cancelled'));
+
+CREATE TABLE public.order_status_history (
+    id BIGSERIAL PRIMARY KEY,
+    order_id VARCHAR(255) NOT NULL REFERENCES public.orders(id) ON DELETE CASCADE,
+    from_status TEXT NOT NULL,
+    to_status TEXT NOT NULL,
+    changed_by VARCHAR(255) NOT NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    changed_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX order_status_history_order_id_idx ON public.order_status_history (order_id, changed_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE public.order_status_history;
+
+ALTER TABLE public.orders DROP CONSTRAINT orders_status_valid;
+
+ALTER TABLE public.orders DROP COLUMN status;
+-- +goose StatementEnd
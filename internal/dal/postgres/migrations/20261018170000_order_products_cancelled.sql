@@ -0,0 +1,14 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE public.order_products ADD COLUMN cancelled_quantity INTEGER NOT NULL DEFAULT 0;
+
+ALTER TABLE public.order_products ADD CONSTRAINT order_products_cancelled_quantity_valid
+    CHECK (cancelled_quantity >= 0 AND cancelled_quantity <= quantity);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE public.order_products DROP CONSTRAINT order_products_cancelled_quantity_valid;
+
+ALTER TABLE public.order_products DROP COLUMN cancelled_quantity;
+-- +goose StatementEnd
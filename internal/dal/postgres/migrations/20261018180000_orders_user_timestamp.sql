@@ -0,0 +1,9 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE INDEX orders_user_id_timestamp_idx ON public.orders (user_id, timestamp, id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX public.orders_user_id_timestamp_idx;
+-- +goose StatementEnd
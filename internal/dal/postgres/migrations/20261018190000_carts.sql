@@ -0,0 +1,27 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE public.carts (
+    id VARCHAR(255) PRIMARY KEY,
+    user_id VARCHAR(255) NOT NULL UNIQUE REFERENCES public.users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL,
+    expires_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX carts_expires_at_idx ON public.carts (expires_at);
+
+CREATE TABLE public.cart_items (
+    cart_id VARCHAR(255) NOT NULL REFERENCES public.carts(id) ON DELETE CASCADE,
+    product_id VARCHAR(255) NOT NULL REFERENCES public.products(id) ON DELETE CASCADE,
+    quantity INTEGER NOT NULL CONSTRAINT cart_items_quantity_positive CHECK (quantity > 0),
+    added_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (cart_id, product_id)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE public.cart_items;
+
+DROP TABLE public.carts;
+-- +goose StatementEnd
@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE public.products ADD COLUMN version BIGINT NOT NULL DEFAULT 1;
+
+ALTER TABLE public.users ADD COLUMN version BIGINT NOT NULL DEFAULT 1;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE public.users DROP COLUMN version;
+
+ALTER TABLE public.products DROP COLUMN version;
+-- +goose StatementEnd
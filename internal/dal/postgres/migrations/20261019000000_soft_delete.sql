@@ -0,0 +1,22 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE public.products ADD COLUMN deleted_at TIMESTAMP;
+
+ALTER TABLE public.users ADD COLUMN deleted_at TIMESTAMP;
+
+-- Only deleted rows are indexed, the purge job looks for old ones
+CREATE INDEX products_deleted_at_idx ON public.products (deleted_at) WHERE deleted_at IS NOT NULL;
+
+CREATE INDEX users_deleted_at_idx ON public.users (deleted_at) WHERE deleted_at IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX public.users_deleted_at_idx;
+
+DROP INDEX public.products_deleted_at_idx;
+
+ALTER TABLE public.users DROP COLUMN deleted_at;
+
+ALTER TABLE public.products DROP COLUMN deleted_at;
+-- +goose StatementEnd
@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE public.audit_log (
+    id BIGSERIAL PRIMARY KEY,
+    actor_id VARCHAR(255), -- NULL for anonymous callers and background jobs
+    action VARCHAR(32) NOT NULL,
+    entity_type VARCHAR(32) NOT NULL,
+    entity_id VARCHAR(255) NOT NULL,
+    diff JSONB NOT NULL,
+    request_id VARCHAR(128),
+    trace_id VARCHAR(32),
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX audit_log_entity_idx ON public.audit_log (entity_type, entity_id, created_at, id);
+
+CREATE INDEX audit_log_created_at_idx ON public.audit_log (created_at, id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE public.audit_log;
+-- +goose StatementEnd
@@ -1,6 +1,73 @@
 package migrations
 
-import "embed"
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+
+	"github.com/Amore14rn/888Starz_test/pkg/errors"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/stdlib"
+	"github.com/pressly/goose/v3"
+)
 
 //go:embed *.sql
 var Content embed.FS
+
+const (
+	CommandUp     = "up"
+	CommandDown   = "down"
+	CommandStatus = "status"
+	CommandRedo   = "redo"
+)
+
+var commands = map[string]func(ctx context.Context, db *sql.DB, dir string, opts ...goose.OptionsFunc) error{
+	CommandUp:     goose.UpContext,
+	CommandDown:   goose.DownContext,
+	CommandStatus: goose.StatusContext,
+	CommandRedo:   goose.RedoContext,
+}
+
+// lockKey identifies the advisory lock held while migrations run, so only
+// one replica applies them at a time.
+const lockKey int64 = 888_000_001
+
+// Run executes a goose command against the embedded migrations. The call
+// blocks until the advisory lock is acquired.
+func Run(ctx context.Context, pool *pgxpool.Pool, command string) error {
+	run, ok := commands[command]
+	if !ok {
+		return fmt.Errorf("unknown migrate command %q, expected one of: up, down, status, redo", command)
+	}
+
+	conn, err := pool.Acquire(ctx)
+	if err != nil {
+		return errors.Wrap(err, "pool.Acquire")
+	}
+	defer conn.Release()
+
+	if _, err = conn.Exec(ctx, "SELECT pg_advisory_lock($1)", lockKey); err != nil {
+		return errors.Wrap(err, "pg_advisory_lock")
+	}
+
+	defer func() {
+		// The lock has to be released even if ctx is already cancelled.
+		_, _ = conn.Exec(context.Background(), "SELECT pg_advisory_unlock($1)", lockKey)
+	}()
+
+	db := stdlib.OpenDB(*pool.Config().ConnConfig)
+	defer db.Close()
+
+	goose.SetBaseFS(Content)
+
+	if err = goose.SetDialect("postgres"); err != nil {
+		return errors.Wrap(err, "goose.SetDialect")
+	}
+
+	if err = run(ctx, db, "."); err != nil {
+		return errors.Wrap(err, "goose "+command)
+	}
+
+	return nil
+}
@@ -0,0 +1,49 @@
+package dao
+
+import (
+	"github.com/Amore14rn/888Starz_test/internal/domain/audit/model"
+	"time"
+)
+
+type EntryStorage struct {
+	ID         int64     `json:"id"`
+	ActorID    *string   `json:"actor_id"`
+	Action     string    `json:"action"`
+	EntityType string    `json:"entity_type"`
+	EntityID   string    `json:"entity_id"`
+	Diff       []byte    `json:"diff"`
+	RequestID  *string   `json:"request_id"`
+	TraceID    *string   `json:"trace_id"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func (es *EntryStorage) ToDomain() model.Entry {
+	return model.Entry{
+		ID:         es.ID,
+		ActorID:    value(es.ActorID),
+		Action:     model.Action(es.Action),
+		EntityType: model.EntityType(es.EntityType),
+		EntityID:   es.EntityID,
+		Diff:       es.Diff,
+		RequestID:  value(es.RequestID),
+		TraceID:    value(es.TraceID),
+		CreatedAt:  es.CreatedAt,
+	}
+}
+
+func value(s *string) string {
+	if s == nil {
+		return ""
+	}
+
+	return *s
+}
+
+// nullable stores empty strings as NULL.
+func nullable(s string) *string {
+	if s == "" {
+		return nil
+	}
+
+	return &s
+}
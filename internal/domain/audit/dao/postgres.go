@@ -0,0 +1,230 @@
+package dao
+
+import (
+	"context"
+	"github.com/Amore14rn/888Starz_test/internal/dal"
+	"github.com/Amore14rn/888Starz_test/internal/dal/postgres"
+	"github.com/Amore14rn/888Starz_test/internal/domain/audit/model"
+	"github.com/Amore14rn/888Starz_test/pkg/common/auth"
+	"github.com/Amore14rn/888Starz_test/pkg/common/core/pagination"
+	"github.com/Amore14rn/888Starz_test/pkg/common/core/requestid"
+	psql "github.com/Amore14rn/888Starz_test/pkg/postgresql"
+	"github.com/Amore14rn/888Starz_test/pkg/tracing"
+	sq "github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel/trace"
+	"strconv"
+)
+
+type AuditDAO struct {
+	qb     sq.StatementBuilderType
+	client psql.Client
+}
+
+func NewAuditDAO(client psql.Client) *AuditDAO {
+	return &AuditDAO{
+		qb:     sq.StatementBuilder.PlaceholderFormat(sq.Dollar),
+		client: client,
+	}
+}
+
+// Append writes entries within tx, so they are only kept if the mutation
+// they describe is committed. The actor, request ID and trace ID of ctx are
+// filled in for entries that don't have them.
+func (repo *AuditDAO) Append(ctx context.Context, tx pgx.Tx, entries ...model.Entry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	var actorID, traceID string
+	if principal, ok := auth.PrincipalFromContext(ctx); ok {
+		actorID = principal.UserID
+	}
+	if span := trace.SpanContextFromContext(ctx); span.HasTraceID() {
+		traceID = span.TraceID().String()
+	}
+	requestID := requestid.FromContext(ctx)
+
+	statement := repo.qb.
+		Insert(postgres.AuditLogTable).
+		Columns(
+			"actor_id",
+			"action",
+			"entity_type",
+			"entity_id",
+			"diff",
+			"request_id",
+			"trace_id",
+			"created_at",
+		)
+
+	for _, e := range entries {
+		if e.ActorID == "" {
+			e.ActorID = actorID
+		}
+		if e.RequestID == "" {
+			e.RequestID = requestID
+		}
+		if e.TraceID == "" {
+			e.TraceID = traceID
+		}
+
+		statement = statement.Values(
+			nullable(e.ActorID),
+			string(e.Action),
+			string(e.EntityType),
+			e.EntityID,
+			[]byte(e.Diff),
+			nullable(e.RequestID),
+			nullable(e.TraceID),
+			e.CreatedAt,
+		)
+	}
+
+	sql, args, err := statement.ToSql()
+	if err != nil {
+		return psql.ErrCreateQuery(err)
+	}
+
+	tracing.SpanEvent(ctx, "Insert Audit Entries query")
+	tracing.TraceVal(ctx, "sql", sql)
+	for i, arg := range args {
+		tracing.TraceIVal(ctx, "arg-"+strconv.Itoa(i), arg)
+	}
+
+	cmd, err := tx.Exec(ctx, sql, args...)
+	if err != nil {
+		return psql.ErrDoQuery(dal.FromPg(err))
+	}
+
+	if cmd.RowsAffected() == 0 {
+		return dal.ErrNothingInserted
+	}
+
+	return nil
+}
+
+// All returns one page of entries matching filter together with the cursor
+// of the next page, which is empty on the last page.
+func (repo *AuditDAO) All(ctx context.Context, filter model.Filter) ([]model.Entry, string, error) {
+	all, err := repo.findBy(ctx, filter)
+	if err != nil {
+		return nil, "", err
+	}
+
+	all, next := postgres.NextCursor(all, filter.Page, func(e EntryStorage) pagination.Cursor {
+		return pagination.Cursor{CreatedAt: e.CreatedAt, ID: strconv.FormatInt(e.ID, 10)}
+	})
+
+	resp := make([]model.Entry, len(all))
+	for i, e := range all {
+		resp[i] = e.ToDomain()
+	}
+
+	return resp, next, nil
+}
+
+func (repo *AuditDAO) findBy(ctx context.Context, filter model.Filter) ([]EntryStorage, error) {
+	// The keyset compares ids as numbers, a cursor we didn't issue would
+	// fail in the database instead
+	if filter.Page.Cursor != "" {
+		cursor, err := pagination.DecodeCursor(filter.Page.Cursor)
+		if err == nil {
+			_, err = strconv.ParseInt(cursor.ID, 10, 64)
+		}
+		if err != nil {
+			return nil, pagination.ErrInvalidCursor
+		}
+	}
+
+	statement := repo.qb.
+		Select(
+			"id",
+			"actor_id",
+			"action",
+			"entity_type",
+			"entity_id",
+			"diff",
+			"request_id",
+			"trace_id",
+			"created_at",
+		).
+		From(postgres.AuditLogTable)
+
+	if filter.EntityType != "" {
+		statement = statement.Where(sq.Eq{"entity_type": string(filter.EntityType)})
+	}
+	if filter.EntityID != "" {
+		statement = statement.Where(sq.Eq{"entity_id": filter.EntityID})
+	}
+	if filter.From != nil {
+		statement = statement.Where(sq.GtOrEq{"created_at": *filter.From})
+	}
+	if filter.To != nil {
+		statement = statement.Where(sq.Lt{"created_at": *filter.To})
+	}
+
+	statement, err := postgres.Paginate(statement, filter.Page)
+	if err != nil {
+		tracing.Error(ctx, err)
+
+		return nil, err
+	}
+
+	query, args, err := statement.ToSql()
+	if err != nil {
+		err = psql.ErrCreateQuery(err)
+		tracing.Error(ctx, err)
+
+		return nil, err
+	}
+
+	tracing.SpanEvent(ctx, "Select Audit Entries")
+	tracing.TraceVal(ctx, "SQL", query)
+	for i, arg := range args {
+		tracing.TraceIVal(ctx, "arg-"+strconv.Itoa(i), arg)
+	}
+
+	rows, err := repo.client.Query(ctx, query, args...)
+	if err != nil {
+		err = psql.ErrDoQuery(dal.FromPg(err))
+		tracing.Error(ctx, err)
+
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	entities := make([]EntryStorage, 0, filter.Page.Limit+1)
+
+	for rows.Next() {
+		var e EntryStorage
+		if err = rows.Scan(
+			&e.ID,
+			&e.ActorID,
+			&e.Action,
+			&e.EntityType,
+			&e.EntityID,
+			&e.Diff,
+			&e.RequestID,
+			&e.TraceID,
+			&e.CreatedAt,
+		); err != nil {
+			err = psql.ErrScan(dal.FromPg(err))
+			tracing.Error(ctx, err)
+
+			return nil, err
+		}
+
+		entities = append(entities, e)
+	}
+
+	if err = rows.Err(); err != nil {
+		err = psql.ErrDoQuery(dal.FromPg(err))
+		tracing.Error(ctx, err)
+
+		return nil, err
+	}
+
+	return entities, nil
+}
@@ -0,0 +1,111 @@
+package model
+
+import (
+	"encoding/json"
+	"github.com/Amore14rn/888Starz_test/pkg/common/core/pagination"
+	"reflect"
+	"time"
+)
+
+type Action string
+
+const (
+	ActionCreate  Action = "create"
+	ActionUpdate  Action = "update"
+	ActionDelete  Action = "delete"
+	ActionRestore Action = "restore"
+	ActionPurge   Action = "purge"
+)
+
+type EntityType string
+
+const (
+	EntityProduct EntityType = "product"
+	EntityUser    EntityType = "user"
+	EntityOrder   EntityType = "order"
+)
+
+// Redacted stands in for values that must not reach the audit log, such as
+// password hashes.
+const Redacted = "[REDACTED]"
+
+// Change is the value of one field before and after a mutation, From is nil
+// for a field that didn't exist and To for one that was removed.
+type Change struct {
+	From any `json:"from"`
+	To   any `json:"to"`
+}
+
+// Diff maps field names to their changes.
+type Diff map[string]Change
+
+// Changes returns the fields whose values differ between the two snapshots.
+// A nil before describes a created entity.
+func Changes(before, after map[string]any) Diff {
+	diff := Diff{}
+
+	for field, to := range after {
+		from, ok := before[field]
+		if !ok || !reflect.DeepEqual(from, to) {
+			diff[field] = Change{From: from, To: to}
+		}
+	}
+
+	for field, from := range before {
+		if _, ok := after[field]; !ok {
+			diff[field] = Change{From: from}
+		}
+	}
+
+	return diff
+}
+
+// Entry is one mutation of an entity. Entries are written by the DAOs in the
+// transaction of the mutation, the actor, request and trace are taken from
+// the context at that moment.
+type Entry struct {
+	ID         int64
+	ActorID    string
+	Action     Action
+	EntityType EntityType
+	EntityID   string
+	Diff       json.RawMessage
+	RequestID  string
+	TraceID    string
+	CreatedAt  time.Time
+}
+
+func NewEntry(action Action, entityType EntityType, entityID string, diff Diff, createdAt time.Time) (Entry, error) {
+	raw, err := json.Marshal(diff)
+	if err != nil {
+		return Entry{}, err
+	}
+
+	return Entry{
+		Action:     action,
+		EntityType: entityType,
+		EntityID:   entityID,
+		Diff:       raw,
+		CreatedAt:  createdAt,
+	}, nil
+}
+
+// Filter selects a page of entries, zero fields don't restrict the result.
+// To is exclusive.
+type Filter struct {
+	EntityType EntityType
+	EntityID   string
+	From       *time.Time
+	To         *time.Time
+	Page       pagination.Params
+}
+
+func NewFilter(entityType EntityType, entityID string, from, to *time.Time, page pagination.Params) Filter {
+	return Filter{
+		EntityType: entityType,
+		EntityID:   entityID,
+		From:       from,
+		To:         to,
+		Page:       page,
+	}
+}
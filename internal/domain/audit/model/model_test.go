@@ -0,0 +1,34 @@
+package model
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChanges(t *testing.T) {
+	before := map[string]any{"quantity": 5, "price": 9.99, "tags": []string{"a"}, "legacy": "x"}
+	after := map[string]any{"quantity": 3, "price": 9.99, "tags": []string{"a"}, "description": "new"}
+
+	assert.Equal(t, Diff{
+		"quantity":    {From: 5, To: 3},
+		"description": {From: nil, To: "new"},
+		"legacy":      {From: "x", To: nil},
+	}, Changes(before, after))
+}
+
+func TestChangesOfCreatedEntity(t *testing.T) {
+	assert.Equal(t, Diff{"status": {To: "pending"}}, Changes(nil, map[string]any{"status": "pending"}))
+}
+
+func TestNewEntry(t *testing.T) {
+	at := time.Date(2023, 9, 10, 12, 0, 0, 0, time.UTC)
+
+	entry, err := NewEntry(ActionUpdate, EntityProduct, "p1", Diff{"quantity": {From: 5, To: 3}}, at)
+
+	require.NoError(t, err)
+	assert.Equal(t, EntityProduct, entry.EntityType)
+	assert.JSONEq(t, `{"quantity": {"from": 5, "to": 3}}`, string(entry.Diff))
+}
@@ -0,0 +1,30 @@
+package service
+
+import (
+	"context"
+	"github.com/Amore14rn/888Starz_test/internal/domain/audit/model"
+	"github.com/Amore14rn/888Starz_test/pkg/errors"
+)
+
+type repository interface {
+	All(ctx context.Context, filter model.Filter) ([]model.Entry, string, error)
+}
+
+type AuditService struct {
+	repository repository
+}
+
+func NewAuditService(repository repository) *AuditService {
+	return &AuditService{
+		repository: repository,
+	}
+}
+
+func (s *AuditService) All(ctx context.Context, filter model.Filter) ([]model.Entry, string, error) {
+	entries, next, err := s.repository.All(ctx, filter)
+	if err != nil {
+		return nil, "", errors.Wrap(err, "repository.All")
+	}
+
+	return entries, next, nil
+}
@@ -0,0 +1,30 @@
+package dao
+
+import (
+	"github.com/Amore14rn/888Starz_test/internal/domain/cart/model"
+	"time"
+)
+
+type CartStorage struct {
+	ID        string    `json:"id"`
+	UserID    string    `json:"user_id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+func (cs *CartStorage) ToDomain(items []model.CartItem) model.Cart {
+	return model.NewCart(cs.ID, cs.UserID, items, cs.CreatedAt, cs.UpdatedAt, cs.ExpiresAt)
+}
+
+type CartItemStorage struct {
+	ProductID   string  `json:"product_id"`
+	Description string  `json:"description"`
+	Quantity    int     `json:"quantity"`
+	Price       float64 `json:"price"`
+	Available   int     `json:"available"`
+}
+
+func (ci *CartItemStorage) ToDomain() model.CartItem {
+	return model.NewCartItem(ci.ProductID, ci.Description, ci.Quantity, ci.Price, ci.Available)
+}
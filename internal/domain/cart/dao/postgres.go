@@ -0,0 +1,459 @@
+package dao
+
+import (
+	"context"
+	"github.com/Amore14rn/888Starz_test/internal/dal"
+	"github.com/Amore14rn/888Starz_test/internal/dal/postgres"
+	"github.com/Amore14rn/888Starz_test/internal/domain/cart/model"
+	psql "github.com/Amore14rn/888Starz_test/pkg/postgresql"
+	"github.com/Amore14rn/888Starz_test/pkg/tracing"
+	sq "github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"strconv"
+	"time"
+)
+
+type CartDAO struct {
+	qb     sq.StatementBuilderType
+	client psql.Client
+}
+
+func NewCartDAO(client psql.Client) *CartDAO {
+	return &CartDAO{
+		qb:     sq.StatementBuilder.PlaceholderFormat(sq.Dollar),
+		client: client,
+	}
+}
+
+// Get returns the user's live cart with the current price and stock of every
+// item. Expired carts are treated as missing even before the sweeper drops them.
+func (repo *CartDAO) Get(ctx context.Context, userID string, now time.Time) (model.Cart, error) {
+	return repo.get(ctx, userID, now, false)
+}
+
+// Lock is Get that also locks the cart row until the transaction of ctx ends.
+// Every item change updates that row first, so the items read here stay the
+// cart's content until then. Without a transaction in ctx the lock is released
+// right away.
+func (repo *CartDAO) Lock(ctx context.Context, userID string, now time.Time) (model.Cart, error) {
+	return repo.get(ctx, userID, now, true)
+}
+
+func (repo *CartDAO) get(ctx context.Context, userID string, now time.Time, lock bool) (model.Cart, error) {
+	cart, err := repo.findByUser(ctx, userID, now, lock)
+	if err != nil {
+		return model.Cart{}, err
+	}
+
+	items, err := repo.findItems(ctx, cart.ID)
+	if err != nil {
+		return model.Cart{}, err
+	}
+
+	return cart.ToDomain(items), nil
+}
+
+func (repo *CartDAO) findByUser(ctx context.Context, userID string, now time.Time, lock bool) (CartStorage, error) {
+	statement := repo.qb.
+		Select(
+			"id",
+			"user_id",
+			"created_at",
+			"updated_at",
+			"expires_at",
+		).
+		From(postgres.CartTable).
+		Where(sq.Eq{"user_id": userID}).
+		Where(sq.Gt{"expires_at": now})
+
+	if lock {
+		statement = statement.Suffix("FOR UPDATE")
+	}
+
+	query, args, err := statement.ToSql()
+	if err != nil {
+		err = psql.ErrCreateQuery(err)
+		tracing.Error(ctx, err)
+
+		return CartStorage{}, err
+	}
+
+	tracing.SpanEvent(ctx, "Select Cart by User")
+	tracing.TraceVal(ctx, "SQL", query)
+	for i, arg := range args {
+		tracing.TraceIVal(ctx, "arg-"+strconv.Itoa(i), arg)
+	}
+
+	var e CartStorage
+	if err = repo.client.QueryRow(ctx, query, args...).Scan(
+		&e.ID,
+		&e.UserID,
+		&e.CreatedAt,
+		&e.UpdatedAt,
+		&e.ExpiresAt,
+	); err != nil {
+		err = psql.ErrScan(dal.FromPg(err))
+		tracing.Error(ctx, err)
+
+		return CartStorage{}, err
+	}
+
+	return e, nil
+}
+
+func (repo *CartDAO) findItems(ctx context.Context, cartID string) ([]model.CartItem, error) {
+	statement := repo.qb.
+		Select(
+			"ci.product_id",
+			"p.description",
+			"ci.quantity",
+			"p.price",
+			"p.quantity",
+		).
+		From(postgres.CartItemTable+" ci").
+		Join(postgres.ProductTable+" p ON p.id = ci.product_id").
+		Where(sq.Eq{"ci.cart_id": cartID}).
+		OrderBy("ci.added_at", "ci.product_id")
+
+	query, args, err := statement.ToSql()
+	if err != nil {
+		err = psql.ErrCreateQuery(err)
+		tracing.Error(ctx, err)
+
+		return nil, err
+	}
+
+	tracing.SpanEvent(ctx, "Select Cart Items")
+	tracing.TraceVal(ctx, "SQL", query)
+	for i, arg := range args {
+		tracing.TraceIVal(ctx, "arg-"+strconv.Itoa(i), arg)
+	}
+
+	rows, err := repo.client.Query(ctx, query, args...)
+	if err != nil {
+		err = psql.ErrDoQuery(dal.FromPg(err))
+		tracing.Error(ctx, err)
+
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	items := make([]model.CartItem, 0)
+
+	for rows.Next() {
+		var e CartItemStorage
+		if err = rows.Scan(
+			&e.ProductID,
+			&e.Description,
+			&e.Quantity,
+			&e.Price,
+			&e.Available,
+		); err != nil {
+			err = psql.ErrScan(dal.FromPg(err))
+			tracing.Error(ctx, err)
+
+			return nil, err
+		}
+
+		items = append(items, e.ToDomain())
+	}
+
+	if err = rows.Err(); err != nil {
+		err = psql.ErrDoQuery(dal.FromPg(err))
+		tracing.Error(ctx, err)
+
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// AddItem adds change.Quantity units of the product to the user's cart,
+// creating the cart with change.CartID when the user has no live one. An
+// expired cart that the sweeper hasn't dropped yet is replaced.
+func (repo *CartDAO) AddItem(ctx context.Context, change model.CartItemChange) error {
+	err := psql.WithTx(ctx, repo.client, pgx.TxOptions{}, func(tx pgx.Tx) error {
+		if _, err := repo.deleteExpired(ctx, tx, sq.Eq{"user_id": change.UserID}, change.At); err != nil {
+			return err
+		}
+
+		cartID, err := repo.upsertCart(ctx, tx, change)
+		if err != nil {
+			return err
+		}
+
+		sql, args, err := repo.qb.
+			Insert(postgres.CartItemTable).
+			Columns(
+				"cart_id",
+				"product_id",
+				"quantity",
+				"added_at",
+			).
+			Values(
+				cartID,
+				change.ProductID,
+				change.Quantity,
+				change.At,
+			).
+			Suffix("ON CONFLICT (cart_id, product_id) DO UPDATE SET quantity = " + postgres.CartItemTable + ".quantity + EXCLUDED.quantity").
+			ToSql()
+		if err != nil {
+			return psql.ErrCreateQuery(err)
+		}
+
+		tracing.SpanEvent(ctx, "Insert Cart Item query")
+		tracing.TraceVal(ctx, "sql", sql)
+		for i, arg := range args {
+			tracing.TraceIVal(ctx, "arg-"+strconv.Itoa(i), arg)
+		}
+
+		cmd, err := tx.Exec(ctx, sql, args...)
+		if err != nil {
+			return psql.ErrDoQuery(dal.FromPg(err))
+		}
+
+		if cmd.RowsAffected() == 0 {
+			return dal.ErrNothingInserted
+		}
+
+		return nil
+	})
+	if err != nil {
+		tracing.Error(ctx, err)
+
+		return err
+	}
+
+	return nil
+}
+
+// upsertCart creates the user's cart or extends the expiry of the existing
+// one and returns its ID.
+func (repo *CartDAO) upsertCart(ctx context.Context, tx pgx.Tx, change model.CartItemChange) (string, error) {
+	sql, args, err := repo.qb.
+		Insert(postgres.CartTable).
+		Columns(
+			"id",
+			"user_id",
+			"created_at",
+			"updated_at",
+			"expires_at",
+		).
+		Values(
+			change.CartID,
+			change.UserID,
+			change.At,
+			change.At,
+			change.ExpiresAt,
+		).
+		Suffix("ON CONFLICT (user_id) DO UPDATE SET updated_at = EXCLUDED.updated_at, expires_at = EXCLUDED.expires_at RETURNING id").
+		ToSql()
+	if err != nil {
+		return "", psql.ErrCreateQuery(err)
+	}
+
+	tracing.SpanEvent(ctx, "Upsert Cart query")
+	tracing.TraceVal(ctx, "sql", sql)
+	for i, arg := range args {
+		tracing.TraceIVal(ctx, "arg-"+strconv.Itoa(i), arg)
+	}
+
+	var id string
+	if err = tx.QueryRow(ctx, sql, args...).Scan(&id); err != nil {
+		return "", psql.ErrScan(dal.FromPg(err))
+	}
+
+	return id, nil
+}
+
+// SetItem replaces the quantity of a product already in the user's cart.
+func (repo *CartDAO) SetItem(ctx context.Context, change model.CartItemChange) error {
+	err := psql.WithTx(ctx, repo.client, pgx.TxOptions{}, func(tx pgx.Tx) error {
+		cartID, err := repo.touch(ctx, tx, change)
+		if err != nil {
+			return err
+		}
+
+		sql, args, err := repo.qb.
+			Update(postgres.CartItemTable).
+			Set("quantity", change.Quantity).
+			Where(sq.Eq{"cart_id": cartID, "product_id": change.ProductID}).
+			ToSql()
+		if err != nil {
+			return psql.ErrCreateQuery(err)
+		}
+
+		tracing.SpanEvent(ctx, "Update Cart Item query")
+		tracing.TraceVal(ctx, "sql", sql)
+		for i, arg := range args {
+			tracing.TraceIVal(ctx, "arg-"+strconv.Itoa(i), arg)
+		}
+
+		cmd, err := tx.Exec(ctx, sql, args...)
+		if err != nil {
+			return psql.ErrDoQuery(dal.FromPg(err))
+		}
+
+		if cmd.RowsAffected() == 0 {
+			return dal.ErrNotFound
+		}
+
+		return nil
+	})
+	if err != nil {
+		tracing.Error(ctx, err)
+
+		return err
+	}
+
+	return nil
+}
+
+// RemoveItem drops a product from the user's cart.
+func (repo *CartDAO) RemoveItem(ctx context.Context, change model.CartItemChange) error {
+	err := psql.WithTx(ctx, repo.client, pgx.TxOptions{}, func(tx pgx.Tx) error {
+		cartID, err := repo.touch(ctx, tx, change)
+		if err != nil {
+			return err
+		}
+
+		sql, args, err := repo.qb.
+			Delete(postgres.CartItemTable).
+			Where(sq.Eq{"cart_id": cartID, "product_id": change.ProductID}).
+			ToSql()
+		if err != nil {
+			return psql.ErrCreateQuery(err)
+		}
+
+		tracing.SpanEvent(ctx, "Delete Cart Item query")
+		tracing.TraceVal(ctx, "sql", sql)
+		for i, arg := range args {
+			tracing.TraceIVal(ctx, "arg-"+strconv.Itoa(i), arg)
+		}
+
+		cmd, err := tx.Exec(ctx, sql, args...)
+		if err != nil {
+			return psql.ErrDoQuery(dal.FromPg(err))
+		}
+
+		if cmd.RowsAffected() == 0 {
+			return dal.ErrNotFound
+		}
+
+		return nil
+	})
+	if err != nil {
+		tracing.Error(ctx, err)
+
+		return err
+	}
+
+	return nil
+}
+
+// touch extends the expiry of the user's live cart and returns its ID, or
+// dal.ErrNotFound when there is none.
+func (repo *CartDAO) touch(ctx context.Context, tx pgx.Tx, change model.CartItemChange) (string, error) {
+	sql, args, err := repo.qb.
+		Update(postgres.CartTable).
+		Set("updated_at", change.At).
+		Set("expires_at", change.ExpiresAt).
+		Where(sq.Eq{"user_id": change.UserID}).
+		Where(sq.Gt{"expires_at": change.At}).
+		Suffix("RETURNING id").
+		ToSql()
+	if err != nil {
+		return "", psql.ErrCreateQuery(err)
+	}
+
+	tracing.SpanEvent(ctx, "Touch Cart query")
+	tracing.TraceVal(ctx, "sql", sql)
+	for i, arg := range args {
+		tracing.TraceIVal(ctx, "arg-"+strconv.Itoa(i), arg)
+	}
+
+	var id string
+	if err = tx.QueryRow(ctx, sql, args...).Scan(&id); err != nil {
+		return "", psql.ErrScan(dal.FromPg(err))
+	}
+
+	return id, nil
+}
+
+// Delete drops the cart together with its items.
+func (repo *CartDAO) Delete(ctx context.Context, id string) error {
+	sql, args, err := repo.qb.
+		Delete(postgres.CartTable).
+		Where(sq.Eq{"id": id}).
+		ToSql()
+	if err != nil {
+		err = psql.ErrCreateQuery(err)
+		tracing.Error(ctx, err)
+
+		return err
+	}
+
+	tracing.SpanEvent(ctx, "Delete Cart query")
+	tracing.TraceVal(ctx, "sql", sql)
+	for i, arg := range args {
+		tracing.TraceIVal(ctx, "arg-"+strconv.Itoa(i), arg)
+	}
+
+	cmd, execErr := repo.client.Exec(ctx, sql, args...)
+	if execErr != nil {
+		execErr = psql.ErrDoQuery(dal.FromPg(execErr))
+		tracing.Error(ctx, execErr)
+
+		return execErr
+	}
+
+	if cmd.RowsAffected() == 0 {
+		return dal.ErrNotFound
+	}
+
+	return nil
+}
+
+// DeleteExpired drops every cart that expired at or before now and returns
+// how many were dropped.
+func (repo *CartDAO) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	deleted, err := repo.deleteExpired(ctx, repo.client, sq.Eq{}, now)
+	if err != nil {
+		tracing.Error(ctx, err)
+
+		return 0, err
+	}
+
+	return deleted, nil
+}
+
+type execer interface {
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+}
+
+func (repo *CartDAO) deleteExpired(ctx context.Context, db execer, where sq.Eq, now time.Time) (int64, error) {
+	sql, args, err := repo.qb.
+		Delete(postgres.CartTable).
+		Where(where).
+		Where(sq.LtOrEq{"expires_at": now}).
+		ToSql()
+	if err != nil {
+		return 0, psql.ErrCreateQuery(err)
+	}
+
+	tracing.SpanEvent(ctx, "Delete expired Carts query")
+	tracing.TraceVal(ctx, "sql", sql)
+	for i, arg := range args {
+		tracing.TraceIVal(ctx, "arg-"+strconv.Itoa(i), arg)
+	}
+
+	cmd, err := db.Exec(ctx, sql, args...)
+	if err != nil {
+		return 0, psql.ErrDoQuery(dal.FromPg(err))
+	}
+
+	return cmd.RowsAffected(), nil
+}
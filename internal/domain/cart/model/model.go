@@ -0,0 +1,95 @@
+package model
+
+import (
+	"math"
+	"time"
+)
+
+// CartItem is a cart line with the product's live price and stock.
+type CartItem struct {
+	ProductID   string
+	Description string
+	Quantity    int
+	Price       float64
+	Available   int
+	InStock     bool
+	Total       float64
+}
+
+func NewCartItem(productID, description string, quantity int, price float64, available int) CartItem {
+	return CartItem{
+		ProductID:   productID,
+		Description: description,
+		Quantity:    quantity,
+		Price:       price,
+		Available:   available,
+		InStock:     available >= quantity,
+		Total:       roundCents(price * float64(quantity)),
+	}
+}
+
+type Cart struct {
+	ID        string
+	UserID    string
+	Items     []CartItem
+	Total     float64
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	ExpiresAt time.Time
+}
+
+func NewCart(id, userID string, items []CartItem, createdAt, updatedAt, expiresAt time.Time) Cart {
+	var total float64
+	for _, item := range items {
+		total += item.Total
+	}
+
+	return Cart{
+		ID:        id,
+		UserID:    userID,
+		Items:     items,
+		Total:     roundCents(total),
+		CreatedAt: createdAt,
+		UpdatedAt: updatedAt,
+		ExpiresAt: expiresAt,
+	}
+}
+
+// NewEmptyCart is the cart of a user who hasn't added anything yet.
+func NewEmptyCart(userID string) Cart {
+	return Cart{
+		UserID: userID,
+		Items:  []CartItem{},
+	}
+}
+
+func (c Cart) Empty() bool {
+	return len(c.Items) == 0
+}
+
+// CartItemChange adds, updates or removes a line of the user's cart. CartID
+// is only used when the user has no live cart yet. Every change moves the
+// expiry of the cart to ExpiresAt.
+type CartItemChange struct {
+	CartID    string
+	UserID    string
+	ProductID string
+	Quantity  int
+	At        time.Time
+	ExpiresAt time.Time
+}
+
+func NewCartItemChange(cartID, userID, productID string, quantity int, at, expiresAt time.Time) CartItemChange {
+	return CartItemChange{
+		CartID:    cartID,
+		UserID:    userID,
+		ProductID: productID,
+		Quantity:  quantity,
+		At:        at,
+		ExpiresAt: expiresAt,
+	}
+}
+
+func roundCents(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
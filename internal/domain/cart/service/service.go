@@ -0,0 +1,89 @@
+package service
+
+import (
+	"context"
+	"github.com/Amore14rn/888Starz_test/internal/domain/cart/model"
+	"github.com/Amore14rn/888Starz_test/pkg/errors"
+	"time"
+)
+
+type repository interface {
+	Get(ctx context.Context, userID string, now time.Time) (model.Cart, error)
+	Lock(ctx context.Context, userID string, now time.Time) (model.Cart, error)
+	AddItem(ctx context.Context, change model.CartItemChange) error
+	SetItem(ctx context.Context, change model.CartItemChange) error
+	RemoveItem(ctx context.Context, change model.CartItemChange) error
+	Delete(ctx context.Context, id string) error
+	DeleteExpired(ctx context.Context, now time.Time) (int64, error)
+}
+
+type CartService struct {
+	repository repository
+}
+
+func NewCartService(repository repository) *CartService {
+	return &CartService{
+		repository: repository,
+	}
+}
+
+func (s *CartService) GetCart(ctx context.Context, userID string, now time.Time) (model.Cart, error) {
+	cart, err := s.repository.Get(ctx, userID, now)
+	if err != nil {
+		return model.Cart{}, errors.Wrap(err, "repository.Get")
+	}
+
+	return cart, nil
+}
+
+// LockCart returns the user's cart and keeps it from changing until the
+// transaction of ctx ends.
+func (s *CartService) LockCart(ctx context.Context, userID string, now time.Time) (model.Cart, error) {
+	cart, err := s.repository.Lock(ctx, userID, now)
+	if err != nil {
+		return model.Cart{}, errors.Wrap(err, "repository.Lock")
+	}
+
+	return cart, nil
+}
+
+func (s *CartService) AddItem(ctx context.Context, change model.CartItemChange) error {
+	if err := s.repository.AddItem(ctx, change); err != nil {
+		return errors.Wrap(err, "repository.AddItem")
+	}
+
+	return nil
+}
+
+func (s *CartService) SetItem(ctx context.Context, change model.CartItemChange) error {
+	if err := s.repository.SetItem(ctx, change); err != nil {
+		return errors.Wrap(err, "repository.SetItem")
+	}
+
+	return nil
+}
+
+func (s *CartService) RemoveItem(ctx context.Context, change model.CartItemChange) error {
+	if err := s.repository.RemoveItem(ctx, change); err != nil {
+		return errors.Wrap(err, "repository.RemoveItem")
+	}
+
+	return nil
+}
+
+func (s *CartService) DeleteCart(ctx context.Context, id string) error {
+	if err := s.repository.Delete(ctx, id); err != nil {
+		return errors.Wrap(err, "repository.Delete")
+	}
+
+	return nil
+}
+
+func (s *CartService) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	deleted, err := s.repository.DeleteExpired(ctx, now)
+	if err != nil {
+		return 0, errors.Wrap(err, "repository.DeleteExpired")
+	}
+
+	return deleted, nil
+}
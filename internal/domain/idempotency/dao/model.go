@@ -0,0 +1,39 @@
+package dao
+
+import (
+	"github.com/Amore14rn/888Starz_test/internal/domain/idempotency/model"
+	"time"
+)
+
+type IdempotencyKeyStorage struct {
+	Scope        string    `json:"scope"`
+	Key          string    `json:"key"`
+	Fingerprint  string    `json:"fingerprint"`
+	StatusCode   *int      `json:"status_code"`
+	ContentType  *string   `json:"content_type"`
+	ResponseBody []byte    `json:"response_body"`
+	CreatedAt    time.Time `json:"created_at"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
+
+func (ik *IdempotencyKeyStorage) ToDomain() model.Record {
+	record := model.Record{
+		Scope:       ik.Scope,
+		Key:         ik.Key,
+		Fingerprint: ik.Fingerprint,
+		CreatedAt:   ik.CreatedAt,
+		ExpiresAt:   ik.ExpiresAt,
+	}
+
+	if ik.StatusCode != nil {
+		var contentType string
+		if ik.ContentType != nil {
+			contentType = *ik.ContentType
+		}
+
+		response := model.NewResponse(*ik.StatusCode, contentType, ik.ResponseBody)
+		record.Response = &response
+	}
+
+	return record
+}
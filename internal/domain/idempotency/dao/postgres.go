@@ -0,0 +1,267 @@
+package dao
+
+import (
+	"context"
+	"errors"
+	"github.com/Amore14rn/888Starz_test/internal/dal"
+	"github.com/Amore14rn/888Starz_test/internal/dal/postgres"
+	"github.com/Amore14rn/888Starz_test/internal/domain/idempotency/model"
+	psql "github.com/Amore14rn/888Starz_test/pkg/postgresql"
+	"github.com/Amore14rn/888Starz_test/pkg/tracing"
+	sq "github.com/Masterminds/squirrel"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var recordColumns = []string{
+	"scope",
+	"key",
+	"fingerprint",
+	"status_code",
+	"content_type",
+	"response_body",
+	"created_at",
+	"expires_at",
+}
+
+// reserveConflict takes over an existing key only once it has expired, so a
+// key the sweeper hasn't dropped yet already behaves as a new one. A key that
+// was never completed expires when its lease ends.
+var reserveConflict = "ON CONFLICT (scope, key) DO UPDATE SET " +
+	"fingerprint = EXCLUDED.fingerprint, " +
+	"status_code = NULL, " +
+	"content_type = NULL, " +
+	"response_body = NULL, " +
+	"created_at = EXCLUDED.created_at, " +
+	"expires_at = EXCLUDED.expires_at " +
+	"WHERE " + postgres.IdempotencyKeyTable + ".expires_at <= EXCLUDED.created_at " +
+	"RETURNING " + strings.Join(recordColumns, ", ")
+
+type IdempotencyDAO struct {
+	qb     sq.StatementBuilderType
+	client psql.Client
+}
+
+func NewIdempotencyDAO(client psql.Client) *IdempotencyDAO {
+	return &IdempotencyDAO{
+		qb:     sq.StatementBuilder.PlaceholderFormat(sq.Dollar),
+		client: client,
+	}
+}
+
+// Reserve stores key until its lease ends unless a live record for it already
+// exists. It returns the record the key maps to and whether the record was
+// created by this call.
+func (repo *IdempotencyDAO) Reserve(ctx context.Context, key model.Key) (model.Record, bool, error) {
+	sql, args, err := repo.qb.
+		Insert(postgres.IdempotencyKeyTable).
+		Columns(
+			"scope",
+			"key",
+			"fingerprint",
+			"created_at",
+			"expires_at",
+		).
+		Values(
+			key.Scope,
+			key.Key,
+			key.Fingerprint,
+			key.CreatedAt,
+			key.LeaseUntil,
+		).
+		Suffix(reserveConflict).
+		ToSql()
+	if err != nil {
+		err = psql.ErrCreateQuery(err)
+		tracing.Error(ctx, err)
+
+		return model.Record{}, false, err
+	}
+
+	tracing.SpanEvent(ctx, "Reserve Idempotency Key query")
+	tracing.TraceVal(ctx, "sql", sql)
+	for i, arg := range args {
+		tracing.TraceIVal(ctx, "arg-"+strconv.Itoa(i), arg)
+	}
+
+	var e IdempotencyKeyStorage
+	err = scanRecord(repo.client.QueryRow(ctx, sql, args...), &e)
+	if err == nil {
+		return e.ToDomain(), true, nil
+	}
+
+	// No row is returned when a live record holds the key
+	if err = dal.FromPg(err); !errors.Is(err, dal.ErrNotFound) {
+		err = psql.ErrScan(err)
+		tracing.Error(ctx, err)
+
+		return model.Record{}, false, err
+	}
+
+	e, err = repo.find(ctx, key.Scope, key.Key)
+	if err != nil {
+		return model.Record{}, false, err
+	}
+
+	return e.ToDomain(), false, nil
+}
+
+func (repo *IdempotencyDAO) find(ctx context.Context, scope, key string) (IdempotencyKeyStorage, error) {
+	query, args, err := repo.qb.
+		Select(recordColumns...).
+		From(postgres.IdempotencyKeyTable).
+		Where(sq.Eq{"scope": scope, "key": key}).
+		ToSql()
+	if err != nil {
+		err = psql.ErrCreateQuery(err)
+		tracing.Error(ctx, err)
+
+		return IdempotencyKeyStorage{}, err
+	}
+
+	tracing.SpanEvent(ctx, "Select Idempotency Key")
+	tracing.TraceVal(ctx, "SQL", query)
+	for i, arg := range args {
+		tracing.TraceIVal(ctx, "arg-"+strconv.Itoa(i), arg)
+	}
+
+	var e IdempotencyKeyStorage
+	if err = scanRecord(repo.client.QueryRow(ctx, query, args...), &e); err != nil {
+		err = psql.ErrScan(dal.FromPg(err))
+		tracing.Error(ctx, err)
+
+		return IdempotencyKeyStorage{}, err
+	}
+
+	return e, nil
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanRecord(row scanner, e *IdempotencyKeyStorage) error {
+	return row.Scan(
+		&e.Scope,
+		&e.Key,
+		&e.Fingerprint,
+		&e.StatusCode,
+		&e.ContentType,
+		&e.ResponseBody,
+		&e.CreatedAt,
+		&e.ExpiresAt,
+	)
+}
+
+// Complete stores the response of the request that reserved the key and
+// keeps it until key.ExpiresAt. A reservation taken over by another request
+// after its lease ended is not touched, dal.ErrNotFound is returned then.
+func (repo *IdempotencyDAO) Complete(ctx context.Context, key model.Key, response model.Response) error {
+	sql, args, err := repo.qb.
+		Update(postgres.IdempotencyKeyTable).
+		Set("status_code", response.StatusCode).
+		Set("content_type", response.ContentType).
+		Set("response_body", response.Body).
+		Set("expires_at", key.ExpiresAt).
+		Where(reservation(key)).
+		ToSql()
+	if err != nil {
+		err = psql.ErrCreateQuery(err)
+		tracing.Error(ctx, err)
+
+		return err
+	}
+
+	tracing.SpanEvent(ctx, "Complete Idempotency Key query")
+	tracing.TraceVal(ctx, "sql", sql)
+	for i, arg := range args {
+		tracing.TraceIVal(ctx, "arg-"+strconv.Itoa(i), arg)
+	}
+
+	cmd, execErr := repo.client.Exec(ctx, sql, args...)
+	if execErr != nil {
+		execErr = psql.ErrDoQuery(dal.FromPg(execErr))
+		tracing.Error(ctx, execErr)
+
+		return execErr
+	}
+
+	if cmd.RowsAffected() == 0 {
+		return dal.ErrNotFound
+	}
+
+	return nil
+}
+
+// Release drops a key whose request failed, so the client may retry with it.
+// Completed keys and reservations taken over by another request are never
+// released.
+func (repo *IdempotencyDAO) Release(ctx context.Context, key model.Key) error {
+	sql, args, err := repo.qb.
+		Delete(postgres.IdempotencyKeyTable).
+		Where(reservation(key)).
+		ToSql()
+	if err != nil {
+		err = psql.ErrCreateQuery(err)
+		tracing.Error(ctx, err)
+
+		return err
+	}
+
+	tracing.SpanEvent(ctx, "Release Idempotency Key query")
+	tracing.TraceVal(ctx, "sql", sql)
+	for i, arg := range args {
+		tracing.TraceIVal(ctx, "arg-"+strconv.Itoa(i), arg)
+	}
+
+	if _, err = repo.client.Exec(ctx, sql, args...); err != nil {
+		err = psql.ErrDoQuery(dal.FromPg(err))
+		tracing.Error(ctx, err)
+
+		return err
+	}
+
+	return nil
+}
+
+// reservation matches the uncompleted row reserved with key.
+func reservation(key model.Key) sq.Eq {
+	return sq.Eq{
+		"scope":       key.Scope,
+		"key":         key.Key,
+		"created_at":  key.CreatedAt,
+		"status_code": nil,
+	}
+}
+
+// DeleteExpired drops every key that expired at or before now and returns
+// how many were dropped.
+func (repo *IdempotencyDAO) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	sql, args, err := repo.qb.
+		Delete(postgres.IdempotencyKeyTable).
+		Where(sq.LtOrEq{"expires_at": now}).
+		ToSql()
+	if err != nil {
+		err = psql.ErrCreateQuery(err)
+		tracing.Error(ctx, err)
+
+		return 0, err
+	}
+
+	tracing.SpanEvent(ctx, "Delete expired Idempotency Keys query")
+	tracing.TraceVal(ctx, "sql", sql)
+	for i, arg := range args {
+		tracing.TraceIVal(ctx, "arg-"+strconv.Itoa(i), arg)
+	}
+
+	cmd, err := repo.client.Exec(ctx, sql, args...)
+	if err != nil {
+		err = psql.ErrDoQuery(dal.FromPg(err))
+		tracing.Error(ctx, err)
+
+		return 0, err
+	}
+
+	return cmd.RowsAffected(), nil
+}
@@ -0,0 +1,60 @@
+package model
+
+import "time"
+
+// Key is an Idempotency-Key sent by a client. It is reserved until LeaseUntil
+// while the first request made with it runs, so a reservation left behind by
+// a crash is taken over once the lease ends, and kept until ExpiresAt once the
+// request has completed.
+type Key struct {
+	Scope       string
+	Key         string
+	Fingerprint string
+	CreatedAt   time.Time
+	LeaseUntil  time.Time
+	ExpiresAt   time.Time
+}
+
+func NewKey(scope, key, fingerprint string, createdAt time.Time, lease, ttl time.Duration) Key {
+	// The database keeps microseconds, CreatedAt identifies the reservation
+	createdAt = createdAt.Truncate(time.Microsecond)
+
+	return Key{
+		Scope:       scope,
+		Key:         key,
+		Fingerprint: fingerprint,
+		CreatedAt:   createdAt,
+		LeaseUntil:  createdAt.Add(lease),
+		ExpiresAt:   createdAt.Add(ttl),
+	}
+}
+
+// Response is the first response produced for a key, replayed to retries.
+type Response struct {
+	StatusCode  int
+	ContentType string
+	Body        []byte
+}
+
+func NewResponse(statusCode int, contentType string, body []byte) Response {
+	return Response{
+		StatusCode:  statusCode,
+		ContentType: contentType,
+		Body:        body,
+	}
+}
+
+// Record is a stored key. Response is nil while the first request made with
+// the key is still in flight.
+type Record struct {
+	Scope       string
+	Key         string
+	Fingerprint string
+	Response    *Response
+	CreatedAt   time.Time
+	ExpiresAt   time.Time
+}
+
+func (r Record) Completed() bool {
+	return r.Response != nil
+}
@@ -0,0 +1,59 @@
+package service
+
+import (
+	"context"
+	"github.com/Amore14rn/888Starz_test/internal/domain/idempotency/model"
+	"github.com/Amore14rn/888Starz_test/pkg/errors"
+	"time"
+)
+
+type repository interface {
+	Reserve(ctx context.Context, key model.Key) (model.Record, bool, error)
+	Complete(ctx context.Context, key model.Key, response model.Response) error
+	Release(ctx context.Context, key model.Key) error
+	DeleteExpired(ctx context.Context, now time.Time) (int64, error)
+}
+
+type IdempotencyService struct {
+	repository repository
+}
+
+func NewIdempotencyService(repository repository) *IdempotencyService {
+	return &IdempotencyService{
+		repository: repository,
+	}
+}
+
+func (s *IdempotencyService) Reserve(ctx context.Context, key model.Key) (model.Record, bool, error) {
+	record, reserved, err := s.repository.Reserve(ctx, key)
+	if err != nil {
+		return model.Record{}, false, errors.Wrap(err, "repository.Reserve")
+	}
+
+	return record, reserved, nil
+}
+
+func (s *IdempotencyService) Complete(ctx context.Context, key model.Key, response model.Response) error {
+	if err := s.repository.Complete(ctx, key, response); err != nil {
+		return errors.Wrap(err, "repository.Complete")
+	}
+
+	return nil
+}
+
+func (s *IdempotencyService) Release(ctx context.Context, key model.Key) error {
+	if err := s.repository.Release(ctx, key); err != nil {
+		return errors.Wrap(err, "repository.Release")
+	}
+
+	return nil
+}
+
+func (s *IdempotencyService) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	deleted, err := s.repository.DeleteExpired(ctx, now)
+	if err != nil {
+		return 0, errors.Wrap(err, "repository.DeleteExpired")
+	}
+
+	return deleted, nil
+}
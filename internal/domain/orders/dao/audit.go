@@ -0,0 +1,68 @@
+package dao
+
+import (
+	auditmodel "github.com/Amore14rn/888Starz_test/internal/domain/audit/model"
+	"github.com/Amore14rn/888Starz_test/internal/domain/orders/model"
+	"time"
+)
+
+type lineState struct {
+	ProductID string  `json:"product_id"`
+	Quantity  int     `json:"quantity"`
+	Price     float64 `json:"price"`
+}
+
+// orderCreatedEntries records a new order and the stock it took, available
+// holds the stock of every product before the order.
+func orderCreatedEntries(order model.Order, available map[string]int) ([]auditmodel.Entry, error) {
+	lines := make([]lineState, 0, len(order.Products))
+	for _, p := range order.Products {
+		lines = append(lines, lineState{
+			ProductID: p.ProductID,
+			Quantity:  p.Quantity,
+			Price:     p.Price,
+		})
+	}
+
+	created, err := auditmodel.NewEntry(auditmodel.ActionCreate, auditmodel.EntityOrder, order.ID, auditmodel.Changes(nil, map[string]any{
+		"user_id":  order.UserID,
+		"status":   string(order.Status),
+		"products": lines,
+	}), order.Timestamp)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := []auditmodel.Entry{created}
+
+	for _, p := range order.Products {
+		entry, err := stockEntry(p.ProductID, available[p.ProductID], available[p.ProductID]-p.Quantity, order.Timestamp)
+		if err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+func stockEntry(productID string, from, to int, at time.Time) (auditmodel.Entry, error) {
+	return auditmodel.NewEntry(auditmodel.ActionUpdate, auditmodel.EntityProduct, productID, auditmodel.Diff{
+		"quantity": {From: from, To: to},
+	}, at)
+}
+
+func statusEntry(change model.StatusChange) (auditmodel.Entry, error) {
+	return auditmodel.NewEntry(auditmodel.ActionUpdate, auditmodel.EntityOrder, change.OrderID, auditmodel.Diff{
+		"status": {From: string(change.From), To: string(change.To)},
+	}, change.ChangedAt)
+}
+
+// cancelledEntry records the units of one line given back to stock,
+// cancelled is the cancelled quantity of the line afterwards.
+func cancelledEntry(orderID string, restock model.Restock, cancelled int, at time.Time) (auditmodel.Entry, error) {
+	return auditmodel.NewEntry(auditmodel.ActionUpdate, auditmodel.EntityOrder, orderID, auditmodel.Diff{
+		"products." + restock.ProductID + ".cancelled_quantity": {From: cancelled - restock.Quantity, To: cancelled},
+	}, at)
+}
@@ -0,0 +1,66 @@
+package dao
+
+import (
+	"github.com/Amore14rn/888Starz_test/internal/domain/orders/model"
+	outboxmodel "github.com/Amore14rn/888Starz_test/internal/domain/outbox/model"
+	"time"
+)
+
+// orderCreatedEvents describes a new order and the stock it took, available
+// holds the stock of every product before the order.
+func orderCreatedEvents(order model.Order, available map[string]int) ([]outboxmodel.Event, error) {
+	lines := make([]outboxmodel.OrderLine, 0, len(order.Products))
+	for _, p := range order.Products {
+		lines = append(lines, outboxmodel.OrderLine{
+			ProductID: p.ProductID,
+			Quantity:  p.Quantity,
+			Price:     p.Price,
+		})
+	}
+
+	created, err := outboxmodel.NewEvent(outboxmodel.AggregateOrder, order.ID, outboxmodel.EventOrderCreated, outboxmodel.OrderCreated{
+		OrderID:   order.ID,
+		UserID:    order.UserID,
+		Status:    string(order.Status),
+		Products:  lines,
+		Timestamp: order.Timestamp,
+	}, order.Timestamp)
+	if err != nil {
+		return nil, err
+	}
+
+	events := []outboxmodel.Event{created}
+
+	for _, p := range order.Products {
+		event, err := stockChangedEvent(p.ProductID, available[p.ProductID]-p.Quantity, -p.Quantity, outboxmodel.StockReasonOrdered, order.ID, order.Timestamp)
+		if err != nil {
+			return nil, err
+		}
+
+		events = append(events, event)
+	}
+
+	return events, nil
+}
+
+func stockChangedEvent(productID string, quantity, delta int, reason, orderID string, at time.Time) (outboxmodel.Event, error) {
+	return outboxmodel.NewEvent(outboxmodel.AggregateProduct, productID, outboxmodel.EventProductStockChanged, outboxmodel.StockChanged{
+		ProductID: productID,
+		Quantity:  quantity,
+		Delta:     delta,
+		Reason:    reason,
+		OrderID:   orderID,
+		ChangedAt: at,
+	}, at)
+}
+
+func statusChangedEvent(change model.StatusChange) (outboxmodel.Event, error) {
+	return outboxmodel.NewEvent(outboxmodel.AggregateOrder, change.OrderID, outboxmodel.EventOrderStatusChanged, outboxmodel.OrderStatusChanged{
+		OrderID:   change.OrderID,
+		From:      string(change.From),
+		To:        string(change.To),
+		ChangedBy: change.ChangedBy,
+		Reason:    change.Reason,
+		ChangedAt: change.ChangedAt,
+	}, change.ChangedAt)
+}
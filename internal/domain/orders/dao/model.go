@@ -0,0 +1,59 @@
+package dao
+
+import (
+	"github.com/Amore14rn/888Starz_test/internal/domain/orders/model"
+	"time"
+)
+
+type OrderStorage struct {
+	ID        string                `json:"id"`
+	UserID    string                `json:"user_id"`
+	Status    string                `json:"status"`
+	Products  []OrderProductStorage `json:"products"`
+	Timestamp time.Time             `json:"timestamp"`
+}
+
+type OrderProductStorage struct {
+	ProductID         string  `json:"product_id"`
+	Quantity          int     `json:"quantity"`
+	CancelledQuantity int     `json:"cancelled_quantity"`
+	Price             float64 `json:"price"`
+}
+
+func (os *OrderStorage) ToDomain() model.Order {
+	products := make([]model.OrderProduct, 0, len(os.Products))
+	for _, p := range os.Products {
+		products = append(products, model.OrderProduct{
+			ProductID:         p.ProductID,
+			Quantity:          p.Quantity,
+			CancelledQuantity: p.CancelledQuantity,
+			Price:             p.Price,
+		})
+	}
+
+	return model.NewOrder(os.ID, os.UserID, model.Status(os.Status), products, os.Timestamp)
+}
+
+type ProductStockStorage struct {
+	ProductID string
+	Quantity  int
+	Price     float64
+}
+
+type OrderLineDetailsStorage struct {
+	OrderID           string  `json:"order_id"`
+	ProductID         string  `json:"product_id"`
+	Quantity          int     `json:"quantity"`
+	CancelledQuantity int     `json:"cancelled_quantity"`
+	Price             float64 `json:"price"`
+	CurrentPrice      float64 `json:"current_price"`
+}
+
+func (ld *OrderLineDetailsStorage) ToDomain() model.OrderLineDetails {
+	return model.NewOrderLineDetails(model.OrderProduct{
+		ProductID:         ld.ProductID,
+		Quantity:          ld.Quantity,
+		CancelledQuantity: ld.CancelledQuantity,
+		Price:             ld.Price,
+	}, ld.CurrentPrice)
+}
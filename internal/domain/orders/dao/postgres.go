@@ -0,0 +1,1014 @@
+package dao
+
+import (
+	"context"
+	"github.com/Amore14rn/888Starz_test/internal/dal"
+	"github.com/Amore14rn/888Starz_test/internal/dal/postgres"
+	auditmodel "github.com/Amore14rn/888Starz_test/internal/domain/audit/model"
+	"github.com/Amore14rn/888Starz_test/internal/domain/orders/model"
+	outboxmodel "github.com/Amore14rn/888Starz_test/internal/domain/outbox/model"
+	"github.com/Amore14rn/888Starz_test/pkg/common/core/pagination"
+	psql "github.com/Amore14rn/888Starz_test/pkg/postgresql"
+	"github.com/Amore14rn/888Starz_test/pkg/tracing"
+	sq "github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+	"strconv"
+	"time"
+)
+
+type outbox interface {
+	Append(ctx context.Context, tx pgx.Tx, events ...outboxmodel.Event) error
+}
+
+type audit interface {
+	Append(ctx context.Context, tx pgx.Tx, entries ...auditmodel.Entry) error
+}
+
+type OrderDAO struct {
+	qb     sq.StatementBuilderType
+	client psql.Client
+	outbox outbox
+	audit  audit
+}
+
+func NewOrderDAO(client psql.Client, outbox outbox, audit audit) *OrderDAO {
+	return &OrderDAO{
+		qb:     sq.StatementBuilder.PlaceholderFormat(sq.Dollar),
+		client: client,
+		outbox: outbox,
+		audit:  audit,
+	}
+}
+
+// Create reserves stock for every line and stores the order with its lines
+// in a single transaction, together with the order.created and stock events.
+// Product rows are locked with SELECT ... FOR UPDATE so concurrent orders for
+// the same product are serialized.
+func (repo *OrderDAO) Create(ctx context.Context, req model.CreateOrder) (model.Order, error) {
+	order := req.ToOrder()
+
+	err := psql.WithTx(ctx, repo.client, pgx.TxOptions{}, func(tx pgx.Tx) error {
+		stock, err := repo.lockStock(ctx, tx, req.Products)
+		if err != nil {
+			return err
+		}
+
+		available := make(map[string]int, len(stock))
+		for id, s := range stock {
+			available[id] = s.Quantity
+		}
+
+		if err = model.CheckStock(available, req.Products); err != nil {
+			return err
+		}
+
+		// Lines always capture the price in effect at order time so later
+		// price changes don't rewrite order history.
+		order.Products = make([]model.OrderProduct, 0, len(req.Products))
+		for _, product := range req.Products {
+			product.Price = stock[product.ProductID].Price
+			order.Products = append(order.Products, product)
+		}
+
+		for _, product := range order.Products {
+			if err = repo.decrementStock(ctx, tx, product); err != nil {
+				return err
+			}
+		}
+
+		if err = repo.insertOrder(ctx, tx, req); err != nil {
+			return err
+		}
+
+		for _, product := range order.Products {
+			if err = repo.insertOrderProduct(ctx, tx, req.ID, product); err != nil {
+				return err
+			}
+		}
+
+		events, err := orderCreatedEvents(order, available)
+		if err != nil {
+			return err
+		}
+
+		if err = repo.outbox.Append(ctx, tx, events...); err != nil {
+			return err
+		}
+
+		entries, err := orderCreatedEntries(order, available)
+		if err != nil {
+			return err
+		}
+
+		return repo.audit.Append(ctx, tx, entries...)
+	})
+	if err != nil {
+		tracing.Error(ctx, err)
+
+		return model.Order{}, err
+	}
+
+	return order, nil
+}
+
+func (repo *OrderDAO) lockStock(ctx context.Context, tx pgx.Tx, products []model.OrderProduct) (map[string]ProductStockStorage, error) {
+	ids := make([]string, 0, len(products))
+	for _, p := range products {
+		ids = append(ids, p.ProductID)
+	}
+
+	// Rows are always locked in id order so two orders touching the same
+	// products can't deadlock each other. Soft deleted products are left out
+	// and so are out of stock.
+	statement := repo.qb.
+		Select(
+			"id",
+			"quantity",
+			"price",
+		).
+		From(postgres.ProductTable).
+		Where(sq.Eq{"id": ids, "deleted_at": nil}).
+		OrderBy("id").
+		Suffix("FOR UPDATE")
+
+	query, args, err := statement.ToSql()
+	if err != nil {
+		err = psql.ErrCreateQuery(err)
+		tracing.Error(ctx, err)
+
+		return nil, err
+	}
+
+	tracing.SpanEvent(ctx, "Lock Products stock")
+	tracing.TraceVal(ctx, "SQL", query)
+	for i, arg := range args {
+		tracing.TraceIVal(ctx, "arg-"+strconv.Itoa(i), arg)
+	}
+
+	rows, err := tx.Query(ctx, query, args...)
+	if err != nil {
+		err = psql.ErrDoQuery(dal.FromPg(err))
+		tracing.Error(ctx, err)
+
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	stock := make(map[string]ProductStockStorage, len(ids))
+
+	for rows.Next() {
+		var e ProductStockStorage
+		if err = rows.Scan(
+			&e.ProductID,
+			&e.Quantity,
+			&e.Price,
+		); err != nil {
+			err = psql.ErrScan(dal.FromPg(err))
+			tracing.Error(ctx, err)
+
+			return nil, err
+		}
+
+		stock[e.ProductID] = e
+	}
+
+	if err = rows.Err(); err != nil {
+		err = psql.ErrDoQuery(dal.FromPg(err))
+		tracing.Error(ctx, err)
+
+		return nil, err
+	}
+
+	return stock, nil
+}
+
+func (repo *OrderDAO) decrementStock(ctx context.Context, tx pgx.Tx, product model.OrderProduct) error {
+	sql, args, err := repo.qb.
+		Update(postgres.ProductTable).
+		Set("quantity", sq.Expr("quantity - ?", product.Quantity)).
+		Set("version", sq.Expr("version + 1")).
+		Where(sq.Eq{"id": product.ProductID}).
+		ToSql()
+	if err != nil {
+		err = psql.ErrCreateQuery(err)
+		tracing.Error(ctx, err)
+
+		return err
+	}
+
+	tracing.SpanEvent(ctx, "Decrement Product stock")
+	tracing.TraceVal(ctx, "sql", sql)
+	for i, arg := range args {
+		tracing.TraceIVal(ctx, "arg-"+strconv.Itoa(i), arg)
+	}
+
+	cmd, execErr := tx.Exec(ctx, sql, args...)
+	if execErr != nil {
+		execErr = psql.ErrDoQuery(dal.FromPg(execErr))
+		tracing.Error(ctx, execErr)
+
+		return execErr
+	}
+
+	if cmd.RowsAffected() == 0 {
+		return dal.ErrNotFound
+	}
+
+	return nil
+}
+
+func (repo *OrderDAO) insertOrder(ctx context.Context, tx pgx.Tx, req model.CreateOrder) error {
+	sql, args, err := repo.qb.
+		Insert(postgres.OrderTable).
+		Columns(
+			"id",
+			"user_id",
+			"status",
+			"timestamp",
+		).
+		Values(
+			req.ID,
+			req.UserID,
+			string(model.StatusPending),
+			req.Timestamp,
+		).ToSql()
+	if err != nil {
+		err = psql.ErrCreateQuery(err)
+		tracing.Error(ctx, err)
+
+		return err
+	}
+
+	tracing.SpanEvent(ctx, "Insert Order query")
+	tracing.TraceVal(ctx, "sql", sql)
+	for i, arg := range args {
+		tracing.TraceIVal(ctx, "arg-"+strconv.Itoa(i), arg)
+	}
+
+	cmd, execErr := tx.Exec(ctx, sql, args...)
+	if execErr != nil {
+		execErr = psql.ErrDoQuery(dal.FromPg(execErr))
+		tracing.Error(ctx, execErr)
+
+		return execErr
+	}
+
+	if cmd.RowsAffected() == 0 {
+		return dal.ErrNothingInserted
+	}
+
+	return nil
+}
+
+func (repo *OrderDAO) insertOrderProduct(ctx context.Context, tx pgx.Tx, orderID string, product model.OrderProduct) error {
+	sql, args, err := repo.qb.
+		Insert(postgres.OrderProductTable).
+		Columns(
+			"order_id",
+			"product_id",
+			"quantity",
+			"price",
+		).
+		Values(
+			orderID,
+			product.ProductID,
+			product.Quantity,
+			product.Price,
+		).ToSql()
+	if err != nil {
+		err = psql.ErrCreateQuery(err)
+		tracing.Error(ctx, err)
+
+		return err
+	}
+
+	tracing.SpanEvent(ctx, "Insert Order Product query")
+	tracing.TraceVal(ctx, "sql", sql)
+	for i, arg := range args {
+		tracing.TraceIVal(ctx, "arg-"+strconv.Itoa(i), arg)
+	}
+
+	cmd, execErr := tx.Exec(ctx, sql, args...)
+	if execErr != nil {
+		execErr = psql.ErrDoQuery(dal.FromPg(execErr))
+		tracing.Error(ctx, execErr)
+
+		return execErr
+	}
+
+	if cmd.RowsAffected() == 0 {
+		return dal.ErrNothingInserted
+	}
+
+	return nil
+}
+
+func (repo *OrderDAO) GetOrder(ctx context.Context, id string) (model.Order, error) {
+	order, err := repo.findByID(ctx, id)
+	if err != nil {
+		return model.Order{}, err
+	}
+
+	products, err := repo.findProducts(ctx, id)
+	if err != nil {
+		return model.Order{}, err
+	}
+
+	order.Products = products
+
+	return order.ToDomain(), nil
+}
+
+func (repo *OrderDAO) findByID(ctx context.Context, id string) (OrderStorage, error) {
+	statement := repo.qb.
+		Select(
+			"id",
+			"user_id",
+			"status",
+			"timestamp",
+		).
+		From(postgres.OrderTable).
+		Where(sq.Eq{"id": id})
+
+	query, args, err := statement.ToSql()
+	if err != nil {
+		err = psql.ErrCreateQuery(err)
+		tracing.Error(ctx, err)
+
+		return OrderStorage{}, err
+	}
+
+	tracing.SpanEvent(ctx, "Select Order by ID")
+	tracing.TraceVal(ctx, "SQL", query)
+	for i, arg := range args {
+		tracing.TraceIVal(ctx, "arg-"+strconv.Itoa(i), arg)
+	}
+
+	row := repo.client.QueryRow(ctx, query, args...)
+
+	var e OrderStorage
+	if err = row.Scan(
+		&e.ID,
+		&e.UserID,
+		&e.Status,
+		&e.Timestamp,
+	); err != nil {
+		err = psql.ErrScan(dal.FromPg(err))
+		tracing.Error(ctx, err)
+
+		return OrderStorage{}, err
+	}
+
+	return e, nil
+}
+
+func (repo *OrderDAO) findProducts(ctx context.Context, orderID string) ([]OrderProductStorage, error) {
+	statement := repo.qb.
+		Select(
+			"product_id",
+			"quantity",
+			"cancelled_quantity",
+			"price",
+		).
+		From(postgres.OrderProductTable).
+		Where(sq.Eq{"order_id": orderID}).
+		OrderBy("product_id")
+
+	query, args, err := statement.ToSql()
+	if err != nil {
+		err = psql.ErrCreateQuery(err)
+		tracing.Error(ctx, err)
+
+		return nil, err
+	}
+
+	tracing.SpanEvent(ctx, "Select Order Products")
+	tracing.TraceVal(ctx, "SQL", query)
+	for i, arg := range args {
+		tracing.TraceIVal(ctx, "arg-"+strconv.Itoa(i), arg)
+	}
+
+	rows, err := repo.client.Query(ctx, query, args...)
+	if err != nil {
+		err = psql.ErrDoQuery(dal.FromPg(err))
+		tracing.Error(ctx, err)
+
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var entities []OrderProductStorage
+
+	for rows.Next() {
+		var e OrderProductStorage
+		if err = rows.Scan(
+			&e.ProductID,
+			&e.Quantity,
+			&e.CancelledQuantity,
+			&e.Price,
+		); err != nil {
+			err = psql.ErrScan(dal.FromPg(err))
+			tracing.Error(ctx, err)
+
+			return nil, err
+		}
+
+		entities = append(entities, e)
+	}
+
+	if err = rows.Err(); err != nil {
+		err = psql.ErrDoQuery(dal.FromPg(err))
+		tracing.Error(ctx, err)
+
+		return nil, err
+	}
+
+	return entities, nil
+}
+
+// Transition moves the order into transition.To and records the change in
+// the status history. The order row is locked first so the current status
+// checked against the transition table can't change before the update.
+func (repo *OrderDAO) Transition(ctx context.Context, transition model.StatusTransition) (model.StatusChange, error) {
+	change := model.StatusChange{
+		OrderID:   transition.OrderID,
+		To:        transition.To,
+		ChangedBy: transition.ChangedBy,
+		Reason:    transition.Reason,
+		ChangedAt: transition.ChangedAt,
+	}
+
+	err := psql.WithTx(ctx, repo.client, pgx.TxOptions{}, func(tx pgx.Tx) error {
+		from, err := repo.lockStatus(ctx, tx, transition.OrderID)
+		if err != nil {
+			return err
+		}
+
+		if err = model.CheckTransition(from, transition.To); err != nil {
+			return err
+		}
+		change.From = from
+
+		// A cancelled order gives back every unit not cancelled yet
+		if transition.To == model.StatusCancelled {
+			if err = repo.restockRemaining(ctx, tx, transition.OrderID, transition.ChangedAt); err != nil {
+				return err
+			}
+		}
+
+		if err = repo.updateStatus(ctx, tx, transition.OrderID, transition.To); err != nil {
+			return err
+		}
+
+		return repo.insertStatusChange(ctx, tx, change)
+	})
+	if err != nil {
+		tracing.Error(ctx, err)
+
+		return model.StatusChange{}, err
+	}
+
+	return change, nil
+}
+
+func (repo *OrderDAO) lockStatus(ctx context.Context, tx pgx.Tx, id string) (model.Status, error) {
+	query, args, err := repo.qb.
+		Select("status").
+		From(postgres.OrderTable).
+		Where(sq.Eq{"id": id}).
+		Suffix("FOR UPDATE").
+		ToSql()
+	if err != nil {
+		return "", psql.ErrCreateQuery(err)
+	}
+
+	tracing.SpanEvent(ctx, "Lock Order status")
+	tracing.TraceVal(ctx, "SQL", query)
+
+	var status string
+	if err = tx.QueryRow(ctx, query, args...).Scan(&status); err != nil {
+		return "", psql.ErrScan(dal.FromPg(err))
+	}
+
+	return model.Status(status), nil
+}
+
+func (repo *OrderDAO) updateStatus(ctx context.Context, tx pgx.Tx, id string, status model.Status) error {
+	sql, args, err := repo.qb.
+		Update(postgres.OrderTable).
+		Set("status", string(status)).
+		Where(sq.Eq{"id": id}).
+		ToSql()
+	if err != nil {
+		return psql.ErrCreateQuery(err)
+	}
+
+	tracing.SpanEvent(ctx, "Update Order status")
+	tracing.TraceVal(ctx, "sql", sql)
+	for i, arg := range args {
+		tracing.TraceIVal(ctx, "arg-"+strconv.Itoa(i), arg)
+	}
+
+	cmd, err := tx.Exec(ctx, sql, args...)
+	if err != nil {
+		return psql.ErrDoQuery(dal.FromPg(err))
+	}
+
+	if cmd.RowsAffected() == 0 {
+		return dal.ErrNotFound
+	}
+
+	return nil
+}
+
+func (repo *OrderDAO) insertStatusChange(ctx context.Context, tx pgx.Tx, change model.StatusChange) error {
+	sql, args, err := repo.qb.
+		Insert(postgres.OrderStatusTable).
+		Columns(
+			"order_id",
+			"from_status",
+			"to_status",
+			"changed_by",
+			"reason",
+			"changed_at",
+		).
+		Values(
+			change.OrderID,
+			string(change.From),
+			string(change.To),
+			change.ChangedBy,
+			change.Reason,
+			change.ChangedAt,
+		).ToSql()
+	if err != nil {
+		return psql.ErrCreateQuery(err)
+	}
+
+	tracing.SpanEvent(ctx, "Insert Order Status History query")
+	tracing.TraceVal(ctx, "sql", sql)
+	for i, arg := range args {
+		tracing.TraceIVal(ctx, "arg-"+strconv.Itoa(i), arg)
+	}
+
+	cmd, err := tx.Exec(ctx, sql, args...)
+	if err != nil {
+		return psql.ErrDoQuery(dal.FromPg(err))
+	}
+
+	if cmd.RowsAffected() == 0 {
+		return dal.ErrNothingInserted
+	}
+
+	event, err := statusChangedEvent(change)
+	if err != nil {
+		return err
+	}
+
+	if err = repo.outbox.Append(ctx, tx, event); err != nil {
+		return err
+	}
+
+	entry, err := statusEntry(change)
+	if err != nil {
+		return err
+	}
+
+	return repo.audit.Append(ctx, tx, entry)
+}
+
+// Cancel gives the cancelled units of the order back to stock in the same
+// transaction that marks them cancelled on the order lines. The order moves
+// to cancelled once no line has units left. Cancelling an already cancelled
+// order, or repeating a partial cancellation, changes nothing.
+func (repo *OrderDAO) Cancel(ctx context.Context, cancellation model.Cancellation) (model.Order, error) {
+	err := psql.WithTx(ctx, repo.client, pgx.TxOptions{}, func(tx pgx.Tx) error {
+		status, err := repo.lockStatus(ctx, tx, cancellation.OrderID)
+		if err != nil {
+			return err
+		}
+
+		if status == model.StatusCancelled {
+			return nil
+		}
+
+		if err = model.CheckTransition(status, model.StatusCancelled); err != nil {
+			return err
+		}
+
+		lines, err := repo.lockLines(ctx, tx, cancellation.OrderID)
+		if err != nil {
+			return err
+		}
+
+		restock, full, err := model.PlanCancellation(lines, cancellation.Lines)
+		if err != nil {
+			return err
+		}
+
+		if err = repo.restock(ctx, tx, cancellation.OrderID, restock, cancellation.ChangedAt); err != nil {
+			return err
+		}
+
+		if !full {
+			return nil
+		}
+
+		if err = repo.updateStatus(ctx, tx, cancellation.OrderID, model.StatusCancelled); err != nil {
+			return err
+		}
+
+		return repo.insertStatusChange(ctx, tx, model.StatusChange{
+			OrderID:   cancellation.OrderID,
+			From:      status,
+			To:        model.StatusCancelled,
+			ChangedBy: cancellation.ChangedBy,
+			Reason:    cancellation.Reason,
+			ChangedAt: cancellation.ChangedAt,
+		})
+	})
+	if err != nil {
+		tracing.Error(ctx, err)
+
+		return model.Order{}, err
+	}
+
+	return repo.GetOrder(ctx, cancellation.OrderID)
+}
+
+func (repo *OrderDAO) restockRemaining(ctx context.Context, tx pgx.Tx, orderID string, at time.Time) error {
+	lines, err := repo.lockLines(ctx, tx, orderID)
+	if err != nil {
+		return err
+	}
+
+	restock, _, err := model.PlanCancellation(lines, nil)
+	if err != nil {
+		return err
+	}
+
+	return repo.restock(ctx, tx, orderID, restock, at)
+}
+
+// lockLines locks the order lines in product id order, the same order
+// products are locked in when stock is restored.
+func (repo *OrderDAO) lockLines(ctx context.Context, tx pgx.Tx, orderID string) ([]model.OrderProduct, error) {
+	query, args, err := repo.qb.
+		Select(
+			"product_id",
+			"quantity",
+			"cancelled_quantity",
+			"price",
+		).
+		From(postgres.OrderProductTable).
+		Where(sq.Eq{"order_id": orderID}).
+		OrderBy("product_id").
+		Suffix("FOR UPDATE").
+		ToSql()
+	if err != nil {
+		return nil, psql.ErrCreateQuery(err)
+	}
+
+	tracing.SpanEvent(ctx, "Lock Order Products")
+	tracing.TraceVal(ctx, "SQL", query)
+	for i, arg := range args {
+		tracing.TraceIVal(ctx, "arg-"+strconv.Itoa(i), arg)
+	}
+
+	rows, err := tx.Query(ctx, query, args...)
+	if err != nil {
+		return nil, psql.ErrDoQuery(dal.FromPg(err))
+	}
+
+	defer rows.Close()
+
+	var lines []model.OrderProduct
+
+	for rows.Next() {
+		var e OrderProductStorage
+		if err = rows.Scan(
+			&e.ProductID,
+			&e.Quantity,
+			&e.CancelledQuantity,
+			&e.Price,
+		); err != nil {
+			return nil, psql.ErrScan(dal.FromPg(err))
+		}
+
+		lines = append(lines, model.OrderProduct{
+			ProductID:         e.ProductID,
+			Quantity:          e.Quantity,
+			CancelledQuantity: e.CancelledQuantity,
+			Price:             e.Price,
+		})
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, psql.ErrDoQuery(dal.FromPg(err))
+	}
+
+	return lines, nil
+}
+
+func (repo *OrderDAO) restock(ctx context.Context, tx pgx.Tx, orderID string, restock []model.Restock, at time.Time) error {
+	events := make([]outboxmodel.Event, 0, len(restock))
+	entries := make([]auditmodel.Entry, 0, 2*len(restock))
+
+	for _, r := range restock {
+		quantity, err := repo.incrementStock(ctx, tx, r)
+		if err != nil {
+			return err
+		}
+
+		cancelled, err := repo.markCancelled(ctx, tx, orderID, r)
+		if err != nil {
+			return err
+		}
+
+		event, err := stockChangedEvent(r.ProductID, quantity, r.Quantity, outboxmodel.StockReasonCancelled, orderID, at)
+		if err != nil {
+			return err
+		}
+
+		events = append(events, event)
+
+		stock, err := stockEntry(r.ProductID, quantity-r.Quantity, quantity, at)
+		if err != nil {
+			return err
+		}
+
+		line, err := cancelledEntry(orderID, r, cancelled, at)
+		if err != nil {
+			return err
+		}
+
+		entries = append(entries, stock, line)
+	}
+
+	if err := repo.outbox.Append(ctx, tx, events...); err != nil {
+		return err
+	}
+
+	return repo.audit.Append(ctx, tx, entries...)
+}
+
+// incrementStock gives restock back to the product and returns its new quantity.
+func (repo *OrderDAO) incrementStock(ctx context.Context, tx pgx.Tx, restock model.Restock) (int, error) {
+	sql, args, err := repo.qb.
+		Update(postgres.ProductTable).
+		Set("quantity", sq.Expr("quantity + ?", restock.Quantity)).
+		Set("version", sq.Expr("version + 1")).
+		Where(sq.Eq{"id": restock.ProductID}).
+		Suffix("RETURNING quantity").
+		ToSql()
+	if err != nil {
+		return 0, psql.ErrCreateQuery(err)
+	}
+
+	tracing.SpanEvent(ctx, "Increment Product stock")
+	tracing.TraceVal(ctx, "sql", sql)
+	for i, arg := range args {
+		tracing.TraceIVal(ctx, "arg-"+strconv.Itoa(i), arg)
+	}
+
+	var quantity int
+	if err = tx.QueryRow(ctx, sql, args...).Scan(&quantity); err != nil {
+		return 0, psql.ErrScan(dal.FromPg(err))
+	}
+
+	return quantity, nil
+}
+
+// markCancelled adds the restocked units to the cancelled quantity of the
+// line and returns the new cancelled quantity.
+func (repo *OrderDAO) markCancelled(ctx context.Context, tx pgx.Tx, orderID string, restock model.Restock) (int, error) {
+	sql, args, err := repo.qb.
+		Update(postgres.OrderProductTable).
+		Set("cancelled_quantity", sq.Expr("cancelled_quantity + ?", restock.Quantity)).
+		Where(sq.Eq{"order_id": orderID, "product_id": restock.ProductID}).
+		Suffix("RETURNING cancelled_quantity").
+		ToSql()
+	if err != nil {
+		return 0, psql.ErrCreateQuery(err)
+	}
+
+	tracing.SpanEvent(ctx, "Cancel Order Product")
+	tracing.TraceVal(ctx, "sql", sql)
+	for i, arg := range args {
+		tracing.TraceIVal(ctx, "arg-"+strconv.Itoa(i), arg)
+	}
+
+	var cancelled int
+	if err = tx.QueryRow(ctx, sql, args...).Scan(&cancelled); err != nil {
+		return 0, psql.ErrScan(dal.FromPg(err))
+	}
+
+	return cancelled, nil
+}
+
+// Details returns the order with the captured and current price of every line.
+func (repo *OrderDAO) Details(ctx context.Context, id string) (model.OrderDetails, error) {
+	order, err := repo.findByID(ctx, id)
+	if err != nil {
+		return model.OrderDetails{}, err
+	}
+
+	lines, err := repo.findLineDetails(ctx, []string{id})
+	if err != nil {
+		return model.OrderDetails{}, err
+	}
+
+	return model.NewOrderDetails(order.ToDomain(), lines[id]), nil
+}
+
+// UserOrders returns one page of the user's orders, newest first by default,
+// together with the cursor of the next page.
+func (repo *OrderDAO) UserOrders(ctx context.Context, filter model.OrderFilter) ([]model.OrderDetails, string, error) {
+	orders, err := repo.findBy(ctx, filter)
+	if err != nil {
+		return nil, "", err
+	}
+
+	orders, next := postgres.NextCursor(orders, filter.Page, func(e OrderStorage) pagination.Cursor {
+		return pagination.Cursor{CreatedAt: e.Timestamp, ID: e.ID}
+	})
+
+	ids := make([]string, len(orders))
+	for i, e := range orders {
+		ids[i] = e.ID
+	}
+
+	lines, err := repo.findLineDetails(ctx, ids)
+	if err != nil {
+		return nil, "", err
+	}
+
+	resp := make([]model.OrderDetails, len(orders))
+	for i, e := range orders {
+		resp[i] = model.NewOrderDetails(e.ToDomain(), lines[e.ID])
+	}
+
+	return resp, next, nil
+}
+
+func (repo *OrderDAO) findBy(ctx context.Context, filter model.OrderFilter) ([]OrderStorage, error) {
+	statement := repo.qb.
+		Select(
+			"id",
+			"user_id",
+			"status",
+			"timestamp",
+		).
+		From(postgres.OrderTable).
+		Where(sq.Eq{"user_id": filter.UserID})
+
+	if len(filter.Statuses) > 0 {
+		statuses := make([]string, len(filter.Statuses))
+		for i, status := range filter.Statuses {
+			statuses[i] = string(status)
+		}
+
+		statement = statement.Where(sq.Eq{"status": statuses})
+	}
+	if filter.From != nil {
+		statement = statement.Where(sq.GtOrEq{"timestamp": *filter.From})
+	}
+	if filter.To != nil {
+		statement = statement.Where(sq.Lt{"timestamp": *filter.To})
+	}
+
+	statement, err := postgres.PaginateBy(statement, filter.Page, "timestamp", "id")
+	if err != nil {
+		tracing.Error(ctx, err)
+
+		return nil, err
+	}
+
+	query, args, err := statement.ToSql()
+	if err != nil {
+		err = psql.ErrCreateQuery(err)
+		tracing.Error(ctx, err)
+
+		return nil, err
+	}
+
+	tracing.SpanEvent(ctx, "Select Orders")
+	tracing.TraceVal(ctx, "SQL", query)
+	for i, arg := range args {
+		tracing.TraceIVal(ctx, "arg-"+strconv.Itoa(i), arg)
+	}
+
+	rows, err := repo.client.Query(ctx, query, args...)
+	if err != nil {
+		err = psql.ErrDoQuery(dal.FromPg(err))
+		tracing.Error(ctx, err)
+
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	entities := make([]OrderStorage, 0, filter.Page.Limit+1)
+
+	for rows.Next() {
+		var e OrderStorage
+		if err = rows.Scan(
+			&e.ID,
+			&e.UserID,
+			&e.Status,
+			&e.Timestamp,
+		); err != nil {
+			err = psql.ErrScan(dal.FromPg(err))
+			tracing.Error(ctx, err)
+
+			return nil, err
+		}
+
+		entities = append(entities, e)
+	}
+
+	if err = rows.Err(); err != nil {
+		err = psql.ErrDoQuery(dal.FromPg(err))
+		tracing.Error(ctx, err)
+
+		return nil, err
+	}
+
+	return entities, nil
+}
+
+// findLineDetails loads the lines of all orderIDs at once, joined with the
+// product's current price, and groups them by order.
+func (repo *OrderDAO) findLineDetails(ctx context.Context, orderIDs []string) (map[string][]model.OrderLineDetails, error) {
+	lines := make(map[string][]model.OrderLineDetails, len(orderIDs))
+	if len(orderIDs) == 0 {
+		return lines, nil
+	}
+
+	statement := repo.qb.
+		Select(
+			"op.order_id",
+			"op.product_id",
+			"op.quantity",
+			"op.cancelled_quantity",
+			"op.price",
+			"p.price",
+		).
+		From(postgres.OrderProductTable+" op").
+		Join(postgres.ProductTable+" p ON p.id = op.product_id").
+		Where(sq.Eq{"op.order_id": orderIDs}).
+		OrderBy("op.order_id", "op.product_id")
+
+	query, args, err := statement.ToSql()
+	if err != nil {
+		err = psql.ErrCreateQuery(err)
+		tracing.Error(ctx, err)
+
+		return nil, err
+	}
+
+	tracing.SpanEvent(ctx, "Select Order Lines with current prices")
+	tracing.TraceVal(ctx, "SQL", query)
+	for i, arg := range args {
+		tracing.TraceIVal(ctx, "arg-"+strconv.Itoa(i), arg)
+	}
+
+	rows, err := repo.client.Query(ctx, query, args...)
+	if err != nil {
+		err = psql.ErrDoQuery(dal.FromPg(err))
+		tracing.Error(ctx, err)
+
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var e OrderLineDetailsStorage
+		if err = rows.Scan(
+			&e.OrderID,
+			&e.ProductID,
+			&e.Quantity,
+			&e.CancelledQuantity,
+			&e.Price,
+			&e.CurrentPrice,
+		); err != nil {
+			err = psql.ErrScan(dal.FromPg(err))
+			tracing.Error(ctx, err)
+
+			return nil, err
+		}
+
+		lines[e.OrderID] = append(lines[e.OrderID], e.ToDomain())
+	}
+
+	if err = rows.Err(); err != nil {
+		err = psql.ErrDoQuery(dal.FromPg(err))
+		tracing.Error(ctx, err)
+
+		return nil, err
+	}
+
+	return lines, nil
+}
@@ -0,0 +1,88 @@
+package model
+
+import "time"
+
+// CancelLine asks for Quantity units of the product's order line to be
+// cancelled in total. The quantity is absolute rather than a delta so that a
+// retried request doesn't cancel, and restock, the same units twice.
+type CancelLine struct {
+	ProductID string
+	Quantity  int
+}
+
+// Cancellation cancels the given lines of an order, or every line left when
+// Lines is empty.
+type Cancellation struct {
+	OrderID   string
+	Lines     []CancelLine
+	ChangedBy string
+	Reason    string
+	ChangedAt time.Time
+}
+
+func NewCancellation(orderID string, lines []CancelLine, changedBy, reason string, changedAt time.Time) Cancellation {
+	return Cancellation{
+		OrderID:   orderID,
+		Lines:     lines,
+		ChangedBy: changedBy,
+		Reason:    reason,
+		ChangedAt: changedAt,
+	}
+}
+
+// Restock is the quantity a cancellation gives back to a product.
+type Restock struct {
+	ProductID string
+	Quantity  int
+}
+
+// PlanCancellation works out how much of every order line goes back to stock
+// and whether the order ends up fully cancelled. Lines already cancelled up
+// to the requested quantity restock nothing. It returns an *InvalidCancelError
+// for products that aren't in the order or quantities above the ordered one.
+func PlanCancellation(lines []OrderProduct, requested []CancelLine) ([]Restock, bool, error) {
+	targets := make(map[string]int, len(lines))
+	if len(requested) == 0 {
+		for _, line := range lines {
+			targets[line.ProductID] = line.Quantity
+		}
+	}
+
+	ordered := make(map[string]int, len(lines))
+	for _, line := range lines {
+		ordered[line.ProductID] = line.Quantity
+	}
+
+	var invalid []InvalidCancelLine
+	for _, r := range requested {
+		quantity, ok := ordered[r.ProductID]
+		switch {
+		case !ok:
+			invalid = append(invalid, InvalidCancelLine{ProductID: r.ProductID, Reason: "not in the order"})
+		case r.Quantity > quantity:
+			invalid = append(invalid, InvalidCancelLine{ProductID: r.ProductID, Reason: "more than ordered"})
+		default:
+			targets[r.ProductID] = r.Quantity
+		}
+	}
+
+	if len(invalid) > 0 {
+		return nil, false, &InvalidCancelError{Lines: invalid}
+	}
+
+	full := true
+	restock := make([]Restock, 0, len(targets))
+	for _, line := range lines {
+		cancelled := line.CancelledQuantity
+		if target, ok := targets[line.ProductID]; ok && target > cancelled {
+			restock = append(restock, Restock{ProductID: line.ProductID, Quantity: target - cancelled})
+			cancelled = target
+		}
+
+		if cancelled < line.Quantity {
+			full = false
+		}
+	}
+
+	return restock, full, nil
+}
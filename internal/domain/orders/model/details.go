@@ -0,0 +1,79 @@
+package model
+
+import (
+	"github.com/Amore14rn/888Starz_test/pkg/common/core/pagination"
+	"math"
+	"time"
+)
+
+// OrderLineDetails is an order line with the price captured at purchase next
+// to the product's current price. Total only counts units not cancelled.
+type OrderLineDetails struct {
+	ProductID         string
+	Quantity          int
+	CancelledQuantity int
+	Price             float64
+	CurrentPrice      float64
+	Total             float64
+}
+
+func NewOrderLineDetails(line OrderProduct, currentPrice float64) OrderLineDetails {
+	return OrderLineDetails{
+		ProductID:         line.ProductID,
+		Quantity:          line.Quantity,
+		CancelledQuantity: line.CancelledQuantity,
+		Price:             line.Price,
+		CurrentPrice:      currentPrice,
+		Total:             roundCents(line.Price * float64(line.Quantity-line.CancelledQuantity)),
+	}
+}
+
+// OrderDetails is an order as shown in the order history.
+type OrderDetails struct {
+	ID        string
+	UserID    string
+	Status    Status
+	Timestamp time.Time
+	Lines     []OrderLineDetails
+	Total     float64
+}
+
+func NewOrderDetails(order Order, lines []OrderLineDetails) OrderDetails {
+	var total float64
+	for _, line := range lines {
+		total += line.Total
+	}
+
+	return OrderDetails{
+		ID:        order.ID,
+		UserID:    order.UserID,
+		Status:    order.Status,
+		Timestamp: order.Timestamp,
+		Lines:     lines,
+		Total:     roundCents(total),
+	}
+}
+
+func roundCents(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
+
+// OrderFilter selects a page of a user's orders. From is inclusive, To is
+// exclusive and an empty Statuses matches every status.
+type OrderFilter struct {
+	UserID   string
+	Statuses []Status
+	From     *time.Time
+	To       *time.Time
+	Page     pagination.Params
+}
+
+func NewOrderFilter(userID string, statuses []Status, from, to *time.Time, page pagination.Params) OrderFilter {
+	return OrderFilter{
+		UserID:   userID,
+		Statuses: statuses,
+		From:     from,
+		To:       to,
+		Page:     page,
+	}
+}
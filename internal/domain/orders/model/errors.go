@@ -0,0 +1,80 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+)
+
+// StockShortage describes an order line that cannot be satisfied from stock.
+type StockShortage struct {
+	ProductID string `json:"product_id"`
+	Requested int    `json:"requested"`
+	Available int    `json:"available"`
+}
+
+// OutOfStockError is returned when at least one order line exceeds the stock left.
+type OutOfStockError struct {
+	Shortages []StockShortage
+}
+
+func (e *OutOfStockError) Error() string {
+	parts := make([]string, 0, len(e.Shortages))
+	for _, s := range e.Shortages {
+		parts = append(parts, fmt.Sprintf("%s (requested %d, available %d)", s.ProductID, s.Requested, s.Available))
+	}
+
+	return "products out of stock: " + strings.Join(parts, ", ")
+}
+
+// CheckStock compares the requested quantity of every product, summed over
+// its lines, against the available quantities and returns an *OutOfStockError
+// for every product that can't be satisfied. Products missing from available
+// are treated as having no stock left.
+func CheckStock(available map[string]int, products []OrderProduct) error {
+	var shortages []StockShortage
+	for _, p := range mergeLines(products) {
+		if left := available[p.ProductID]; left < p.Quantity {
+			shortages = append(shortages, StockShortage{
+				ProductID: p.ProductID,
+				Requested: p.Quantity,
+				Available: left,
+			})
+		}
+	}
+
+	if len(shortages) > 0 {
+		return &OutOfStockError{Shortages: shortages}
+	}
+
+	return nil
+}
+
+// IllegalTransitionError is returned when an order can't move between two statuses.
+type IllegalTransitionError struct {
+	From Status
+	To   Status
+}
+
+func (e *IllegalTransitionError) Error() string {
+	return fmt.Sprintf("order can't move from %q to %q", e.From, e.To)
+}
+
+// InvalidCancelLine describes a cancellation line that doesn't match the order.
+type InvalidCancelLine struct {
+	ProductID string
+	Reason    string
+}
+
+// InvalidCancelError is returned when a cancellation names lines the order can't give back.
+type InvalidCancelError struct {
+	Lines []InvalidCancelLine
+}
+
+func (e *InvalidCancelError) Error() string {
+	parts := make([]string, 0, len(e.Lines))
+	for _, l := range e.Lines {
+		parts = append(parts, fmt.Sprintf("%s (%s)", l.ProductID, l.Reason))
+	}
+
+	return "invalid cancellation lines: " + strings.Join(parts, ", ")
+}
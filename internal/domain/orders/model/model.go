@@ -0,0 +1,83 @@
+package model
+
+import "time"
+
+type Order struct {
+	ID        string
+	UserID    string
+	Status    Status
+	Products  []OrderProduct
+	Timestamp time.Time
+}
+
+func NewOrder(id, userID string, status Status, products []OrderProduct, timestamp time.Time) Order {
+	return Order{
+		ID:        id,
+		UserID:    userID,
+		Status:    status,
+		Products:  products,
+		Timestamp: timestamp,
+	}
+}
+
+type OrderProduct struct {
+	ProductID         string
+	Quantity          int
+	CancelledQuantity int
+	Price             float64
+}
+
+func (o *Order) AddProduct(product OrderProduct) {
+	o.Products = append(o.Products, product)
+}
+
+// Total is the sum of the lines at purchase prices, without cancelled units.
+func (o *Order) Total() float64 {
+	var total float64
+	for _, p := range o.Products {
+		total += p.Price * float64(p.Quantity-p.CancelledQuantity)
+	}
+
+	return roundCents(total)
+}
+
+type CreateOrder struct {
+	ID        string
+	UserID    string
+	Products  []OrderProduct
+	Timestamp time.Time
+}
+
+// NewCreateOrder merges lines of the same product into one, an order has a
+// single line per product.
+func NewCreateOrder(id, userID string, products []OrderProduct, timestamp time.Time) CreateOrder {
+	return CreateOrder{
+		ID:        id,
+		UserID:    userID,
+		Products:  mergeLines(products),
+		Timestamp: timestamp,
+	}
+}
+
+// mergeLines sums the quantities of lines with the same product, keeping the
+// position of its first line.
+func mergeLines(products []OrderProduct) []OrderProduct {
+	merged := make([]OrderProduct, 0, len(products))
+	index := make(map[string]int, len(products))
+
+	for _, p := range products {
+		if i, ok := index[p.ProductID]; ok {
+			merged[i].Quantity += p.Quantity
+			continue
+		}
+
+		index[p.ProductID] = len(merged)
+		merged = append(merged, p)
+	}
+
+	return merged
+}
+
+func (co CreateOrder) ToOrder() Order {
+	return NewOrder(co.ID, co.UserID, StatusPending, co.Products, co.Timestamp)
+}
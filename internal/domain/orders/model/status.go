@@ -0,0 +1,71 @@
+package model
+
+import "time"
+
+// Status is the lifecycle state of an order.
+type Status string
+
+const (
+	StatusPending   Status = "pending"
+	StatusPaid      Status = "paid"
+	StatusShipped   Status = "shipped"
+	StatusDelivered Status = "delivered"
+	StatusCancelled Status = "cancelled"
+)
+
+// transitions lists the statuses reachable from each status. Delivered and
+// cancelled orders are final.
+var transitions = map[Status][]Status{
+	StatusPending: {StatusPaid, StatusCancelled},
+	StatusPaid:    {StatusShipped, StatusCancelled},
+	StatusShipped: {StatusDelivered},
+}
+
+// CanTransitionTo reports whether an order in status s may move to next.
+func (s Status) CanTransitionTo(next Status) bool {
+	for _, allowed := range transitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+
+	return false
+}
+
+// CheckTransition returns an *IllegalTransitionError when from can't move to to.
+func CheckTransition(from, to Status) error {
+	if !from.CanTransitionTo(to) {
+		return &IllegalTransitionError{From: from, To: to}
+	}
+
+	return nil
+}
+
+// StatusTransition is a request to move an order into another status.
+type StatusTransition struct {
+	OrderID   string
+	To        Status
+	ChangedBy string
+	Reason    string
+	ChangedAt time.Time
+}
+
+func NewStatusTransition(orderID string, to Status, changedBy, reason string, changedAt time.Time) StatusTransition {
+	return StatusTransition{
+		OrderID:   orderID,
+		To:        to,
+		ChangedBy: changedBy,
+		Reason:    reason,
+		ChangedAt: changedAt,
+	}
+}
+
+// StatusChange is a row of the order status history.
+type StatusChange struct {
+	OrderID   string
+	From      Status
+	To        Status
+	ChangedBy string
+	Reason    string
+	ChangedAt time.Time
+}
@@ -0,0 +1,80 @@
+package service
+
+import (
+	"context"
+	"github.com/Amore14rn/888Starz_test/internal/domain/orders/model"
+	"github.com/Amore14rn/888Starz_test/pkg/errors"
+)
+
+type repository interface {
+	Create(ctx context.Context, req model.CreateOrder) (model.Order, error)
+	GetOrder(ctx context.Context, id string) (model.Order, error)
+	Transition(ctx context.Context, transition model.StatusTransition) (model.StatusChange, error)
+	Cancel(ctx context.Context, cancellation model.Cancellation) (model.Order, error)
+	Details(ctx context.Context, id string) (model.OrderDetails, error)
+	UserOrders(ctx context.Context, filter model.OrderFilter) ([]model.OrderDetails, string, error)
+}
+
+type OrderService struct {
+	repository repository
+}
+
+func NewOrderService(repository repository) *OrderService {
+	return &OrderService{
+		repository: repository,
+	}
+}
+
+func (s *OrderService) CreateOrder(ctx context.Context, req model.CreateOrder) (model.Order, error) {
+	order, err := s.repository.Create(ctx, req)
+	if err != nil {
+		return model.Order{}, errors.Wrap(err, "repository.CreateOrder")
+	}
+
+	return order, nil
+}
+
+func (s *OrderService) GetOrder(ctx context.Context, id string) (model.Order, error) {
+	order, err := s.repository.GetOrder(ctx, id)
+	if err != nil {
+		return model.Order{}, errors.Wrap(err, "repository.GetOrder")
+	}
+
+	return order, nil
+}
+
+func (s *OrderService) Transition(ctx context.Context, transition model.StatusTransition) (model.StatusChange, error) {
+	change, err := s.repository.Transition(ctx, transition)
+	if err != nil {
+		return model.StatusChange{}, errors.Wrap(err, "repository.Transition")
+	}
+
+	return change, nil
+}
+
+func (s *OrderService) CancelOrder(ctx context.Context, cancellation model.Cancellation) (model.Order, error) {
+	order, err := s.repository.Cancel(ctx, cancellation)
+	if err != nil {
+		return model.Order{}, errors.Wrap(err, "repository.Cancel")
+	}
+
+	return order, nil
+}
+
+func (s *OrderService) OrderDetails(ctx context.Context, id string) (model.OrderDetails, error) {
+	details, err := s.repository.Details(ctx, id)
+	if err != nil {
+		return model.OrderDetails{}, errors.Wrap(err, "repository.Details")
+	}
+
+	return details, nil
+}
+
+func (s *OrderService) UserOrders(ctx context.Context, filter model.OrderFilter) ([]model.OrderDetails, string, error) {
+	orders, next, err := s.repository.UserOrders(ctx, filter)
+	if err != nil {
+		return nil, "", errors.Wrap(err, "repository.UserOrders")
+	}
+
+	return orders, next, nil
+}
@@ -0,0 +1,39 @@
+package dao
+
+import (
+	"github.com/Amore14rn/888Starz_test/internal/domain/outbox/model"
+	"time"
+)
+
+type EventStorage struct {
+	ID            int64      `json:"id"`
+	AggregateType string     `json:"aggregate_type"`
+	AggregateID   string     `json:"aggregate_id"`
+	EventType     string     `json:"event_type"`
+	Payload       []byte     `json:"payload"`
+	OccurredAt    time.Time  `json:"occurred_at"`
+	Attempts      int        `json:"attempts"`
+	NextAttemptAt time.Time  `json:"next_attempt_at"`
+	LastError     *string    `json:"last_error"`
+	PublishedAt   *time.Time `json:"published_at"`
+}
+
+func (es *EventStorage) ToDomain() model.Event {
+	var lastError string
+	if es.LastError != nil {
+		lastError = *es.LastError
+	}
+
+	return model.Event{
+		ID:            es.ID,
+		AggregateType: es.AggregateType,
+		AggregateID:   es.AggregateID,
+		Type:          model.EventType(es.EventType),
+		Payload:       es.Payload,
+		OccurredAt:    es.OccurredAt,
+		Attempts:      es.Attempts,
+		NextAttemptAt: es.NextAttemptAt,
+		LastError:     lastError,
+		PublishedAt:   es.PublishedAt,
+	}
+}
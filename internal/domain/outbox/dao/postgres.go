@@ -0,0 +1,268 @@
+package dao
+
+import (
+	"context"
+	"github.com/Amore14rn/888Starz_test/internal/dal"
+	"github.com/Amore14rn/888Starz_test/internal/dal/postgres"
+	"github.com/Amore14rn/888Starz_test/internal/domain/outbox/model"
+	psql "github.com/Amore14rn/888Starz_test/pkg/postgresql"
+	"github.com/Amore14rn/888Starz_test/pkg/tracing"
+	sq "github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var eventColumns = []string{
+	"id",
+	"aggregate_type",
+	"aggregate_id",
+	"event_type",
+	"payload",
+	"occurred_at",
+	"attempts",
+	"next_attempt_at",
+	"last_error",
+	"published_at",
+}
+
+type OutboxDAO struct {
+	qb     sq.StatementBuilderType
+	client psql.Client
+}
+
+func NewOutboxDAO(client psql.Client) *OutboxDAO {
+	return &OutboxDAO{
+		qb:     sq.StatementBuilder.PlaceholderFormat(sq.Dollar),
+		client: client,
+	}
+}
+
+// Append writes events within tx, so they are only published if the state
+// change they describe is committed.
+func (repo *OutboxDAO) Append(ctx context.Context, tx pgx.Tx, events ...model.Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	statement := repo.qb.
+		Insert(postgres.OutboxTable).
+		Columns(
+			"aggregate_type",
+			"aggregate_id",
+			"event_type",
+			"payload",
+			"occurred_at",
+			"next_attempt_at",
+		)
+
+	for _, e := range events {
+		statement = statement.Values(
+			e.AggregateType,
+			e.AggregateID,
+			string(e.Type),
+			[]byte(e.Payload),
+			e.OccurredAt,
+			e.NextAttemptAt,
+		)
+	}
+
+	sql, args, err := statement.ToSql()
+	if err != nil {
+		return psql.ErrCreateQuery(err)
+	}
+
+	tracing.SpanEvent(ctx, "Insert Outbox Events query")
+	tracing.TraceVal(ctx, "sql", sql)
+	for i, arg := range args {
+		tracing.TraceIVal(ctx, "arg-"+strconv.Itoa(i), arg)
+	}
+
+	cmd, err := tx.Exec(ctx, sql, args...)
+	if err != nil {
+		return psql.ErrDoQuery(dal.FromPg(err))
+	}
+
+	if cmd.RowsAffected() == 0 {
+		return dal.ErrNothingInserted
+	}
+
+	return nil
+}
+
+// Claim leases up to limit events that are due at now until now+lease and
+// returns them in the order they were written. Only the oldest unpublished
+// event of every aggregate is eligible, so the next one waits until it is
+// published. An event whose lease runs out before it is published, because
+// the relay crashed, is claimed again.
+func (repo *OutboxDAO) Claim(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]model.Event, error) {
+	due := sq.
+		Select("o.id").
+		From(postgres.OutboxTable + " o").
+		Where(sq.Eq{"o.published_at": nil}).
+		Where(sq.LtOrEq{"o.next_attempt_at": now}).
+		Where("NOT EXISTS (SELECT 1 FROM " + postgres.OutboxTable + " p " +
+			"WHERE p.aggregate_type = o.aggregate_type AND p.aggregate_id = o.aggregate_id " +
+			"AND p.published_at IS NULL AND p.id < o.id)").
+		OrderBy("o.id").
+		Limit(uint64(limit)).
+		Suffix("FOR UPDATE SKIP LOCKED")
+
+	query, args, err := repo.qb.
+		Update(postgres.OutboxTable).
+		Set("next_attempt_at", now.Add(lease)).
+		Where(sq.Expr("id IN (?)", due)).
+		Suffix("RETURNING " + strings.Join(eventColumns, ", ")).
+		ToSql()
+	if err != nil {
+		err = psql.ErrCreateQuery(err)
+		tracing.Error(ctx, err)
+
+		return nil, err
+	}
+
+	tracing.SpanEvent(ctx, "Claim Outbox Events")
+	tracing.TraceVal(ctx, "SQL", query)
+	for i, arg := range args {
+		tracing.TraceIVal(ctx, "arg-"+strconv.Itoa(i), arg)
+	}
+
+	rows, err := repo.client.Query(ctx, query, args...)
+	if err != nil {
+		err = psql.ErrDoQuery(dal.FromPg(err))
+		tracing.Error(ctx, err)
+
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var events []model.Event
+
+	for rows.Next() {
+		var e EventStorage
+		if err = rows.Scan(
+			&e.ID,
+			&e.AggregateType,
+			&e.AggregateID,
+			&e.EventType,
+			&e.Payload,
+			&e.OccurredAt,
+			&e.Attempts,
+			&e.NextAttemptAt,
+			&e.LastError,
+			&e.PublishedAt,
+		); err != nil {
+			err = psql.ErrScan(dal.FromPg(err))
+			tracing.Error(ctx, err)
+
+			return nil, err
+		}
+
+		events = append(events, e.ToDomain())
+	}
+
+	if err = rows.Err(); err != nil {
+		err = psql.ErrDoQuery(dal.FromPg(err))
+		tracing.Error(ctx, err)
+
+		return nil, err
+	}
+
+	// RETURNING doesn't keep the order of the subquery
+	sort.Slice(events, func(i, j int) bool { return events[i].ID < events[j].ID })
+
+	return events, nil
+}
+
+func (repo *OutboxDAO) MarkPublished(ctx context.Context, id int64, publishedAt time.Time) error {
+	sql, args, err := repo.qb.
+		Update(postgres.OutboxTable).
+		Set("published_at", publishedAt).
+		Set("attempts", sq.Expr("attempts + 1")).
+		Set("last_error", nil).
+		Where(sq.Eq{"id": id}).
+		ToSql()
+	if err != nil {
+		err = psql.ErrCreateQuery(err)
+		tracing.Error(ctx, err)
+
+		return err
+	}
+
+	return repo.exec(ctx, "Mark Outbox Event published query", sql, args)
+}
+
+// Reschedule records a failed attempt and moves the event to nextAttemptAt.
+func (repo *OutboxDAO) Reschedule(ctx context.Context, id int64, nextAttemptAt time.Time, lastError string) error {
+	sql, args, err := repo.qb.
+		Update(postgres.OutboxTable).
+		Set("attempts", sq.Expr("attempts + 1")).
+		Set("next_attempt_at", nextAttemptAt).
+		Set("last_error", lastError).
+		Where(sq.Eq{"id": id}).
+		ToSql()
+	if err != nil {
+		err = psql.ErrCreateQuery(err)
+		tracing.Error(ctx, err)
+
+		return err
+	}
+
+	return repo.exec(ctx, "Reschedule Outbox Event query", sql, args)
+}
+
+func (repo *OutboxDAO) exec(ctx context.Context, event, sql string, args []any) error {
+	tracing.SpanEvent(ctx, event)
+	tracing.TraceVal(ctx, "sql", sql)
+	for i, arg := range args {
+		tracing.TraceIVal(ctx, "arg-"+strconv.Itoa(i), arg)
+	}
+
+	cmd, err := repo.client.Exec(ctx, sql, args...)
+	if err != nil {
+		err = psql.ErrDoQuery(dal.FromPg(err))
+		tracing.Error(ctx, err)
+
+		return err
+	}
+
+	if cmd.RowsAffected() == 0 {
+		return dal.ErrNotFound
+	}
+
+	return nil
+}
+
+// DeletePublished drops events published at or before before and returns
+// how many were dropped.
+func (repo *OutboxDAO) DeletePublished(ctx context.Context, before time.Time) (int64, error) {
+	sql, args, err := repo.qb.
+		Delete(postgres.OutboxTable).
+		Where(sq.LtOrEq{"published_at": before}).
+		ToSql()
+	if err != nil {
+		err = psql.ErrCreateQuery(err)
+		tracing.Error(ctx, err)
+
+		return 0, err
+	}
+
+	tracing.SpanEvent(ctx, "Delete published Outbox Events query")
+	tracing.TraceVal(ctx, "sql", sql)
+	for i, arg := range args {
+		tracing.TraceIVal(ctx, "arg-"+strconv.Itoa(i), arg)
+	}
+
+	cmd, err := repo.client.Exec(ctx, sql, args...)
+	if err != nil {
+		err = psql.ErrDoQuery(dal.FromPg(err))
+		tracing.Error(ctx, err)
+
+		return 0, err
+	}
+
+	return cmd.RowsAffected(), nil
+}
@@ -0,0 +1,101 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type EventType string
+
+const (
+	EventOrderCreated        EventType = "order.created"
+	EventOrderStatusChanged  EventType = "order.status_changed"
+	EventProductStockChanged EventType = "product.stock_changed"
+	EventProductPriceChanged EventType = "product.price_changed"
+)
+
+const (
+	AggregateOrder   = "order"
+	AggregateProduct = "product"
+)
+
+// Event is a domain event written to the outbox in the same transaction as
+// the state change it describes. Events of one aggregate are published in
+// the order they were written.
+type Event struct {
+	ID            int64
+	AggregateType string
+	AggregateID   string
+	Type          EventType
+	Payload       json.RawMessage
+	OccurredAt    time.Time
+	Attempts      int
+	NextAttemptAt time.Time
+	LastError     string
+	PublishedAt   *time.Time
+}
+
+func NewEvent(aggregateType, aggregateID string, eventType EventType, payload any, occurredAt time.Time) (Event, error) {
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return Event{}, err
+	}
+
+	return Event{
+		AggregateType: aggregateType,
+		AggregateID:   aggregateID,
+		Type:          eventType,
+		Payload:       raw,
+		OccurredAt:    occurredAt,
+		NextAttemptAt: occurredAt,
+	}, nil
+}
+
+type OrderLine struct {
+	ProductID string  `json:"product_id"`
+	Quantity  int     `json:"quantity"`
+	Price     float64 `json:"price"`
+}
+
+type OrderCreated struct {
+	OrderID   string      `json:"order_id"`
+	UserID    string      `json:"user_id"`
+	Status    string      `json:"status"`
+	Products  []OrderLine `json:"products"`
+	Timestamp time.Time   `json:"timestamp"`
+}
+
+type OrderStatusChanged struct {
+	OrderID   string    `json:"order_id"`
+	From      string    `json:"from"`
+	To        string    `json:"to"`
+	ChangedBy string    `json:"changed_by"`
+	Reason    string    `json:"reason,omitempty"`
+	ChangedAt time.Time `json:"changed_at"`
+}
+
+// StockChanged carries the new quantity and the difference to the previous
+// one, Delta is negative when stock was taken.
+type StockChanged struct {
+	ProductID string    `json:"product_id"`
+	Quantity  int       `json:"quantity"`
+	Delta     int       `json:"delta"`
+	Reason    string    `json:"reason"`
+	OrderID   string    `json:"order_id,omitempty"`
+	ChangedAt time.Time `json:"changed_at"`
+}
+
+type PriceChanged struct {
+	ProductID string    `json:"product_id"`
+	Price     float64   `json:"price"`
+	OldPrice  float64   `json:"old_price"`
+	ChangedAt time.Time `json:"changed_at"`
+}
+
+// Reasons a product's stock changed.
+const (
+	StockReasonCreated   = "product.created"
+	StockReasonUpdated   = "product.updated"
+	StockReasonOrdered   = "order.created"
+	StockReasonCancelled = "order.cancelled"
+)
@@ -0,0 +1,43 @@
+package publisher
+
+import (
+	"context"
+	"github.com/Amore14rn/888Starz_test/internal/domain/outbox/model"
+	"sync"
+)
+
+// Memory keeps published events in memory. It is meant for tests, Fail lets
+// a test reject chosen events.
+type Memory struct {
+	Fail func(event model.Event) error
+
+	mu     sync.Mutex
+	events []model.Event
+}
+
+func NewMemory() *Memory {
+	return &Memory{}
+}
+
+func (m *Memory) Publish(ctx context.Context, event model.Event) error {
+	if m.Fail != nil {
+		if err := m.Fail(event); err != nil {
+			return err
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.events = append(m.events, event)
+
+	return nil
+}
+
+// Events returns the events published so far, in publishing order.
+func (m *Memory) Events() []model.Event {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]model.Event(nil), m.events...)
+}
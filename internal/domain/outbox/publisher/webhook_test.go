@@ -0,0 +1,49 @@
+package publisher
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/Amore14rn/888Starz_test/internal/domain/outbox/model"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookPublish(t *testing.T) {
+	at := time.Date(2023, 9, 10, 12, 0, 0, 0, time.UTC)
+	event, err := model.NewEvent(model.AggregateOrder, "o1", model.EventOrderCreated, model.OrderCreated{OrderID: "o1"}, at)
+	require.NoError(t, err)
+	event.ID = 42
+
+	var got Envelope
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "42", r.Header.Get(HeaderEventID))
+		assert.Equal(t, string(model.EventOrderCreated), r.Header.Get(HeaderEventType))
+
+		body, _ := io.ReadAll(r.Body)
+		assert.NoError(t, json.Unmarshal(body, &got))
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	err = NewWebhook(server.URL, time.Second).Publish(context.Background(), event)
+
+	assert.NoError(t, err)
+	assert.Equal(t, NewEnvelope(event), got)
+}
+
+func TestWebhookPublishRejected(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	err := NewWebhook(server.URL, time.Second).Publish(context.Background(), model.Event{ID: 1, Payload: json.RawMessage(`{}`)})
+
+	assert.ErrorContains(t, err, "503")
+}
@@ -0,0 +1,31 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/Amore14rn/888Starz_test/internal/domain/outbox/model"
+)
+
+// Fanout publishes every event to all of its publishers. The event fails if
+// any of them fails and is then published to all of them again, so every
+// publisher has to tolerate duplicates.
+type Fanout struct {
+	publishers []Publisher
+}
+
+func NewFanout(publishers ...Publisher) *Fanout {
+	return &Fanout{
+		publishers: publishers,
+	}
+}
+
+func (f *Fanout) Publish(ctx context.Context, event model.Event) error {
+	var errs []error
+	for _, p := range f.publishers {
+		if err := p.Publish(ctx, event); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
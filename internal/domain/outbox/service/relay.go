@@ -0,0 +1,115 @@
+package service
+
+import (
+	"context"
+	"github.com/Amore14rn/888Starz_test/internal/domain/outbox/model"
+	"github.com/Amore14rn/888Starz_test/pkg/common/core/clock"
+	"github.com/Amore14rn/888Starz_test/pkg/common/logging"
+	"time"
+)
+
+// Publisher delivers an event downstream. Delivery is at least once, so
+// consumers should deduplicate by event ID.
+type Publisher interface {
+	Publish(ctx context.Context, event model.Event) error
+}
+
+type RelayConfig struct {
+	Interval   time.Duration
+	BatchSize  int
+	Lease      time.Duration
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+}
+
+// Relay publishes pending outbox events. A failed event is retried with
+// exponential backoff and holds back later events of its aggregate until it
+// is published.
+type Relay struct {
+	outbox    *OutboxService
+	publisher Publisher
+	clock     clock.Clock
+	cfg       RelayConfig
+}
+
+func NewRelay(outbox *OutboxService, publisher Publisher, clock clock.Clock, cfg RelayConfig) *Relay {
+	return &Relay{
+		outbox:    outbox,
+		publisher: publisher,
+		clock:     clock,
+		cfg:       cfg,
+	}
+}
+
+// Run relays events until ctx is done. A full batch is followed by the next
+// one right away, otherwise the relay waits for the interval.
+func (r *Relay) Run(ctx context.Context) error {
+	for {
+		claimed, err := r.RelayOnce(ctx)
+		if err != nil {
+			logging.WithError(ctx, err).Error("outbox relay failed")
+		}
+
+		if err == nil && claimed == r.cfg.BatchSize {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-r.clock.After(r.cfg.Interval):
+		}
+	}
+}
+
+// RelayOnce publishes one batch of due events and returns how many were claimed.
+func (r *Relay) RelayOnce(ctx context.Context) (int, error) {
+	now := r.clock.Now()
+
+	events, err := r.outbox.Claim(ctx, now, r.cfg.Lease, r.cfg.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, event := range events {
+		if ctx.Err() != nil {
+			// Unpublished events are claimed again once their lease runs out
+			return len(events), nil
+		}
+
+		if err = r.publisher.Publish(ctx, event); err != nil {
+			logging.WithError(ctx, err).With(
+				logging.Int64Field("event_id", event.ID),
+				logging.StringField("event_type", string(event.Type)),
+			).Warn("failed to publish outbox event")
+
+			next := r.clock.Now().Add(Backoff(event.Attempts, r.cfg.MinBackoff, r.cfg.MaxBackoff))
+			if err = r.outbox.Reschedule(ctx, event.ID, next, err.Error()); err != nil {
+				return len(events), err
+			}
+
+			continue
+		}
+
+		if err = r.outbox.MarkPublished(ctx, event.ID, r.clock.Now()); err != nil {
+			return len(events), err
+		}
+	}
+
+	return len(events), nil
+}
+
+// Backoff is the delay before the retry that follows attempts failed
+// attempts: min doubled per attempt, capped at max.
+func Backoff(attempts int, min, max time.Duration) time.Duration {
+	delay := min
+	for i := 0; i < attempts && delay < max; i++ {
+		delay *= 2
+	}
+
+	if delay > max {
+		return max
+	}
+
+	return delay
+}
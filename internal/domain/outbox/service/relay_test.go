@@ -0,0 +1,127 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"github.com/Amore14rn/888Starz_test/internal/domain/outbox/model"
+	"github.com/Amore14rn/888Starz_test/internal/domain/outbox/publisher"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockRepository struct {
+	mock.Mock
+}
+
+func (m *MockRepository) Claim(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]model.Event, error) {
+	args := m.Called(ctx, now, lease, limit)
+	return args.Get(0).([]model.Event), args.Error(1)
+}
+
+func (m *MockRepository) MarkPublished(ctx context.Context, id int64, publishedAt time.Time) error {
+	args := m.Called(ctx, id, publishedAt)
+	return args.Error(0)
+}
+
+func (m *MockRepository) Reschedule(ctx context.Context, id int64, nextAttemptAt time.Time, lastError string) error {
+	args := m.Called(ctx, id, nextAttemptAt, lastError)
+	return args.Error(0)
+}
+
+func (m *MockRepository) DeletePublished(ctx context.Context, before time.Time) (int64, error) {
+	args := m.Called(ctx, before)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+type MockClock struct {
+	now time.Time
+}
+
+func (m MockClock) After(d time.Duration) <-chan time.Time { return nil }
+
+func (m MockClock) Now() time.Time { return m.now }
+
+func (m MockClock) Since(t time.Time) time.Duration { return m.now.Sub(t) }
+
+func (m MockClock) Until(t time.Time) time.Duration { return t.Sub(m.now) }
+
+func (m MockClock) Sleep(d time.Duration) {}
+
+func (m MockClock) Tick(d time.Duration) <-chan time.Time { return nil }
+
+var relayConfig = RelayConfig{
+	Interval:   time.Second,
+	BatchSize:  10,
+	Lease:      time.Minute,
+	MinBackoff: time.Second,
+	MaxBackoff: time.Minute,
+}
+
+func TestRelayOnce(t *testing.T) {
+	now := time.Date(2023, 9, 10, 12, 0, 0, 0, time.UTC)
+	events := []model.Event{
+		{ID: 1, AggregateType: model.AggregateOrder, AggregateID: "o1", Type: model.EventOrderCreated},
+		{ID: 2, AggregateType: model.AggregateProduct, AggregateID: "p1", Type: model.EventProductStockChanged, Attempts: 3},
+		{ID: 3, AggregateType: model.AggregateProduct, AggregateID: "p2", Type: model.EventProductPriceChanged},
+	}
+
+	mockRepo := new(MockRepository)
+	mockRepo.On("Claim", mock.Anything, now, time.Minute, 10).Return(events, nil)
+	mockRepo.On("MarkPublished", mock.Anything, int64(1), now).Return(nil)
+	mockRepo.On("Reschedule", mock.Anything, int64(2), now.Add(8*time.Second), "receiver is down").Return(nil)
+	mockRepo.On("MarkPublished", mock.Anything, int64(3), now).Return(nil)
+
+	pub := publisher.NewMemory()
+	pub.Fail = func(event model.Event) error {
+		if event.ID == 2 {
+			return errors.New("receiver is down")
+		}
+
+		return nil
+	}
+
+	relay := NewRelay(NewOutboxService(mockRepo), pub, MockClock{now: now}, relayConfig)
+
+	claimed, err := relay.RelayOnce(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, claimed)
+	assert.Equal(t, []model.Event{events[0], events[2]}, pub.Events())
+	mockRepo.AssertExpectations(t)
+}
+
+func TestRelayOnceStopsOnStoreError(t *testing.T) {
+	now := time.Date(2023, 9, 10, 12, 0, 0, 0, time.UTC)
+	events := []model.Event{{ID: 1}, {ID: 2}}
+
+	mockRepo := new(MockRepository)
+	mockRepo.On("Claim", mock.Anything, now, time.Minute, 10).Return(events, nil)
+	mockRepo.On("MarkPublished", mock.Anything, int64(1), now).Return(errors.New("connection reset"))
+
+	pub := publisher.NewMemory()
+	relay := NewRelay(NewOutboxService(mockRepo), pub, MockClock{now: now}, relayConfig)
+
+	_, err := relay.RelayOnce(context.Background())
+
+	assert.Error(t, err)
+	// The second event stays leased and is published after the lease runs out
+	assert.Len(t, pub.Events(), 1)
+	mockRepo.AssertNotCalled(t, "MarkPublished", mock.Anything, int64(2), mock.Anything)
+}
+
+func TestBackoff(t *testing.T) {
+	tests := map[int]time.Duration{
+		0:  time.Second,
+		1:  2 * time.Second,
+		5:  32 * time.Second,
+		6:  time.Minute,
+		40: time.Minute,
+	}
+
+	for attempts, want := range tests {
+		assert.Equal(t, want, Backoff(attempts, time.Second, time.Minute), "attempts %d", attempts)
+	}
+}
@@ -0,0 +1,59 @@
+package service
+
+import (
+	"context"
+	"github.com/Amore14rn/888Starz_test/internal/domain/outbox/model"
+	"github.com/Amore14rn/888Starz_test/pkg/errors"
+	"time"
+)
+
+type repository interface {
+	Claim(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]model.Event, error)
+	MarkPublished(ctx context.Context, id int64, publishedAt time.Time) error
+	Reschedule(ctx context.Context, id int64, nextAttemptAt time.Time, lastError string) error
+	DeletePublished(ctx context.Context, before time.Time) (int64, error)
+}
+
+type OutboxService struct {
+	repository repository
+}
+
+func NewOutboxService(repository repository) *OutboxService {
+	return &OutboxService{
+		repository: repository,
+	}
+}
+
+func (s *OutboxService) Claim(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]model.Event, error) {
+	events, err := s.repository.Claim(ctx, now, lease, limit)
+	if err != nil {
+		return nil, errors.Wrap(err, "repository.Claim")
+	}
+
+	return events, nil
+}
+
+func (s *OutboxService) MarkPublished(ctx context.Context, id int64, publishedAt time.Time) error {
+	if err := s.repository.MarkPublished(ctx, id, publishedAt); err != nil {
+		return errors.Wrap(err, "repository.MarkPublished")
+	}
+
+	return nil
+}
+
+func (s *OutboxService) Reschedule(ctx context.Context, id int64, nextAttemptAt time.Time, lastError string) error {
+	if err := s.repository.Reschedule(ctx, id, nextAttemptAt, lastError); err != nil {
+		return errors.Wrap(err, "repository.Reschedule")
+	}
+
+	return nil
+}
+
+func (s *OutboxService) DeletePublished(ctx context.Context, before time.Time) (int64, error) {
+	deleted, err := s.repository.DeletePublished(ctx, before)
+	if err != nil {
+		return 0, errors.Wrap(err, "repository.DeletePublished")
+	}
+
+	return deleted, nil
+}
@@ -0,0 +1,28 @@
+package access
+
+import (
+	"context"
+	"github.com/Amore14rn/888Starz_test/internal/apperror"
+	"github.com/Amore14rn/888Starz_test/pkg/common/auth"
+)
+
+// FieldRequiredRole names the role missing from the caller in ErrForbidden fields.
+const FieldRequiredRole = "required_role"
+
+// RequireRole returns the authenticated caller when their role grants required.
+// Anonymous callers get ErrUnauthorized, callers without the role get
+// ErrForbidden naming the required role.
+func RequireRole(ctx context.Context, required auth.Role) (auth.Principal, error) {
+	principal, ok := auth.PrincipalFromContext(ctx)
+	if !ok {
+		return auth.Principal{}, apperror.ErrUnauthorized
+	}
+
+	if !principal.Role.Grants(required) {
+		return auth.Principal{}, apperror.ErrForbidden.WithDetails(apperror.ErrorFields{
+			FieldRequiredRole: string(required),
+		})
+	}
+
+	return principal, nil
+}
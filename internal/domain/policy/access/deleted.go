@@ -0,0 +1,21 @@
+package access
+
+import (
+	"context"
+	"github.com/Amore14rn/888Starz_test/internal/dal"
+	"github.com/Amore14rn/888Starz_test/pkg/common/auth"
+)
+
+// IncludeDeleted lets reads in the returned context see soft deleted rows
+// when include is set. Only admins may look at deleted rows.
+func IncludeDeleted(ctx context.Context, include bool) (context.Context, error) {
+	if !include {
+		return ctx, nil
+	}
+
+	if _, err := RequireRole(ctx, auth.RoleAdmin); err != nil {
+		return ctx, err
+	}
+
+	return dal.WithDeleted(ctx), nil
+}
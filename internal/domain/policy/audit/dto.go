@@ -0,0 +1,22 @@
+package audit
+
+import (
+	"github.com/Amore14rn/888Starz_test/internal/domain/audit/model"
+	"time"
+)
+
+// AllEntriesInput filters the audit log, To is exclusive.
+type AllEntriesInput struct {
+	EntityType model.EntityType
+	EntityID   string
+	From       *time.Time
+	To         *time.Time
+	Limit      int
+	Cursor     string
+	Sort       string
+}
+
+type AllEntriesOutput struct {
+	Entries    []model.Entry
+	NextCursor string
+}
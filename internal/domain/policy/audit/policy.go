@@ -0,0 +1,46 @@
+package audit
+
+import (
+	"context"
+	"github.com/Amore14rn/888Starz_test/internal/apperror"
+	"github.com/Amore14rn/888Starz_test/internal/domain/audit/model"
+	"github.com/Amore14rn/888Starz_test/internal/domain/audit/service"
+	"github.com/Amore14rn/888Starz_test/internal/domain/policy/access"
+	"github.com/Amore14rn/888Starz_test/pkg/common/auth"
+	"github.com/Amore14rn/888Starz_test/pkg/common/core/pagination"
+)
+
+type Policy struct {
+	auditService *service.AuditService
+}
+
+func NewAuditPolicy(auditService *service.AuditService) *Policy {
+	return &Policy{
+		auditService: auditService,
+	}
+}
+
+// All returns a page of the audit log, only admins may read it.
+func (p *Policy) All(ctx context.Context, input AllEntriesInput) (AllEntriesOutput, error) {
+	if _, err := access.RequireRole(ctx, auth.RoleAdmin); err != nil {
+		return AllEntriesOutput{}, err
+	}
+
+	filter := model.NewFilter(
+		input.EntityType,
+		input.EntityID,
+		input.From,
+		input.To,
+		pagination.NewParams(input.Limit, input.Cursor, pagination.Sort(input.Sort)),
+	)
+
+	entries, next, err := p.auditService.All(ctx, filter)
+	if err != nil {
+		return AllEntriesOutput{}, apperror.Wrap(err, "Error when getting audit entries")
+	}
+
+	return AllEntriesOutput{
+		Entries:    entries,
+		NextCursor: next,
+	}, nil
+}
@@ -0,0 +1,66 @@
+package audit
+
+import (
+	"context"
+	"github.com/Amore14rn/888Starz_test/internal/apperror"
+	"github.com/Amore14rn/888Starz_test/internal/domain/audit/model"
+	"github.com/Amore14rn/888Starz_test/internal/domain/audit/service"
+	"github.com/Amore14rn/888Starz_test/pkg/common/auth"
+	"github.com/Amore14rn/888Starz_test/pkg/common/core/pagination"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockRepository struct {
+	mock.Mock
+}
+
+func (m *MockRepository) All(ctx context.Context, filter model.Filter) ([]model.Entry, string, error) {
+	args := m.Called(ctx, filter)
+	return args.Get(0).([]model.Entry), args.String(1), args.Error(2)
+}
+
+func TestAll(t *testing.T) {
+	mockRepo := new(MockRepository)
+	policy := NewAuditPolicy(service.NewAuditService(mockRepo))
+	admin := auth.ContextWithPrincipal(context.Background(), auth.Principal{UserID: "a1", Role: auth.RoleAdmin})
+
+	entries := []model.Entry{{ID: 1, Action: model.ActionUpdate, EntityType: model.EntityProduct, EntityID: "p1"}}
+	mockRepo.On("All", admin, mock.MatchedBy(func(f model.Filter) bool {
+		return f.EntityType == model.EntityProduct && f.EntityID == "p1" && f.Page.Limit == 10
+	})).Return(entries, "next", nil)
+
+	output, err := policy.All(admin, AllEntriesInput{EntityType: model.EntityProduct, EntityID: "p1", Limit: 10})
+
+	require.NoError(t, err)
+	assert.Equal(t, entries, output.Entries)
+	assert.Equal(t, "next", output.NextCursor)
+}
+
+func TestAllInvalidCursor(t *testing.T) {
+	mockRepo := new(MockRepository)
+	policy := NewAuditPolicy(service.NewAuditService(mockRepo))
+	admin := auth.ContextWithPrincipal(context.Background(), auth.Principal{UserID: "a1", Role: auth.RoleAdmin})
+
+	mockRepo.On("All", admin, mock.Anything).Return([]model.Entry(nil), "", pagination.ErrInvalidCursor)
+
+	_, err := policy.All(admin, AllEntriesInput{Cursor: "bogus"})
+	assert.ErrorIs(t, err, apperror.ErrValidation)
+}
+
+func TestAllRequiresAdmin(t *testing.T) {
+	mockRepo := new(MockRepository)
+	policy := NewAuditPolicy(service.NewAuditService(mockRepo))
+	manager := auth.ContextWithPrincipal(context.Background(), auth.Principal{UserID: "m1", Role: auth.RoleManager})
+
+	_, err := policy.All(context.Background(), AllEntriesInput{})
+	assert.ErrorIs(t, err, apperror.ErrUnauthorized)
+
+	_, err = policy.All(manager, AllEntriesInput{})
+	assert.ErrorIs(t, err, apperror.ErrForbidden)
+
+	mockRepo.AssertNotCalled(t, "All", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,35 @@
+package auth
+
+import (
+	"github.com/Amore14rn/888Starz_test/pkg/common/core/token"
+)
+
+type LoginInput struct {
+	ID       string
+	Password string
+}
+
+func NewLoginInput(id, password string) LoginInput {
+	return LoginInput{
+		ID:       id,
+		Password: password,
+	}
+}
+
+type LoginOutput struct {
+	Tokens token.Pair
+}
+
+type RefreshInput struct {
+	RefreshToken string
+}
+
+func NewRefreshInput(refreshToken string) RefreshInput {
+	return RefreshInput{
+		RefreshToken: refreshToken,
+	}
+}
+
+type RefreshOutput struct {
+	Tokens token.Pair
+}
@@ -0,0 +1,87 @@
+package auth
+
+import (
+	"context"
+	"github.com/Amore14rn/888Starz_test/internal/apperror"
+	"github.com/Amore14rn/888Starz_test/internal/dal"
+	"github.com/Amore14rn/888Starz_test/internal/domain/policy/user"
+	"github.com/Amore14rn/888Starz_test/pkg/common/core/token"
+	"github.com/Amore14rn/888Starz_test/pkg/errors"
+	"time"
+)
+
+type UserAuthenticator interface {
+	Authenticate(ctx context.Context, input user.AuthenticateInput) (user.AuthenticateOutput, error)
+	GetUser(ctx context.Context, input user.GetUserInput) (user.GetUserOutput, error)
+}
+
+type TokenManager interface {
+	Issue(subject, role string, now time.Time) (token.Pair, error)
+	Parse(raw string, kind token.Kind, now time.Time) (token.Claims, error)
+}
+
+type Clock interface {
+	Now() time.Time
+}
+
+type Policy struct {
+	users  UserAuthenticator
+	tokens TokenManager
+	clock  Clock
+}
+
+func NewAuthPolicy(users UserAuthenticator, tokens TokenManager, clock Clock) *Policy {
+	return &Policy{
+		users:  users,
+		tokens: tokens,
+		clock:  clock,
+	}
+}
+
+func (p *Policy) Login(ctx context.Context, input LoginInput) (LoginOutput, error) {
+	authenticated, err := p.users.Authenticate(ctx, user.NewAuthenticateInput(input.ID, input.Password))
+	if err != nil {
+		// Unknown users and wrong passwords look the same to the caller.
+		if errors.Is(err, user.ErrInvalidCredentials) || errors.Is(err, dal.ErrNotFound) {
+			return LoginOutput{}, apperror.ErrUnauthorized
+		}
+
+		return LoginOutput{}, apperror.Wrap(err, "Error when authenticating user")
+	}
+
+	tokens, err := p.tokens.Issue(authenticated.User.ID, string(authenticated.User.Role), p.clock.Now())
+	if err != nil {
+		return LoginOutput{}, apperror.Wrap(err, "Error when issuing tokens")
+	}
+
+	return LoginOutput{
+		Tokens: tokens,
+	}, nil
+}
+
+func (p *Policy) Refresh(ctx context.Context, input RefreshInput) (RefreshOutput, error) {
+	claims, err := p.tokens.Parse(input.RefreshToken, token.KindRefresh, p.clock.Now())
+	if err != nil {
+		return RefreshOutput{}, apperror.ErrUnauthorized
+	}
+
+	// The user may have been deleted or had their role changed since the
+	// refresh token was issued.
+	current, err := p.users.GetUser(ctx, user.NewGetUserInput(claims.Subject))
+	if err != nil {
+		if errors.Is(err, dal.ErrNotFound) {
+			return RefreshOutput{}, apperror.ErrUnauthorized
+		}
+
+		return RefreshOutput{}, apperror.Wrap(err, "Error when getting user")
+	}
+
+	tokens, err := p.tokens.Issue(current.User.ID, string(current.User.Role), p.clock.Now())
+	if err != nil {
+		return RefreshOutput{}, apperror.Wrap(err, "Error when issuing tokens")
+	}
+
+	return RefreshOutput{
+		Tokens: tokens,
+	}, nil
+}
@@ -0,0 +1,48 @@
+package cart
+
+import (
+	"github.com/Amore14rn/888Starz_test/internal/domain/cart/model"
+	ordermodel "github.com/Amore14rn/888Starz_test/internal/domain/orders/model"
+)
+
+type GetCartOutput struct {
+	Cart model.Cart
+}
+
+type AddItemInput struct {
+	ProductID string
+	Quantity  int
+}
+
+func NewAddItemInput(productID string, quantity int) AddItemInput {
+	return AddItemInput{
+		ProductID: productID,
+		Quantity:  quantity,
+	}
+}
+
+type UpdateItemInput struct {
+	ProductID string
+	Quantity  int
+}
+
+func NewUpdateItemInput(productID string, quantity int) UpdateItemInput {
+	return UpdateItemInput{
+		ProductID: productID,
+		Quantity:  quantity,
+	}
+}
+
+type RemoveItemInput struct {
+	ProductID string
+}
+
+func NewRemoveItemInput(productID string) RemoveItemInput {
+	return RemoveItemInput{
+		ProductID: productID,
+	}
+}
+
+type CheckoutOutput struct {
+	Order ordermodel.OrderDetails
+}
@@ -0,0 +1,209 @@
+package cart
+
+import (
+	"context"
+	"github.com/Amore14rn/888Starz_test/internal/apperror"
+	"github.com/Amore14rn/888Starz_test/internal/dal"
+	"github.com/Amore14rn/888Starz_test/internal/domain/cart/model"
+	"github.com/Amore14rn/888Starz_test/internal/domain/cart/service"
+	ordermodel "github.com/Amore14rn/888Starz_test/internal/domain/orders/model"
+	"github.com/Amore14rn/888Starz_test/internal/domain/policy/orders"
+	"github.com/Amore14rn/888Starz_test/pkg/common/auth"
+	"github.com/Amore14rn/888Starz_test/pkg/errors"
+	"github.com/jackc/pgx/v5"
+	"time"
+)
+
+type OrderPlacer interface {
+	PlaceOrder(ctx context.Context, input orders.CreateOrderInput) (orders.CreateOrderOutput, error)
+	GetOrder(ctx context.Context, input orders.GetOrderInput) (orders.GetOrderOutput, error)
+}
+
+// Transactor runs fn in a transaction that the DAOs pick up from its context.
+type Transactor interface {
+	WithinTx(ctx context.Context, opts pgx.TxOptions, fn func(ctx context.Context) error) error
+}
+
+type IdentityGenerator interface {
+	GenerateUUIDv4String() string
+}
+
+type Clock interface {
+	Now() time.Time
+}
+
+// Metrics counts orders placed or rejected at checkout.
+type Metrics interface {
+	OrderCreated(total float64)
+	StockOutRejected()
+}
+
+type Policy struct {
+	cartService *service.CartService
+	orders      OrderPlacer
+	tx          Transactor
+
+	identity IdentityGenerator
+	clock    Clock
+	metrics  Metrics
+	ttl      time.Duration
+}
+
+func NewCartPolicy(cartService *service.CartService, orders OrderPlacer, tx Transactor, identity IdentityGenerator, clock Clock, metrics Metrics, ttl time.Duration) *Policy {
+	return &Policy{
+		cartService: cartService,
+		orders:      orders,
+		tx:          tx,
+		identity:    identity,
+		clock:       clock,
+		metrics:     metrics,
+		ttl:         ttl,
+	}
+}
+
+// GetCart returns the caller's cart, or an empty one when there is none.
+func (p *Policy) GetCart(ctx context.Context) (GetCartOutput, error) {
+	principal, ok := auth.PrincipalFromContext(ctx)
+	if !ok {
+		return GetCartOutput{}, apperror.ErrUnauthorized
+	}
+
+	cart, err := p.cartService.GetCart(ctx, principal.UserID, p.clock.Now())
+	if err != nil {
+		if errors.Is(err, dal.ErrNotFound) {
+			return GetCartOutput{Cart: model.NewEmptyCart(principal.UserID)}, nil
+		}
+
+		return GetCartOutput{}, apperror.Wrap(err, "Error when getting cart")
+	}
+
+	return GetCartOutput{
+		Cart: cart,
+	}, nil
+}
+
+func (p *Policy) AddItem(ctx context.Context, input AddItemInput) (GetCartOutput, error) {
+	principal, ok := auth.PrincipalFromContext(ctx)
+	if !ok {
+		return GetCartOutput{}, apperror.ErrUnauthorized
+	}
+
+	err := p.cartService.AddItem(ctx, p.change(principal, input.ProductID, input.Quantity))
+	if err != nil {
+		// Единственный внешний ключ строки корзины — товар
+		if errors.Is(err, dal.ErrForeignKeyViolation) {
+			return GetCartOutput{}, apperror.ErrNotFound.WithCause(err).WithDetails(apperror.ErrorFields{
+				"product_id": "product not found",
+			})
+		}
+
+		return GetCartOutput{}, apperror.Wrap(err, "Error when adding cart item")
+	}
+
+	return p.GetCart(ctx)
+}
+
+func (p *Policy) UpdateItem(ctx context.Context, input UpdateItemInput) (GetCartOutput, error) {
+	principal, ok := auth.PrincipalFromContext(ctx)
+	if !ok {
+		return GetCartOutput{}, apperror.ErrUnauthorized
+	}
+
+	err := p.cartService.SetItem(ctx, p.change(principal, input.ProductID, input.Quantity))
+	if err != nil {
+		return GetCartOutput{}, apperror.Wrap(err, "Error when updating cart item")
+	}
+
+	return p.GetCart(ctx)
+}
+
+func (p *Policy) RemoveItem(ctx context.Context, input RemoveItemInput) (GetCartOutput, error) {
+	principal, ok := auth.PrincipalFromContext(ctx)
+	if !ok {
+		return GetCartOutput{}, apperror.ErrUnauthorized
+	}
+
+	err := p.cartService.RemoveItem(ctx, p.change(principal, input.ProductID, 0))
+	if err != nil {
+		return GetCartOutput{}, apperror.Wrap(err, "Error when removing cart item")
+	}
+
+	return p.GetCart(ctx)
+}
+
+// Checkout places an order for everything in the caller's cart and drops the
+// cart in one transaction. The cart is locked for the whole transaction, so
+// items added meanwhile wait for it and land in a new cart instead of being
+// dropped with this one, and a concurrent checkout finds the cart gone. The
+// order is counted only once the transaction has committed.
+func (p *Policy) Checkout(ctx context.Context) (CheckoutOutput, error) {
+	principal, ok := auth.PrincipalFromContext(ctx)
+	if !ok {
+		return CheckoutOutput{}, apperror.ErrUnauthorized
+	}
+
+	var orderOutput orders.GetOrderOutput
+	err := p.tx.WithinTx(ctx, pgx.TxOptions{}, func(ctx context.Context) error {
+		cart, err := p.cartService.LockCart(ctx, principal.UserID, p.clock.Now())
+		if err != nil && !errors.Is(err, dal.ErrNotFound) {
+			return apperror.Wrap(err, "Error when getting cart")
+		}
+
+		if err != nil || cart.Empty() {
+			return apperror.ErrValidation.WithDetails(apperror.ErrorFields{
+				"cart": "cart is empty",
+			})
+		}
+
+		products := make([]ordermodel.OrderProduct, len(cart.Items))
+		for i, item := range cart.Items {
+			products[i] = ordermodel.OrderProduct{
+				ProductID: item.ProductID,
+				Quantity:  item.Quantity,
+			}
+		}
+
+		// Заказ получает идентификатор корзины
+		input := orders.NewCreateOrderInput(cart.UserID, products)
+		input.ID = cart.ID
+
+		if _, err = p.orders.PlaceOrder(ctx, input); err != nil {
+			return err
+		}
+
+		if err = p.cartService.DeleteCart(ctx, cart.ID); err != nil {
+			return apperror.Wrap(err, "Error when deleting cart")
+		}
+
+		orderOutput, err = p.orders.GetOrder(ctx, orders.NewGetOrderInput(cart.ID))
+
+		return err
+	})
+	if err != nil {
+		var outOfStock *ordermodel.OutOfStockError
+		if errors.As(err, &outOfStock) {
+			p.metrics.StockOutRejected()
+		}
+
+		return CheckoutOutput{}, err
+	}
+
+	p.metrics.OrderCreated(orderOutput.Order.Total)
+
+	return CheckoutOutput{
+		Order: orderOutput.Order,
+	}, nil
+}
+
+func (p *Policy) change(principal auth.Principal, productID string, quantity int) model.CartItemChange {
+	now := p.clock.Now()
+
+	return model.NewCartItemChange(
+		p.identity.GenerateUUIDv4String(),
+		principal.UserID,
+		productID,
+		quantity,
+		now,
+		now.Add(p.ttl),
+	)
+}
@@ -0,0 +1,258 @@
+package cart
+
+import (
+	"context"
+	"github.com/Amore14rn/888Starz_test/internal/apperror"
+	"github.com/Amore14rn/888Starz_test/internal/dal"
+	"github.com/Amore14rn/888Starz_test/internal/domain/cart/model"
+	"github.com/Amore14rn/888Starz_test/internal/domain/cart/service"
+	ordermodel "github.com/Amore14rn/888Starz_test/internal/domain/orders/model"
+	"github.com/Amore14rn/888Starz_test/internal/domain/policy/orders"
+	"github.com/Amore14rn/888Starz_test/pkg/common/auth"
+	psql "github.com/Amore14rn/888Starz_test/pkg/postgresql"
+	"github.com/jackc/pgx/v5"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockRepository struct {
+	mock.Mock
+}
+
+func (m *MockRepository) Get(ctx context.Context, userID string, now time.Time) (model.Cart, error) {
+	args := m.Called(ctx, userID, now)
+	return args.Get(0).(model.Cart), args.Error(1)
+}
+
+func (m *MockRepository) Lock(ctx context.Context, userID string, now time.Time) (model.Cart, error) {
+	args := m.Called(ctx, userID, now)
+	return args.Get(0).(model.Cart), args.Error(1)
+}
+
+func (m *MockRepository) AddItem(ctx context.Context, change model.CartItemChange) error {
+	return m.Called(ctx, change).Error(0)
+}
+
+func (m *MockRepository) SetItem(ctx context.Context, change model.CartItemChange) error {
+	return m.Called(ctx, change).Error(0)
+}
+
+func (m *MockRepository) RemoveItem(ctx context.Context, change model.CartItemChange) error {
+	return m.Called(ctx, change).Error(0)
+}
+
+func (m *MockRepository) Delete(ctx context.Context, id string) error {
+	return m.Called(ctx, id).Error(0)
+}
+
+func (m *MockRepository) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	args := m.Called(ctx, now)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+type MockOrderPlacer struct {
+	mock.Mock
+}
+
+func (m *MockOrderPlacer) PlaceOrder(ctx context.Context, input orders.CreateOrderInput) (orders.CreateOrderOutput, error) {
+	args := m.Called(ctx, input)
+	return args.Get(0).(orders.CreateOrderOutput), args.Error(1)
+}
+
+func (m *MockOrderPlacer) GetOrder(ctx context.Context, input orders.GetOrderInput) (orders.GetOrderOutput, error) {
+	args := m.Called(ctx, input)
+	return args.Get(0).(orders.GetOrderOutput), args.Error(1)
+}
+
+type txMarker struct{}
+
+// MockTransactor runs fn with a marked context and records what it returned,
+// i.e. whether a real transaction would have been rolled back.
+type MockTransactor struct {
+	err error
+}
+
+func (m *MockTransactor) WithinTx(ctx context.Context, _ pgx.TxOptions, fn func(ctx context.Context) error) error {
+	m.err = fn(context.WithValue(ctx, txMarker{}, true))
+	return m.err
+}
+
+var inTx = mock.MatchedBy(func(ctx context.Context) bool {
+	return ctx.Value(txMarker{}) != nil
+})
+
+type MockMetrics struct {
+	created   int
+	revenue   float64
+	stockOuts int
+}
+
+func (m *MockMetrics) OrderCreated(total float64) {
+	m.created++
+	m.revenue += total
+}
+
+func (m *MockMetrics) StockOutRejected() {
+	m.stockOuts++
+}
+
+type MockIdentityGenerator struct {
+}
+
+func (m MockIdentityGenerator) GenerateUUIDv4String() string {
+	return "some_mocked_uuid"
+}
+
+type MockClock struct {
+	now time.Time
+}
+
+func (m MockClock) Now() time.Time { return m.now }
+
+var now = time.Date(2023, 9, 10, 12, 0, 0, 0, time.UTC)
+
+func callerContext(userID string) context.Context {
+	return auth.ContextWithPrincipal(context.Background(), auth.Principal{UserID: userID})
+}
+
+func newPolicy(repo *MockRepository, placer *MockOrderPlacer, metrics *MockMetrics) *Policy {
+	return NewCartPolicy(service.NewCartService(repo), placer, new(MockTransactor), MockIdentityGenerator{}, MockClock{now: now}, metrics, time.Hour)
+}
+
+func TestAddItemExtendsTTL(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockRepo.On("AddItem", mock.Anything, model.NewCartItemChange("some_mocked_uuid", "u1", "p1", 2, now, now.Add(time.Hour))).Return(nil)
+	mockRepo.On("Get", mock.Anything, "u1", now).Return(model.NewCart("c1", "u1", []model.CartItem{model.NewCartItem("p1", "d", 2, 1.5, 1)}, now, now, now.Add(time.Hour)), nil)
+
+	output, err := newPolicy(mockRepo, new(MockOrderPlacer), &MockMetrics{}).AddItem(callerContext("u1"), NewAddItemInput("p1", 2))
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3.0, output.Cart.Total)
+	assert.False(t, output.Cart.Items[0].InStock)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestAddItemUnknownProduct(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockRepo.On("AddItem", mock.Anything, mock.Anything).Return(psql.ErrDoQuery(dal.ErrForeignKeyViolation))
+
+	_, err := newPolicy(mockRepo, new(MockOrderPlacer), &MockMetrics{}).AddItem(callerContext("u1"), NewAddItemInput("p9", 1))
+
+	assert.ErrorIs(t, err, apperror.ErrNotFound)
+}
+
+func TestGetCartWithoutCart(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockRepo.On("Get", mock.Anything, "u1", now).Return(model.Cart{}, dal.ErrNotFound)
+
+	output, err := newPolicy(mockRepo, new(MockOrderPlacer), &MockMetrics{}).GetCart(callerContext("u1"))
+
+	assert.NoError(t, err)
+	assert.True(t, output.Cart.Empty())
+	assert.Equal(t, "u1", output.Cart.UserID)
+}
+
+func TestCheckout(t *testing.T) {
+	cart := model.NewCart("c1", "u1", []model.CartItem{
+		model.NewCartItem("p1", "d1", 2, 1.5, 10),
+		model.NewCartItem("p2", "d2", 1, 3, 10),
+	}, now, now, now.Add(time.Hour))
+	order := ordermodel.OrderDetails{ID: "c1", UserID: "u1", Total: 6}
+
+	mockRepo := new(MockRepository)
+	mockRepo.On("Lock", inTx, "u1", now).Return(cart, nil)
+	mockRepo.On("Delete", inTx, "c1").Return(nil)
+
+	placer := new(MockOrderPlacer)
+	placer.On("PlaceOrder", inTx, orders.CreateOrderInput{
+		ID:     "c1",
+		UserID: "u1",
+		Products: []ordermodel.OrderProduct{
+			{ProductID: "p1", Quantity: 2},
+			{ProductID: "p2", Quantity: 1},
+		},
+	}).Return(orders.CreateOrderOutput{}, nil)
+	placer.On("GetOrder", inTx, orders.NewGetOrderInput("c1")).Return(orders.GetOrderOutput{Order: order}, nil)
+
+	metrics := &MockMetrics{}
+	output, err := newPolicy(mockRepo, placer, metrics).Checkout(callerContext("u1"))
+
+	assert.NoError(t, err)
+	assert.Equal(t, order, output.Order)
+	assert.Equal(t, 1, metrics.created)
+	assert.Equal(t, 6.0, metrics.revenue)
+	mockRepo.AssertNotCalled(t, "Get", mock.Anything, mock.Anything, mock.Anything)
+	mockRepo.AssertExpectations(t)
+	placer.AssertExpectations(t)
+}
+
+func TestCheckoutKeepsCartWhenOrderFails(t *testing.T) {
+	cart := model.NewCart("c1", "u1", []model.CartItem{model.NewCartItem("p1", "d1", 5, 1, 1)}, now, now, now.Add(time.Hour))
+
+	mockRepo := new(MockRepository)
+	mockRepo.On("Lock", inTx, "u1", now).Return(cart, nil)
+
+	placer := new(MockOrderPlacer)
+	stockErr := ordermodel.CheckStock(map[string]int{"p1": 1}, []ordermodel.OrderProduct{{ProductID: "p1", Quantity: 5}})
+	placer.On("PlaceOrder", mock.Anything, mock.Anything).Return(orders.CreateOrderOutput{}, apperror.ErrOutOfStock.WithCause(stockErr))
+
+	metrics := &MockMetrics{}
+	_, err := newPolicy(mockRepo, placer, metrics).Checkout(callerContext("u1"))
+
+	assert.ErrorIs(t, err, apperror.ErrOutOfStock)
+	assert.Equal(t, 1, metrics.stockOuts)
+	assert.Zero(t, metrics.created)
+	mockRepo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+}
+
+func TestCheckoutRollsBackOrderWhenCartDeleteFails(t *testing.T) {
+	cart := model.NewCart("c1", "u1", []model.CartItem{model.NewCartItem("p1", "d1", 1, 1, 1)}, now, now, now.Add(time.Hour))
+
+	mockRepo := new(MockRepository)
+	mockRepo.On("Lock", inTx, "u1", now).Return(cart, nil)
+	mockRepo.On("Delete", inTx, "c1").Return(psql.ErrDoQuery(context.DeadlineExceeded))
+
+	placer := new(MockOrderPlacer)
+	placer.On("PlaceOrder", inTx, mock.Anything).Return(orders.CreateOrderOutput{}, nil)
+
+	tx := new(MockTransactor)
+	metrics := &MockMetrics{}
+	policy := NewCartPolicy(service.NewCartService(mockRepo), placer, tx, MockIdentityGenerator{}, MockClock{now: now}, metrics, time.Hour)
+
+	_, err := policy.Checkout(callerContext("u1"))
+
+	assert.Error(t, err)
+	// The order was rolled back with the transaction
+	assert.Zero(t, metrics.created)
+	assert.ErrorIs(t, tx.err, context.DeadlineExceeded)
+	placer.AssertNotCalled(t, "GetOrder", mock.Anything, mock.Anything)
+}
+
+func TestCheckoutEmptyCart(t *testing.T) {
+	tests := map[string]struct {
+		cart model.Cart
+		err  error
+	}{
+		// A concurrent checkout that committed first has dropped the cart
+		"no cart":  {err: dal.ErrNotFound},
+		"no items": {cart: model.NewCart("c1", "u1", []model.CartItem{}, now, now, now.Add(time.Hour))},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			mockRepo := new(MockRepository)
+			mockRepo.On("Lock", inTx, "u1", now).Return(tt.cart, tt.err)
+
+			placer := new(MockOrderPlacer)
+
+			_, err := newPolicy(mockRepo, placer, &MockMetrics{}).Checkout(callerContext("u1"))
+
+			assert.ErrorIs(t, err, apperror.ErrValidation)
+			placer.AssertNotCalled(t, "PlaceOrder", mock.Anything, mock.Anything)
+			mockRepo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+		})
+	}
+}
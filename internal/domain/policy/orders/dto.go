@@ -0,0 +1,90 @@
+package orders
+
+import (
+	"github.com/Amore14rn/888Starz_test/internal/domain/orders/model"
+	"time"
+)
+
+// CreateOrderInput places an order. ID is only set by internal callers such
+// as the cart checkout, HTTP clients always get a generated one.
+type CreateOrderInput struct {
+	ID       string
+	UserID   string
+	Products []model.OrderProduct
+}
+
+func NewCreateOrderInput(userID string, products []model.OrderProduct) CreateOrderInput {
+	return CreateOrderInput{
+		UserID:   userID,
+		Products: products,
+	}
+}
+
+type CreateOrderOutput struct {
+	Order model.Order
+}
+
+type GetOrderInput struct {
+	ID string
+}
+
+func NewGetOrderInput(id string) GetOrderInput {
+	return GetOrderInput{
+		ID: id,
+	}
+}
+
+type GetOrderOutput struct {
+	Order model.OrderDetails
+}
+
+type UserOrdersInput struct {
+	UserID   string
+	Statuses []model.Status
+	From     *time.Time
+	To       *time.Time
+	Limit    int
+	Cursor   string
+	Sort     string
+}
+
+type UserOrdersOutput struct {
+	Orders     []model.OrderDetails
+	NextCursor string
+}
+
+type TransitionOrderInput struct {
+	ID     string
+	Status model.Status
+	Reason string
+}
+
+func NewTransitionOrderInput(id string, status model.Status, reason string) TransitionOrderInput {
+	return TransitionOrderInput{
+		ID:     id,
+		Status: status,
+		Reason: reason,
+	}
+}
+
+type TransitionOrderOutput struct {
+	Change model.StatusChange
+}
+
+type CancelOrderInput struct {
+	ID     string
+	Lines  []model.CancelLine
+	Reason string
+}
+
+func NewCancelOrderInput(id string, lines []model.CancelLine, reason string) CancelOrderInput {
+	return CancelOrderInput{
+		ID:     id,
+		Lines:  lines,
+		Reason: reason,
+	}
+}
+
+type CancelOrderOutput struct {
+	Order model.Order
+}
@@ -0,0 +1,281 @@
+package orders
+
+import (
+	"context"
+	"fmt"
+	"github.com/Amore14rn/888Starz_test/internal/apperror"
+	"github.com/Amore14rn/888Starz_test/internal/domain/orders/model"
+	"github.com/Amore14rn/888Starz_test/internal/domain/orders/service"
+	"github.com/Amore14rn/888Starz_test/internal/domain/policy/access"
+	"github.com/Amore14rn/888Starz_test/pkg/common/auth"
+	"github.com/Amore14rn/888Starz_test/pkg/common/core/clock"
+	"github.com/Amore14rn/888Starz_test/pkg/common/core/pagination"
+	"github.com/Amore14rn/888Starz_test/pkg/errors"
+	"time"
+)
+
+type IdentityGenerator interface {
+	GenerateUUIDv4String() string
+}
+
+type Clock interface {
+	Now() time.Time
+}
+
+// Metrics counts placed and rejected orders.
+type Metrics interface {
+	OrderCreated(total float64)
+	StockOutRejected()
+}
+
+type Policy struct {
+	orderService *service.OrderService
+
+	identity IdentityGenerator
+	clock    Clock
+	metrics  Metrics
+}
+
+func NewOrderPolicy(orderService *service.OrderService, identity IdentityGenerator, clock clock.Clock, metrics Metrics) *Policy {
+	return &Policy{
+		orderService: orderService,
+		identity:     identity,
+		clock:        clock,
+		metrics:      metrics,
+	}
+}
+
+func (p *Policy) CreateOrder(ctx context.Context, input CreateOrderInput) (CreateOrderOutput, error) {
+	output, err := p.PlaceOrder(ctx, input)
+
+	var outOfStock *model.OutOfStockError
+	switch {
+	case errors.As(err, &outOfStock):
+		p.metrics.StockOutRejected()
+	case err == nil:
+		p.metrics.OrderCreated(output.Order.Total())
+	}
+
+	return output, err
+}
+
+// PlaceOrder creates the order like CreateOrder but leaves it out of the
+// metrics. It is meant for callers that place the order within their own
+// transaction and count it once that commits.
+func (p *Policy) PlaceOrder(ctx context.Context, input CreateOrderInput) (CreateOrderOutput, error) {
+	// Orders are always placed on behalf of the authenticated caller
+	principal, ok := auth.PrincipalFromContext(ctx)
+	if !ok {
+		return CreateOrderOutput{}, apperror.ErrUnauthorized
+	}
+
+	if input.UserID != "" && input.UserID != principal.UserID {
+		return CreateOrderOutput{}, apperror.ErrForbidden
+	}
+	input.UserID = principal.UserID
+
+	// Идентификатор генерируется, если вызывающий не передал свой
+	id := input.ID
+	if id == "" {
+		id = p.identity.GenerateUUIDv4String()
+	}
+
+	createOrder := model.NewCreateOrder(
+		id,
+		input.UserID,
+		input.Products,
+		p.clock.Now(),
+	)
+
+	order, err := p.orderService.CreateOrder(ctx, createOrder)
+	if err != nil {
+		var outOfStock *model.OutOfStockError
+		if errors.As(err, &outOfStock) {
+			return CreateOrderOutput{}, outOfStockError(outOfStock)
+		}
+
+		return CreateOrderOutput{}, apperror.Wrap(err, "Error when creating an order")
+	}
+
+	return CreateOrderOutput{
+		Order: order,
+	}, nil
+}
+
+// GetOrder returns the order with its lines and totals to its owner or a manager.
+func (p *Policy) GetOrder(ctx context.Context, input GetOrderInput) (GetOrderOutput, error) {
+	principal, ok := auth.PrincipalFromContext(ctx)
+	if !ok {
+		return GetOrderOutput{}, apperror.ErrUnauthorized
+	}
+
+	order, err := p.orderService.OrderDetails(ctx, input.ID)
+	if err != nil {
+		return GetOrderOutput{}, apperror.Wrap(err, "Error when getting order")
+	}
+
+	if order.UserID != principal.UserID && !principal.Role.Grants(auth.RoleManager) {
+		return GetOrderOutput{}, apperror.ErrForbidden
+	}
+
+	return GetOrderOutput{
+		Order: order,
+	}, nil
+}
+
+// UserOrders returns a page of the user's order history. Customers only see
+// their own orders, managers see anyone's.
+func (p *Policy) UserOrders(ctx context.Context, input UserOrdersInput) (UserOrdersOutput, error) {
+	principal, ok := auth.PrincipalFromContext(ctx)
+	if !ok {
+		return UserOrdersOutput{}, apperror.ErrUnauthorized
+	}
+
+	if input.UserID != principal.UserID && !principal.Role.Grants(auth.RoleManager) {
+		return UserOrdersOutput{}, apperror.ErrForbidden
+	}
+
+	filter := model.NewOrderFilter(
+		input.UserID,
+		input.Statuses,
+		input.From,
+		input.To,
+		pagination.NewParams(input.Limit, input.Cursor, pagination.Sort(input.Sort)),
+	)
+
+	orders, next, err := p.orderService.UserOrders(ctx, filter)
+	if err != nil {
+		return UserOrdersOutput{}, apperror.Wrap(err, "Error when getting user orders")
+	}
+
+	return UserOrdersOutput{
+		Orders:     orders,
+		NextCursor: next,
+	}, nil
+}
+
+// TransitionOrder moves an order along its lifecycle. Managers may perform
+// any allowed transition, customers may only cancel their own orders.
+func (p *Policy) TransitionOrder(ctx context.Context, input TransitionOrderInput) (TransitionOrderOutput, error) {
+	principal, ok := auth.PrincipalFromContext(ctx)
+	if !ok {
+		return TransitionOrderOutput{}, apperror.ErrUnauthorized
+	}
+
+	if !principal.Role.Grants(auth.RoleManager) {
+		if err := p.checkOwner(ctx, principal, input.ID); err != nil {
+			return TransitionOrderOutput{}, err
+		}
+
+		if input.Status != model.StatusCancelled {
+			return TransitionOrderOutput{}, apperror.ErrForbidden.WithDetails(apperror.ErrorFields{
+				access.FieldRequiredRole: string(auth.RoleManager),
+			})
+		}
+	}
+
+	transition := model.NewStatusTransition(
+		input.ID,
+		input.Status,
+		principal.UserID,
+		input.Reason,
+		p.clock.Now(),
+	)
+
+	change, err := p.orderService.Transition(ctx, transition)
+	if err != nil {
+		var illegal *model.IllegalTransitionError
+		if errors.As(err, &illegal) {
+			return TransitionOrderOutput{}, invalidStateError(illegal)
+		}
+
+		return TransitionOrderOutput{}, apperror.Wrap(err, "Error when changing order status")
+	}
+
+	return TransitionOrderOutput{
+		Change: change,
+	}, nil
+}
+
+// CancelOrder cancels the whole order or some of its lines and gives the
+// units back to stock. Customers may cancel their own orders only.
+func (p *Policy) CancelOrder(ctx context.Context, input CancelOrderInput) (CancelOrderOutput, error) {
+	principal, ok := auth.PrincipalFromContext(ctx)
+	if !ok {
+		return CancelOrderOutput{}, apperror.ErrUnauthorized
+	}
+
+	if !principal.Role.Grants(auth.RoleManager) {
+		if err := p.checkOwner(ctx, principal, input.ID); err != nil {
+			return CancelOrderOutput{}, err
+		}
+	}
+
+	cancellation := model.NewCancellation(
+		input.ID,
+		input.Lines,
+		principal.UserID,
+		input.Reason,
+		p.clock.Now(),
+	)
+
+	order, err := p.orderService.CancelOrder(ctx, cancellation)
+	if err != nil {
+		var illegal *model.IllegalTransitionError
+		if errors.As(err, &illegal) {
+			return CancelOrderOutput{}, invalidStateError(illegal)
+		}
+
+		var invalid *model.InvalidCancelError
+		if errors.As(err, &invalid) {
+			return CancelOrderOutput{}, invalidCancelError(invalid)
+		}
+
+		return CancelOrderOutput{}, apperror.Wrap(err, "Error when cancelling order")
+	}
+
+	return CancelOrderOutput{
+		Order: order,
+	}, nil
+}
+
+// checkOwner allows the request only when the order belongs to principal.
+func (p *Policy) checkOwner(ctx context.Context, principal auth.Principal, orderID string) error {
+	order, err := p.orderService.GetOrder(ctx, orderID)
+	if err != nil {
+		return apperror.Wrap(err, "Error when getting order")
+	}
+
+	if order.UserID != principal.UserID {
+		return apperror.ErrForbidden
+	}
+
+	return nil
+}
+
+// invalidStateError names the statuses of the rejected transition.
+func invalidStateError(err *model.IllegalTransitionError) error {
+	return apperror.ErrInvalidState.WithCause(err).WithDetails(apperror.ErrorFields{
+		"status": fmt.Sprintf("can't move from %s to %s", err.From, err.To),
+	})
+}
+
+// invalidCancelError lists every cancellation line that doesn't match the order.
+func invalidCancelError(err *model.InvalidCancelError) error {
+	fields := make(apperror.ErrorFields, len(err.Lines))
+	for _, l := range err.Lines {
+		fields[l.ProductID] = l.Reason
+	}
+
+	return apperror.ErrValidation.WithCause(err).WithDetails(fields)
+}
+
+// outOfStockError lists every short product with the quantity still available.
+func outOfStockError(err *model.OutOfStockError) error {
+	fields := make(apperror.ErrorFields, len(err.Shortages))
+	for _, s := range err.Shortages {
+		fields[s.ProductID] = fmt.Sprintf("requested %d, available %d", s.Requested, s.Available)
+	}
+
+	return apperror.ErrOutOfStock.WithCause(err).WithDetails(fields)
+}
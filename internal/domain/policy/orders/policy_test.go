@@ -0,0 +1,421 @@
+package orders
+
+import (
+	"context"
+	"github.com/Amore14rn/888Starz_test/internal/apperror"
+	"github.com/Amore14rn/888Starz_test/internal/domain/orders/model"
+	"github.com/Amore14rn/888Starz_test/internal/domain/orders/service"
+	"github.com/Amore14rn/888Starz_test/pkg/common/auth"
+	"github.com/Amore14rn/888Starz_test/pkg/common/core/pagination"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockRepository struct {
+	mock.Mock
+}
+
+func (m *MockRepository) Create(ctx context.Context, req model.CreateOrder) (model.Order, error) {
+	args := m.Called(ctx, req)
+	return args.Get(0).(model.Order), args.Error(1)
+}
+
+func (m *MockRepository) GetOrder(ctx context.Context, id string) (model.Order, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(model.Order), args.Error(1)
+}
+
+func (m *MockRepository) Transition(ctx context.Context, transition model.StatusTransition) (model.StatusChange, error) {
+	args := m.Called(ctx, transition)
+	return args.Get(0).(model.StatusChange), args.Error(1)
+}
+
+func (m *MockRepository) Cancel(ctx context.Context, cancellation model.Cancellation) (model.Order, error) {
+	args := m.Called(ctx, cancellation)
+	return args.Get(0).(model.Order), args.Error(1)
+}
+
+func (m *MockRepository) Details(ctx context.Context, id string) (model.OrderDetails, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(model.OrderDetails), args.Error(1)
+}
+
+func (m *MockRepository) UserOrders(ctx context.Context, filter model.OrderFilter) ([]model.OrderDetails, string, error) {
+	args := m.Called(ctx, filter)
+	return args.Get(0).([]model.OrderDetails), args.String(1), args.Error(2)
+}
+
+type MockIdentityGenerator struct {
+}
+
+func (m MockIdentityGenerator) GenerateUUIDv4String() string {
+	return "some_mocked_uuid"
+}
+
+type MockClock struct {
+	now time.Time
+}
+
+func (m MockClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+func (m MockClock) Since(t time.Time) time.Duration { return m.now.Sub(t) }
+
+func (m MockClock) Until(t time.Time) time.Duration { return t.Sub(m.now) }
+
+func (m MockClock) Sleep(d time.Duration) {}
+
+func (m MockClock) Tick(d time.Duration) <-chan time.Time { return time.Tick(d) }
+
+func (m MockClock) Now() time.Time { return m.now }
+
+type MockMetrics struct {
+	created   int
+	revenue   float64
+	stockOuts int
+}
+
+func (m *MockMetrics) OrderCreated(total float64) {
+	m.created++
+	m.revenue += total
+}
+
+func (m *MockMetrics) StockOutRejected() {
+	m.stockOuts++
+}
+
+func callerContext(userID string) context.Context {
+	return auth.ContextWithPrincipal(context.Background(), auth.Principal{UserID: userID})
+}
+
+func managerContext(userID string) context.Context {
+	return auth.ContextWithPrincipal(context.Background(), auth.Principal{UserID: userID, Role: auth.RoleManager})
+}
+
+func TestCreateOrder(t *testing.T) {
+	now := time.Date(2023, 9, 10, 12, 0, 0, 0, time.UTC)
+	products := []model.OrderProduct{
+		{ProductID: "p1", Quantity: 2},
+		{ProductID: "p2", Quantity: 1},
+	}
+
+	mockRepo := new(MockRepository)
+	createOrder := model.NewCreateOrder("some_mocked_uuid", "u1", products, now)
+	created := createOrder.ToOrder()
+	created.Products = []model.OrderProduct{
+		{ProductID: "p1", Quantity: 2, Price: 10.5},
+		{ProductID: "p2", Quantity: 1, Price: 3.25},
+	}
+	mockRepo.On("Create", mock.Anything, createOrder).Return(created, nil)
+
+	metrics := &MockMetrics{}
+	policy := NewOrderPolicy(service.NewOrderService(mockRepo), MockIdentityGenerator{}, MockClock{now: now}, metrics)
+
+	output, err := policy.CreateOrder(callerContext("u1"), NewCreateOrderInput("", products))
+
+	assert.NoError(t, err)
+	assert.Equal(t, "some_mocked_uuid", output.Order.ID)
+	assert.Equal(t, "u1", output.Order.UserID)
+	assert.Equal(t, created.Products, output.Order.Products)
+	assert.Equal(t, 1, metrics.created)
+	assert.Equal(t, 24.25, metrics.revenue)
+	assert.Zero(t, metrics.stockOuts)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCreateOrderOutOfStock(t *testing.T) {
+	products := []model.OrderProduct{
+		{ProductID: "p1", Quantity: 3},
+		{ProductID: "p2", Quantity: 1},
+		{ProductID: "p3", Quantity: 1},
+	}
+	stockErr := model.CheckStock(map[string]int{"p1": 2, "p2": 5}, products)
+
+	mockRepo := new(MockRepository)
+	mockRepo.On("Create", mock.Anything, mock.Anything).Return(model.Order{}, stockErr)
+
+	metrics := &MockMetrics{}
+	policy := NewOrderPolicy(service.NewOrderService(mockRepo), MockIdentityGenerator{}, MockClock{}, metrics)
+
+	_, err := policy.CreateOrder(callerContext("u1"), NewCreateOrderInput("", products))
+
+	assert.ErrorIs(t, err, apperror.ErrOutOfStock)
+	assert.Equal(t, 1, metrics.stockOuts)
+	assert.Zero(t, metrics.created)
+	var appErr *apperror.AppError
+	if assert.ErrorAs(t, err, &appErr) {
+		assert.Equal(t, apperror.ErrorFields{
+			"p1": "requested 3, available 2",
+			"p3": "requested 1, available 0",
+		}, appErr.Fields)
+	}
+
+	var outOfStock *model.OutOfStockError
+	if assert.ErrorAs(t, err, &outOfStock) {
+		assert.Equal(t, []model.StockShortage{
+			{ProductID: "p1", Requested: 3, Available: 2},
+			{ProductID: "p3", Requested: 1, Available: 0},
+		}, outOfStock.Shortages)
+	}
+}
+
+func TestCreateOrderMergesDuplicateLines(t *testing.T) {
+	products := []model.OrderProduct{
+		{ProductID: "p1", Quantity: 2},
+		{ProductID: "p2", Quantity: 1},
+		{ProductID: "p1", Quantity: 3},
+	}
+
+	mockRepo := new(MockRepository)
+	mockRepo.On("Create", mock.Anything, mock.MatchedBy(func(req model.CreateOrder) bool {
+		return assert.ObjectsAreEqual([]model.OrderProduct{
+			{ProductID: "p1", Quantity: 5},
+			{ProductID: "p2", Quantity: 1},
+		}, req.Products)
+	})).Return(model.Order{}, nil)
+
+	policy := NewOrderPolicy(service.NewOrderService(mockRepo), MockIdentityGenerator{}, MockClock{}, &MockMetrics{})
+
+	_, err := policy.CreateOrder(callerContext("u1"), NewCreateOrderInput("", products))
+
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCheckStockSumsDuplicateLines(t *testing.T) {
+	err := model.CheckStock(map[string]int{"p1": 4}, []model.OrderProduct{
+		{ProductID: "p1", Quantity: 3},
+		{ProductID: "p1", Quantity: 2},
+	})
+
+	var outOfStock *model.OutOfStockError
+	if assert.ErrorAs(t, err, &outOfStock) {
+		assert.Equal(t, []model.StockShortage{{ProductID: "p1", Requested: 5, Available: 4}}, outOfStock.Shortages)
+	}
+}
+
+func TestCreateOrderForAnotherUser(t *testing.T) {
+	mockRepo := new(MockRepository)
+	policy := NewOrderPolicy(service.NewOrderService(mockRepo), MockIdentityGenerator{}, MockClock{}, &MockMetrics{})
+	products := []model.OrderProduct{{ProductID: "p1", Quantity: 1}}
+
+	_, err := policy.CreateOrder(context.Background(), NewCreateOrderInput("u1", products))
+	assert.ErrorIs(t, err, apperror.ErrUnauthorized)
+
+	_, err = policy.CreateOrder(callerContext("u2"), NewCreateOrderInput("u1", products))
+	assert.ErrorIs(t, err, apperror.ErrForbidden)
+
+	mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+}
+
+func TestStatusTransitions(t *testing.T) {
+	tests := []struct {
+		from, to model.Status
+		allowed  bool
+	}{
+		{model.StatusPending, model.StatusPaid, true},
+		{model.StatusPending, model.StatusCancelled, true},
+		{model.StatusPending, model.StatusShipped, false},
+		{model.StatusPaid, model.StatusShipped, true},
+		{model.StatusPaid, model.StatusCancelled, true},
+		{model.StatusShipped, model.StatusDelivered, true},
+		{model.StatusShipped, model.StatusCancelled, false},
+		{model.StatusDelivered, model.StatusPending, false},
+		{model.StatusCancelled, model.StatusPaid, false},
+		{model.StatusPaid, model.StatusPaid, false},
+	}
+
+	for _, tt := range tests {
+		err := model.CheckTransition(tt.from, tt.to)
+		if tt.allowed {
+			assert.NoError(t, err, "%s -> %s", tt.from, tt.to)
+			continue
+		}
+
+		var illegal *model.IllegalTransitionError
+		if assert.ErrorAs(t, err, &illegal, "%s -> %s", tt.from, tt.to) {
+			assert.Equal(t, tt.from, illegal.From)
+			assert.Equal(t, tt.to, illegal.To)
+		}
+	}
+}
+
+func TestTransitionOrder(t *testing.T) {
+	now := time.Date(2023, 9, 10, 12, 0, 0, 0, time.UTC)
+	transition := model.NewStatusTransition("o1", model.StatusShipped, "m1", "handed to courier", now)
+	change := model.StatusChange{OrderID: "o1", From: model.StatusPaid, To: model.StatusShipped, ChangedBy: "m1", Reason: "handed to courier", ChangedAt: now}
+
+	mockRepo := new(MockRepository)
+	mockRepo.On("Transition", mock.Anything, transition).Return(change, nil)
+
+	policy := NewOrderPolicy(service.NewOrderService(mockRepo), MockIdentityGenerator{}, MockClock{now: now}, &MockMetrics{})
+
+	output, err := policy.TransitionOrder(managerContext("m1"), NewTransitionOrderInput("o1", model.StatusShipped, "handed to courier"))
+
+	assert.NoError(t, err)
+	assert.Equal(t, change, output.Change)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestTransitionOrderIllegal(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockRepo.On("Transition", mock.Anything, mock.Anything).
+		Return(model.StatusChange{}, model.CheckTransition(model.StatusDelivered, model.StatusPaid))
+
+	policy := NewOrderPolicy(service.NewOrderService(mockRepo), MockIdentityGenerator{}, MockClock{}, &MockMetrics{})
+
+	_, err := policy.TransitionOrder(managerContext("m1"), NewTransitionOrderInput("o1", model.StatusPaid, ""))
+
+	assert.ErrorIs(t, err, apperror.ErrInvalidState)
+	var illegal *model.IllegalTransitionError
+	assert.ErrorAs(t, err, &illegal)
+}
+
+func TestCustomerMayOnlyCancelOwnOrder(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockRepo.On("GetOrder", mock.Anything, "o1").Return(model.Order{ID: "o1", UserID: "u1"}, nil)
+	mockRepo.On("Transition", mock.Anything, mock.Anything).Return(model.StatusChange{To: model.StatusCancelled}, nil)
+
+	policy := NewOrderPolicy(service.NewOrderService(mockRepo), MockIdentityGenerator{}, MockClock{}, &MockMetrics{})
+
+	_, err := policy.TransitionOrder(callerContext("u2"), NewTransitionOrderInput("o1", model.StatusCancelled, ""))
+	assert.ErrorIs(t, err, apperror.ErrForbidden)
+
+	_, err = policy.TransitionOrder(callerContext("u1"), NewTransitionOrderInput("o1", model.StatusPaid, ""))
+	assert.ErrorIs(t, err, apperror.ErrForbidden)
+	mockRepo.AssertNotCalled(t, "Transition", mock.Anything, mock.Anything)
+
+	_, err = policy.TransitionOrder(callerContext("u1"), NewTransitionOrderInput("o1", model.StatusCancelled, "changed my mind"))
+	assert.NoError(t, err)
+}
+
+func TestPlanCancellation(t *testing.T) {
+	lines := []model.OrderProduct{
+		{ProductID: "p1", Quantity: 3, CancelledQuantity: 1},
+		{ProductID: "p2", Quantity: 2},
+	}
+
+	restock, full, err := model.PlanCancellation(lines, []model.CancelLine{{ProductID: "p1", Quantity: 2}})
+	assert.NoError(t, err)
+	assert.False(t, full)
+	assert.Equal(t, []model.Restock{{ProductID: "p1", Quantity: 1}}, restock)
+
+	// Retrying the same partial cancellation restocks nothing
+	restock, _, err = model.PlanCancellation(lines, []model.CancelLine{{ProductID: "p1", Quantity: 1}})
+	assert.NoError(t, err)
+	assert.Empty(t, restock)
+
+	restock, full, err = model.PlanCancellation(lines, nil)
+	assert.NoError(t, err)
+	assert.True(t, full)
+	assert.Equal(t, []model.Restock{{ProductID: "p1", Quantity: 2}, {ProductID: "p2", Quantity: 2}}, restock)
+
+	_, _, err = model.PlanCancellation(lines, []model.CancelLine{{ProductID: "p2", Quantity: 5}, {ProductID: "p9", Quantity: 1}})
+	var invalid *model.InvalidCancelError
+	if assert.ErrorAs(t, err, &invalid) {
+		assert.Equal(t, []model.InvalidCancelLine{
+			{ProductID: "p2", Reason: "more than ordered"},
+			{ProductID: "p9", Reason: "not in the order"},
+		}, invalid.Lines)
+	}
+}
+
+func TestCancelOrder(t *testing.T) {
+	now := time.Date(2023, 9, 10, 12, 0, 0, 0, time.UTC)
+	lines := []model.CancelLine{{ProductID: "p1", Quantity: 1}}
+	cancelled := model.Order{ID: "o1", UserID: "u1", Status: model.StatusPending}
+
+	mockRepo := new(MockRepository)
+	mockRepo.On("GetOrder", mock.Anything, "o1").Return(model.Order{ID: "o1", UserID: "u1"}, nil)
+	mockRepo.On("Cancel", mock.Anything, model.NewCancellation("o1", lines, "u1", "too many", now)).Return(cancelled, nil)
+
+	policy := NewOrderPolicy(service.NewOrderService(mockRepo), MockIdentityGenerator{}, MockClock{now: now}, &MockMetrics{})
+
+	output, err := policy.CancelOrder(callerContext("u1"), NewCancelOrderInput("o1", lines, "too many"))
+
+	assert.NoError(t, err)
+	assert.Equal(t, cancelled, output.Order)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCancelOrderErrors(t *testing.T) {
+	tests := map[string]struct {
+		err  error
+		want *apperror.AppError
+	}{
+		"shipped order": {err: model.CheckTransition(model.StatusShipped, model.StatusCancelled), want: apperror.ErrInvalidState},
+		"unknown line":  {err: &model.InvalidCancelError{Lines: []model.InvalidCancelLine{{ProductID: "p9", Reason: "not in the order"}}}, want: apperror.ErrValidation},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			mockRepo := new(MockRepository)
+			mockRepo.On("Cancel", mock.Anything, mock.Anything).Return(model.Order{}, tt.err)
+
+			policy := NewOrderPolicy(service.NewOrderService(mockRepo), MockIdentityGenerator{}, MockClock{}, &MockMetrics{})
+
+			_, err := policy.CancelOrder(managerContext("m1"), NewCancelOrderInput("o1", nil, ""))
+			assert.ErrorIs(t, err, tt.want)
+		})
+	}
+}
+
+func TestOrderDetailsTotals(t *testing.T) {
+	order := model.Order{ID: "o1", UserID: "u1", Status: model.StatusPaid}
+	lines := []model.OrderLineDetails{
+		model.NewOrderLineDetails(model.OrderProduct{ProductID: "p1", Quantity: 3, CancelledQuantity: 1, Price: 0.1}, 0.2),
+		model.NewOrderLineDetails(model.OrderProduct{ProductID: "p2", Quantity: 1, Price: 9.99}, 8.5),
+	}
+
+	details := model.NewOrderDetails(order, lines)
+
+	assert.Equal(t, 0.2, details.Lines[0].Total)
+	assert.Equal(t, 0.2, details.Lines[0].CurrentPrice)
+	assert.Equal(t, 9.99, details.Lines[1].Total)
+	assert.Equal(t, 10.19, details.Total)
+}
+
+func TestGetOrderAccess(t *testing.T) {
+	details := model.OrderDetails{ID: "o1", UserID: "u1"}
+
+	mockRepo := new(MockRepository)
+	mockRepo.On("Details", mock.Anything, "o1").Return(details, nil)
+
+	policy := NewOrderPolicy(service.NewOrderService(mockRepo), MockIdentityGenerator{}, MockClock{}, &MockMetrics{})
+
+	output, err := policy.GetOrder(callerContext("u1"), NewGetOrderInput("o1"))
+	assert.NoError(t, err)
+	assert.Equal(t, details, output.Order)
+
+	_, err = policy.GetOrder(managerContext("m1"), NewGetOrderInput("o1"))
+	assert.NoError(t, err)
+
+	_, err = policy.GetOrder(callerContext("u2"), NewGetOrderInput("o1"))
+	assert.ErrorIs(t, err, apperror.ErrForbidden)
+}
+
+func TestUserOrders(t *testing.T) {
+	from := time.Date(2023, 9, 1, 0, 0, 0, 0, time.UTC)
+	statuses := []model.Status{model.StatusPaid}
+
+	mockRepo := new(MockRepository)
+	mockRepo.On("UserOrders", mock.Anything, model.OrderFilter{
+		UserID:   "u1",
+		Statuses: statuses,
+		From:     &from,
+		Page:     pagination.Params{Limit: 5, Sort: pagination.SortCreatedDesc},
+	}).Return([]model.OrderDetails{{ID: "o1"}}, "next", nil)
+
+	policy := NewOrderPolicy(service.NewOrderService(mockRepo), MockIdentityGenerator{}, MockClock{}, &MockMetrics{})
+
+	output, err := policy.UserOrders(callerContext("u1"), UserOrdersInput{UserID: "u1", Statuses: statuses, From: &from, Limit: 5})
+	assert.NoError(t, err)
+	assert.Len(t, output.Orders, 1)
+	assert.Equal(t, "next", output.NextCursor)
+	mockRepo.AssertExpectations(t)
+
+	_, err = policy.UserOrders(callerContext("u2"), UserOrdersInput{UserID: "u1"})
+	assert.ErrorIs(t, err, apperror.ErrForbidden)
+}
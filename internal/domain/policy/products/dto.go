@@ -9,17 +9,17 @@ type CreateProductInput struct {
 	ID          string
 	Description string
 	Quantity    int
+	Price       float64
 	Tags        []string
-	CreatedAt   time.Time
 }
 
-func NewCreateProductInput(id, description string, quantity int, tags []string, createdAt time.Time) CreateProductInput {
+func NewCreateProductInput(id, description string, quantity int, price float64, tags []string) CreateProductInput {
 	return CreateProductInput{
 		ID:          id,
 		Description: description,
 		Quantity:    quantity,
+		Price:       price,
 		Tags:        tags,
-		CreatedAt:   createdAt,
 	}
 }
 
@@ -27,21 +27,23 @@ type CreateProductOutput struct {
 	Product model.Products
 }
 
+// UpdateProductInput is a partial update, nil fields are left as they are.
 type UpdateProductInput struct {
 	ID          string
-	Description string
-	Quantity    int
-	Tags        []string
-	UpdatedAt   time.Time
+	Description *string
+	Quantity    *int
+	Price       *float64
+	Tags        *[]string
+	Version     int64
 }
 
-func NewUpdateProductInput(id, description string, quantity int, tags []string, updatedAt time.Time) UpdateProductInput {
+func NewUpdateProductInput(id string, description *string, quantity *int, price *float64, tags *[]string) UpdateProductInput {
 	return UpdateProductInput{
 		ID:          id,
 		Description: description,
 		Quantity:    quantity,
+		Price:       price,
 		Tags:        tags,
-		UpdatedAt:   updatedAt,
 	}
 }
 
@@ -50,7 +52,8 @@ type UpdateProductOutput struct {
 }
 
 type GetProductInput struct {
-	ID string
+	ID             string
+	IncludeDeleted bool // Только для администраторов
 }
 
 func NewGetProductInput(id string) GetProductInput {
@@ -76,3 +79,77 @@ func NewDeleteProductInput(id string) DeleteProductInput {
 type DeleteProductOutput struct {
 	Product model.Products
 }
+
+type RestoreProductInput struct {
+	ID string
+}
+
+func NewRestoreProductInput(id string) RestoreProductInput {
+	return RestoreProductInput{
+		ID: id,
+	}
+}
+
+type RestoreProductOutput struct {
+	Product model.Products
+}
+
+type GetPriceHistoryInput struct {
+	ID string
+}
+
+func NewGetPriceHistoryInput(id string) GetPriceHistoryInput {
+	return GetPriceHistoryInput{
+		ID: id,
+	}
+}
+
+type GetPriceHistoryOutput struct {
+	History []model.ProductHistory
+}
+
+type AllProductsInput struct {
+	Tags           []string
+	TagMatch       string
+	MinQuantity    *int
+	MaxQuantity    *int
+	CreatedAfter   *time.Time
+	Limit          int
+	Cursor         string
+	Sort           string
+	IncludeDeleted bool // Только для администраторов
+}
+
+func NewAllProductsInput(tags []string, tagMatch string) AllProductsInput {
+	return AllProductsInput{
+		Tags:     tags,
+		TagMatch: tagMatch,
+	}
+}
+
+type AllProductsOutput struct {
+	Products   []model.Products
+	NextCursor string
+}
+
+type TagsOutput struct {
+	Tags []model.TagCount
+}
+
+type SearchProductsInput struct {
+	Query  string
+	Limit  int
+	Offset int
+}
+
+func NewSearchProductsInput(query string, limit, offset int) SearchProductsInput {
+	return SearchProductsInput{
+		Query:  query,
+		Limit:  limit,
+		Offset: offset,
+	}
+}
+
+type SearchProductsOutput struct {
+	Results []model.ProductSearchResult
+}
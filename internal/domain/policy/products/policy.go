@@ -2,10 +2,13 @@ package products
 
 import (
 	"context"
+	"github.com/Amore14rn/888Starz_test/internal/apperror"
+	"github.com/Amore14rn/888Starz_test/internal/domain/policy/access"
 	"github.com/Amore14rn/888Starz_test/internal/domain/products/model"
 	"github.com/Amore14rn/888Starz_test/internal/domain/products/service"
+	"github.com/Amore14rn/888Starz_test/pkg/common/auth"
 	"github.com/Amore14rn/888Starz_test/pkg/common/core/clock"
-	"github.com/Amore14rn/888Starz_test/pkg/errors"
+	"github.com/Amore14rn/888Starz_test/pkg/common/core/pagination"
 	"time"
 )
 
@@ -33,28 +36,30 @@ func NewProductPolicy(productService *service.ProductService, identity IdentityG
 }
 
 func (p *Policy) CreateProduct(ctx context.Context, input CreateProductInput) (CreateProductOutput, error) {
-
-	if input.Description == "" {
-		return CreateProductOutput{}, errors.New("Описание продукта обязательно")
+	// Только менеджеры управляют остатками и ценами
+	if _, err := access.RequireRole(ctx, auth.RoleManager); err != nil {
+		return CreateProductOutput{}, err
 	}
 
-	// Проверка количества
-	if input.Quantity <= 0 {
-		return CreateProductOutput{}, errors.New("Количество продукта должно быть положительным числом")
+	// Идентификатор генерируется, если клиент не передал свой
+	id := input.ID
+	if id == "" {
+		id = p.identity.GenerateUUIDv4String()
 	}
 
 	// Создание продукта
 	createProduct := model.NewCreateProducts(
-		input.ID,
+		id,
 		input.Description,
 		input.Quantity,
+		input.Price,
 		input.Tags,
-		input.CreatedAt,
+		p.clock.Now(),
 	)
 
 	product, err := p.productService.CreateProduct(ctx, createProduct)
 	if err != nil {
-		return CreateProductOutput{}, errors.Wrap(err, "Error when creating a product")
+		return CreateProductOutput{}, apperror.Wrap(err, "Error when creating a product")
 	}
 
 	return CreateProductOutput{
@@ -62,19 +67,41 @@ func (p *Policy) CreateProduct(ctx context.Context, input CreateProductInput) (C
 	}, nil
 }
 
-func (p *Policy) All(ctx context.Context) ([]model.Products, error) {
-	products, err := p.productService.All(ctx)
+func (p *Policy) All(ctx context.Context, input AllProductsInput) (AllProductsOutput, error) {
+	ctx, err := access.IncludeDeleted(ctx, input.IncludeDeleted)
+	if err != nil {
+		return AllProductsOutput{}, err
+	}
+
+	filter := model.NewProductFilter(
+		input.Tags,
+		model.TagMatch(input.TagMatch),
+		input.MinQuantity,
+		input.MaxQuantity,
+		input.CreatedAfter,
+		pagination.NewParams(input.Limit, input.Cursor, pagination.Sort(input.Sort)),
+	)
+
+	products, next, err := p.productService.All(ctx, filter)
 	if err != nil {
-		return nil, errors.Wrap(err, "Error when getting all products")
+		return AllProductsOutput{}, apperror.Wrap(err, "Error when getting all products")
 	}
 
-	return products, nil
+	return AllProductsOutput{
+		Products:   products,
+		NextCursor: next,
+	}, nil
 }
 
 func (p *Policy) GetProduct(ctx context.Context, input GetProductInput) (GetProductOutput, error) {
+	ctx, err := access.IncludeDeleted(ctx, input.IncludeDeleted)
+	if err != nil {
+		return GetProductOutput{}, err
+	}
+
 	product, err := p.productService.GetProduct(ctx, input.ID)
 	if err != nil {
-		return GetProductOutput{}, errors.Wrap(err, "Error when getting product")
+		return GetProductOutput{}, apperror.Wrap(err, "Error when getting product")
 	}
 
 	return GetProductOutput{
@@ -82,45 +109,139 @@ func (p *Policy) GetProduct(ctx context.Context, input GetProductInput) (GetProd
 	}, nil
 }
 
+// UpdateProduct merges input into the product if it still has input.Version
+// and returns the product with the new version. The invariants are checked
+// on the merged product, not on the patch alone.
 func (p *Policy) UpdateProduct(ctx context.Context, input UpdateProductInput) (UpdateProductOutput, error) {
+	if _, err := access.RequireRole(ctx, auth.RoleManager); err != nil {
+		return UpdateProductOutput{}, err
+	}
+
 	// Проверка на существование продукта
-	_, err := p.productService.GetProduct(ctx, input.ID)
+	current, err := p.productService.GetProduct(ctx, input.ID)
 	if err != nil {
-		return UpdateProductOutput{}, errors.Wrap(err, "Error when getting product")
+		return UpdateProductOutput{}, apperror.Wrap(err, "Error when getting product")
 	}
 
-	// Проверка на количество
-	if input.Quantity <= 0 {
-		return UpdateProductOutput{}, errors.New("Количество продукта должно быть положительным числом")
+	// Патч, составленный по старой версии, нельзя проверять на новой
+	if current.Version != input.Version {
+		return UpdateProductOutput{}, apperror.ErrPrecondition
 	}
 
 	updateProduct := model.NewUpdateProducts(
 		input.ID,
 		input.Description,
 		input.Quantity,
+		input.Price,
 		input.Tags,
-		input.UpdatedAt,
+		p.clock.Now(),
+		input.Version,
 	)
 
+	if fields := updateProduct.Apply(current).Validate(); fields != nil {
+		return UpdateProductOutput{}, apperror.ErrValidation.WithDetails(fields)
+	}
+
 	err = p.productService.UpdateProduct(ctx, updateProduct)
 	if err != nil {
-		return UpdateProductOutput{}, errors.Wrap(err, "Error when updating product")
+		return UpdateProductOutput{}, apperror.Wrap(err, "Error when updating product")
+	}
+
+	product, err := p.productService.GetProduct(ctx, input.ID)
+	if err != nil {
+		return UpdateProductOutput{}, apperror.Wrap(err, "Error when getting product")
 	}
 
-	return UpdateProductOutput{}, nil
+	return UpdateProductOutput{
+		Product: product,
+	}, nil
 }
 
 func (p *Policy) DeleteProduct(ctx context.Context, input DeleteProductInput) (DeleteProductOutput, error) {
+	if _, err := access.RequireRole(ctx, auth.RoleManager); err != nil {
+		return DeleteProductOutput{}, err
+	}
+
 	// Проверка на существование продукта
 	_, err := p.productService.GetProduct(ctx, input.ID)
 	if err != nil {
-		return DeleteProductOutput{}, errors.Wrap(err, "Error when getting product")
+		return DeleteProductOutput{}, apperror.Wrap(err, "Error when getting product")
 	}
 
-	err = p.productService.DeleteProduct(ctx, input.ID)
+	err = p.productService.DeleteProduct(ctx, input.ID, p.clock.Now())
 	if err != nil {
-		return DeleteProductOutput{}, errors.Wrap(err, "Error when deleting product")
+		return DeleteProductOutput{}, apperror.Wrap(err, "Error when deleting product")
 	}
 
 	return DeleteProductOutput{}, nil
 }
+
+// RestoreProduct undoes DeleteProduct while the product hasn't been purged
+// yet and returns the restored product.
+func (p *Policy) RestoreProduct(ctx context.Context, input RestoreProductInput) (RestoreProductOutput, error) {
+	if _, err := access.RequireRole(ctx, auth.RoleManager); err != nil {
+		return RestoreProductOutput{}, err
+	}
+
+	err := p.productService.RestoreProduct(ctx, input.ID, p.clock.Now())
+	if err != nil {
+		return RestoreProductOutput{}, apperror.Wrap(err, "Error when restoring product")
+	}
+
+	product, err := p.productService.GetProduct(ctx, input.ID)
+	if err != nil {
+		return RestoreProductOutput{}, apperror.Wrap(err, "Error when getting product")
+	}
+
+	return RestoreProductOutput{
+		Product: product,
+	}, nil
+}
+
+func (p *Policy) PriceHistory(ctx context.Context, input GetPriceHistoryInput) (GetPriceHistoryOutput, error) {
+	// Проверка на существование продукта
+	_, err := p.productService.GetProduct(ctx, input.ID)
+	if err != nil {
+		return GetPriceHistoryOutput{}, apperror.Wrap(err, "Error when getting product")
+	}
+
+	history, err := p.productService.PriceHistory(ctx, input.ID)
+	if err != nil {
+		return GetPriceHistoryOutput{}, apperror.Wrap(err, "Error when getting price history")
+	}
+
+	return GetPriceHistoryOutput{
+		History: history,
+	}, nil
+}
+
+func (p *Policy) Tags(ctx context.Context) (TagsOutput, error) {
+	tags, err := p.productService.Tags(ctx)
+	if err != nil {
+		return TagsOutput{}, apperror.Wrap(err, "Error when getting product tags")
+	}
+
+	return TagsOutput{
+		Tags: tags,
+	}, nil
+}
+
+func (p *Policy) Search(ctx context.Context, input SearchProductsInput) (SearchProductsOutput, error) {
+	search := model.NewProductSearch(input.Query, input.Limit, input.Offset)
+
+	// Запрос из одних знаков препинания ничего не найдёт
+	if len(search.Terms) == 0 {
+		return SearchProductsOutput{}, apperror.ErrValidation.WithDetails(apperror.ErrorFields{
+			"q": "must contain at least one letter or digit",
+		})
+	}
+
+	results, err := p.productService.Search(ctx, search)
+	if err != nil {
+		return SearchProductsOutput{}, apperror.Wrap(err, "Error when searching products")
+	}
+
+	return SearchProductsOutput{
+		Results: results,
+	}, nil
+}
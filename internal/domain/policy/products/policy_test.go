@@ -2,22 +2,29 @@ package products
 
 import (
 	"context"
+	"github.com/Amore14rn/888Starz_test/internal/apperror"
+	"github.com/Amore14rn/888Starz_test/internal/dal"
+	"github.com/Amore14rn/888Starz_test/internal/domain/policy/access"
 	"github.com/Amore14rn/888Starz_test/internal/domain/products/model"
 	"github.com/Amore14rn/888Starz_test/internal/domain/products/service"
+	"github.com/Amore14rn/888Starz_test/pkg/common/auth"
+	"github.com/Amore14rn/888Starz_test/pkg/common/core/pagination"
+	"github.com/Amore14rn/888Starz_test/pkg/utils/pointer"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 )
 
 type MockRepository struct {
 	mock.Mock
 }
 
-func (m *MockRepository) All(ctx context.Context) ([]model.Products, error) {
-	args := m.Called(ctx)
-	return args.Get(0).([]model.Products), args.Error(1)
+func (m *MockRepository) All(ctx context.Context, filter model.ProductFilter) ([]model.Products, string, error) {
+	args := m.Called(ctx, filter)
+	return args.Get(0).([]model.Products), args.String(1), args.Error(2)
 }
 
 func (m *MockRepository) Create(ctx context.Context, req model.CreateProducts) (model.Products, error) {
@@ -35,11 +42,36 @@ func (m *MockRepository) Update(ctx context.Context, req model.UpdateProducts) e
 	return args.Error(0)
 }
 
-func (m *MockRepository) Delete(ctx context.Context, id string) error {
-	args := m.Called(ctx, id)
+func (m *MockRepository) Delete(ctx context.Context, id string, at time.Time) error {
+	args := m.Called(ctx, id, at)
 	return args.Error(0)
 }
 
+func (m *MockRepository) Restore(ctx context.Context, id string, at time.Time) error {
+	args := m.Called(ctx, id, at)
+	return args.Error(0)
+}
+
+func (m *MockRepository) PurgeDeleted(ctx context.Context, before, at time.Time) (int64, error) {
+	args := m.Called(ctx, before, at)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockRepository) Tags(ctx context.Context) ([]model.TagCount, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]model.TagCount), args.Error(1)
+}
+
+func (m *MockRepository) PriceHistory(ctx context.Context, id string) ([]model.ProductHistory, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).([]model.ProductHistory), args.Error(1)
+}
+
+func (m *MockRepository) Search(ctx context.Context, search model.ProductSearch) ([]model.ProductSearchResult, error) {
+	args := m.Called(ctx, search)
+	return args.Get(0).([]model.ProductSearchResult), args.Error(1)
+}
+
 type MockIdentityGenerator struct {
 }
 
@@ -79,6 +111,10 @@ func (m MockClock) Now() time.Time {
 	return time.Now()
 }
 
+func managerContext() context.Context {
+	return auth.ContextWithPrincipal(context.Background(), auth.Principal{UserID: "m1", Role: auth.RoleManager})
+}
+
 func TestCreateProduct(t *testing.T) {
 	mockRepo := new(MockRepository)
 	mockRepo.On("Create", mock.Anything, mock.Anything).Return(model.Products{}, nil)
@@ -89,33 +125,31 @@ func TestCreateProduct(t *testing.T) {
 		ID:          "mockedID",
 		Description: "Test Product",
 		Quantity:    5,
+		Price:       9.99,
 		Tags:        []string{"tag1", "tag2"},
-		CreatedAt:   time.Now(),
 	}
 
-	output, err := policy.CreateProduct(context.Background(), input)
+	output, err := policy.CreateProduct(managerContext(), input)
 
 	assert.NoError(t, err)
 	assert.NotNil(t, output.Product)
 }
 
 func NewProductService(repo *MockRepository) *service.ProductService {
-
-	return &service.ProductService{
-		Repository: repo,
-	}
+	return service.NewProductService(repo)
 }
 
 func TestAll(t *testing.T) {
 	mockRepo := new(MockRepository)
-	mockRepo.On("All", mock.Anything).Return([]model.Products{}, nil)
+	mockRepo.On("All", mock.Anything, mock.Anything).Return([]model.Products{}, "next", nil)
 
 	policy := NewProductPolicy(NewProductService(mockRepo), MockIdentityGenerator{}, MockClock{})
 
-	products, err := policy.All(context.Background())
+	output, err := policy.All(context.Background(), AllProductsInput{})
 
 	assert.NoError(t, err)
-	assert.NotNil(t, products)
+	assert.NotNil(t, output.Products)
+	assert.Equal(t, "next", output.NextCursor)
 }
 
 func TestGetProduct(t *testing.T) {
@@ -135,29 +169,88 @@ func TestGetProduct(t *testing.T) {
 }
 
 func TestUpdateProduct(t *testing.T) {
+	current := model.Products{ID: "mockedID", Description: "Test Product", Quantity: 3, Price: 9.99, Version: 4}
+
 	mockRepo := new(MockRepository)
-	mockRepo.On("GetProduct", mock.Anything, mock.Anything).Return(model.Products{}, nil)
-	mockRepo.On("Update", mock.Anything, mock.Anything).Return(nil)
+	mockRepo.On("GetProduct", mock.Anything, "mockedID").Return(current, nil).Once()
+	mockRepo.On("Update", mock.Anything, mock.MatchedBy(func(req model.UpdateProducts) bool {
+		// Only the supplied fields reach the DAO
+		return req.ID == "mockedID" && req.Version == 4 &&
+			req.Description == nil && req.Price == nil && req.Tags == nil &&
+			req.Quantity != nil && *req.Quantity == 0
+	})).Return(nil)
+	mockRepo.On("GetProduct", mock.Anything, "mockedID").Return(model.Products{ID: "mockedID", Version: 5}, nil).Once()
 
 	policy := NewProductPolicy(NewProductService(mockRepo), MockIdentityGenerator{}, MockClock{})
 
-	input := UpdateProductInput{
-		ID:          "mockedID",
-		Description: "Updated Test Product",
-		Quantity:    10,
-		Tags:        []string{"tag1", "tag2"},
-		UpdatedAt:   time.Now(),
-	}
+	input := NewUpdateProductInput("mockedID", nil, pointer.Pointer(0), nil, nil)
+	input.Version = 4
 
-	_, err := policy.UpdateProduct(context.Background(), input)
+	output, err := policy.UpdateProduct(managerContext(), input)
 
 	assert.NoError(t, err)
+	assert.Equal(t, int64(5), output.Product.Version)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestUpdateProductValidatesMergedProduct(t *testing.T) {
+	// A product stored before descriptions became mandatory
+	current := model.Products{ID: "mockedID", Quantity: 3, Price: 9.99, Version: 4}
+
+	mockRepo := new(MockRepository)
+	mockRepo.On("GetProduct", mock.Anything, "mockedID").Return(current, nil)
+
+	policy := NewProductPolicy(NewProductService(mockRepo), MockIdentityGenerator{}, MockClock{})
+
+	input := NewUpdateProductInput("mockedID", nil, nil, pointer.Pointer(-1.0), nil)
+	input.Version = 4
+
+	_, err := policy.UpdateProduct(managerContext(), input)
+
+	var appErr *apperror.AppError
+	require.ErrorAs(t, err, &appErr)
+	assert.Equal(t, apperror.ErrValidation.Code, appErr.Code)
+	assert.Equal(t, apperror.ErrorFields{
+		"description": "must not be empty",
+		"price":       "must be greater than or equal to 0",
+	}, appErr.Fields)
+	mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
+
+func TestUpdateProductVersionMismatch(t *testing.T) {
+	tests := map[string]error{
+		"stale read":        nil,
+		"concurrent update": dal.ErrVersionMismatch,
+	}
+
+	for name, updateErr := range tests {
+		t.Run(name, func(t *testing.T) {
+			version := int64(5)
+			if updateErr != nil {
+				// The product changes between the policy's read and the update
+				version = 4
+			}
+
+			mockRepo := new(MockRepository)
+			mockRepo.On("GetProduct", mock.Anything, "mockedID").Return(model.Products{ID: "mockedID", Description: "d", Version: version}, nil)
+			mockRepo.On("Update", mock.Anything, mock.Anything).Return(updateErr)
+
+			policy := NewProductPolicy(NewProductService(mockRepo), MockIdentityGenerator{}, MockClock{})
+
+			input := NewUpdateProductInput("mockedID", nil, pointer.Pointer(1), nil, nil)
+			input.Version = 4
+
+			_, err := policy.UpdateProduct(managerContext(), input)
+
+			assert.ErrorIs(t, err, apperror.ErrPrecondition)
+		})
+	}
 }
 
 func TestDeleteProduct(t *testing.T) {
 	mockRepo := new(MockRepository)
 	mockRepo.On("GetProduct", mock.Anything, mock.Anything).Return(model.Products{}, nil)
-	mockRepo.On("Delete", mock.Anything, mock.Anything).Return(nil)
+	mockRepo.On("Delete", mock.Anything, mock.Anything, mock.Anything).Return(nil)
 
 	policy := NewProductPolicy(NewProductService(mockRepo), MockIdentityGenerator{}, MockClock{})
 
@@ -165,7 +258,142 @@ func TestDeleteProduct(t *testing.T) {
 		ID: "mockedID",
 	}
 
-	_, err := policy.DeleteProduct(context.Background(), input)
+	_, err := policy.DeleteProduct(managerContext(), input)
+
+	assert.NoError(t, err)
+}
+
+func TestRestoreProduct(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockRepo.On("Restore", mock.Anything, "mockedID", mock.Anything).Return(nil)
+	mockRepo.On("GetProduct", mock.Anything, "mockedID").Return(model.Products{ID: "mockedID", Version: 3}, nil)
+
+	policy := NewProductPolicy(NewProductService(mockRepo), MockIdentityGenerator{}, MockClock{})
+
+	output, err := policy.RestoreProduct(managerContext(), NewRestoreProductInput("mockedID"))
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), output.Product.Version)
+}
+
+func TestRestoreProductNotFound(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockRepo.On("Restore", mock.Anything, "mockedID", mock.Anything).Return(dal.ErrNotFound)
+
+	policy := NewProductPolicy(NewProductService(mockRepo), MockIdentityGenerator{}, MockClock{})
+
+	_, err := policy.RestoreProduct(managerContext(), NewRestoreProductInput("mockedID"))
+
+	assert.ErrorIs(t, err, apperror.ErrNotFound)
+	mockRepo.AssertNotCalled(t, "GetProduct", mock.Anything, mock.Anything)
+}
+
+func TestIncludeDeletedRequiresAdmin(t *testing.T) {
+	isDeletedIncluded := mock.MatchedBy(dal.DeletedIncluded)
+
+	mockRepo := new(MockRepository)
+	mockRepo.On("GetProduct", isDeletedIncluded, "mockedID").Return(model.Products{ID: "mockedID"}, nil)
+	mockRepo.On("All", isDeletedIncluded, mock.Anything).Return([]model.Products{}, "", nil)
+
+	policy := NewProductPolicy(NewProductService(mockRepo), MockIdentityGenerator{}, MockClock{})
+	admin := auth.ContextWithPrincipal(context.Background(), auth.Principal{UserID: "a1", Role: auth.RoleAdmin})
+
+	_, err := policy.GetProduct(context.Background(), GetProductInput{ID: "mockedID", IncludeDeleted: true})
+	assert.ErrorIs(t, err, apperror.ErrUnauthorized)
+
+	_, err = policy.All(managerContext(), AllProductsInput{IncludeDeleted: true})
+	assert.ErrorIs(t, err, apperror.ErrForbidden)
+
+	_, err = policy.GetProduct(admin, GetProductInput{ID: "mockedID", IncludeDeleted: true})
+	assert.NoError(t, err)
+
+	_, err = policy.All(admin, AllProductsInput{IncludeDeleted: true})
+	assert.NoError(t, err)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestPriceHistory(t *testing.T) {
+	history := []model.ProductHistory{
+		model.NewProductHistory("mockedID", 12.5, time.Date(2023, 9, 12, 0, 0, 0, 0, time.UTC)),
+		model.NewProductHistory("mockedID", 9.99, time.Date(2023, 9, 10, 0, 0, 0, 0, time.UTC)),
+	}
+
+	mockRepo := new(MockRepository)
+	mockRepo.On("GetProduct", mock.Anything, "mockedID").Return(model.Products{ID: "mockedID"}, nil)
+	mockRepo.On("PriceHistory", mock.Anything, "mockedID").Return(history, nil)
+
+	policy := NewProductPolicy(NewProductService(mockRepo), MockIdentityGenerator{}, MockClock{})
+
+	output, err := policy.PriceHistory(context.Background(), NewGetPriceHistoryInput("mockedID"))
+
+	assert.NoError(t, err)
+	assert.Equal(t, history, output.History)
+}
+
+func TestAllTagFilter(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockRepo.On("All", mock.Anything, model.ProductFilter{
+		Tags:     []string{"a", "b"},
+		TagMatch: model.TagMatchAll,
+		Page:     pagination.Params{Limit: pagination.DefaultLimit, Sort: pagination.SortCreatedDesc},
+	}).Return([]model.Products{}, "", nil)
+
+	policy := NewProductPolicy(NewProductService(mockRepo), MockIdentityGenerator{}, MockClock{})
+
+	_, err := policy.All(context.Background(), NewAllProductsInput([]string{" a", "b", "a", ""}, "all"))
+	assert.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestProductManagementRequiresManager(t *testing.T) {
+	mockRepo := new(MockRepository)
+	policy := NewProductPolicy(NewProductService(mockRepo), MockIdentityGenerator{}, MockClock{})
+	customer := auth.ContextWithPrincipal(context.Background(), auth.Principal{UserID: "c1", Role: auth.RoleCustomer})
+
+	_, err := policy.CreateProduct(context.Background(), CreateProductInput{Description: "d", Quantity: 1})
+	assert.ErrorIs(t, err, apperror.ErrUnauthorized)
+
+	_, err = policy.UpdateProduct(customer, UpdateProductInput{ID: "mockedID", Quantity: pointer.Pointer(1)})
+	assert.ErrorIs(t, err, apperror.ErrForbidden)
+
+	_, err = policy.RestoreProduct(customer, RestoreProductInput{ID: "mockedID"})
+	assert.ErrorIs(t, err, apperror.ErrForbidden)
+
+	_, err = policy.DeleteProduct(customer, DeleteProductInput{ID: "mockedID"})
+	var appErr *apperror.AppError
+	if assert.ErrorAs(t, err, &appErr) {
+		assert.Equal(t, string(auth.RoleManager), appErr.Fields[access.FieldRequiredRole])
+	}
+	assert.Empty(t, apperror.ErrForbidden.Fields, "shared error must not be mutated")
+
+	mockRepo.AssertNotCalled(t, "GetProduct", mock.Anything, mock.Anything)
+}
+
+func TestSearch(t *testing.T) {
+	results := []model.ProductSearchResult{{Product: model.Products{ID: "p1"}, Rank: 0.5, Headline: "<b>red</b> shoes"}}
+
+	mockRepo := new(MockRepository)
+	mockRepo.On("Search", mock.Anything, model.ProductSearch{
+		Terms: []string{"red", "sho"},
+		Limit: pagination.DefaultLimit,
+	}).Return(results, nil)
+
+	policy := NewProductPolicy(NewProductService(mockRepo), MockIdentityGenerator{}, MockClock{})
+
+	output, err := policy.Search(context.Background(), NewSearchProductsInput(" Red & sho:*", 0, 0))
 
 	assert.NoError(t, err)
+	assert.Equal(t, results, output.Results)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestSearchWithoutTerms(t *testing.T) {
+	mockRepo := new(MockRepository)
+	policy := NewProductPolicy(NewProductService(mockRepo), MockIdentityGenerator{}, MockClock{})
+
+	_, err := policy.Search(context.Background(), NewSearchProductsInput("!& |", 0, 0))
+
+	assert.ErrorIs(t, err, apperror.ErrValidation)
+	mockRepo.AssertNotCalled(t, "Search", mock.Anything, mock.Anything)
 }
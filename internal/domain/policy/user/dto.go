@@ -14,10 +14,9 @@ type CreateUserInput struct {
 	IsMarried bool
 	Password  string
 	CreatedAt time.Time
-	Order     model.Order
 }
 
-func NewCreateUserInput(firstName string, lastName string, fullname string, age uint32, isMarried bool, password string, order model.Order) CreateUserInput {
+func NewCreateUserInput(firstName string, lastName string, fullname string, age uint32, isMarried bool, password string) CreateUserInput {
 	return CreateUserInput{
 		FirstName: firstName,
 		LastName:  lastName,
@@ -25,7 +24,6 @@ func NewCreateUserInput(firstName string, lastName string, fullname string, age
 		Age:       age,
 		IsMarried: isMarried,
 		Password:  password,
-		Order:     order,
 	}
 }
 
@@ -33,8 +31,25 @@ type CreateUserOutput struct {
 	User model.User
 }
 
+type AllUsersInput struct {
+	IsMarried      *bool
+	MinAge         *uint32
+	MaxAge         *uint32
+	CreatedAfter   *time.Time
+	Limit          int
+	Cursor         string
+	Sort           string
+	IncludeDeleted bool // Admins only
+}
+
+type AllUsersOutput struct {
+	Users      []model.User
+	NextCursor string
+}
+
 type GetUserInput struct {
-	ID string
+	ID             string
+	IncludeDeleted bool // Admins only
 }
 
 func NewGetUserInput(id string) GetUserInput {
@@ -61,38 +76,31 @@ type GetUserByNameOutput struct {
 	User []model.User
 }
 
+// UpdateUserInput is a partial update, nil fields are left as they are.
 type UpdateUserInput struct {
 	ID        string
-	FirstName string
-	LastName  string
-	FullName  string
-	Age       uint32
-	IsMarried bool
-	Password  string
-	Order     model.Order
-	UpdatedAt time.Time
+	FirstName *string
+	LastName  *string
+	Age       *uint32
+	IsMarried *bool
+	Password  *string
+	Version   int64
 }
 
 func NewUpdateUserInput(
 	id string,
-	firstName string,
-	lastName string,
-	fullname string,
-	age uint32,
-	isMarried bool,
-	password string,
-	order model.Order,
-	updatedAt time.Time) UpdateUserInput {
+	firstName *string,
+	lastName *string,
+	age *uint32,
+	isMarried *bool,
+	password *string) UpdateUserInput {
 	return UpdateUserInput{
 		ID:        id,
 		FirstName: firstName,
 		LastName:  lastName,
-		FullName:  fullname,
 		Age:       age,
 		IsMarried: isMarried,
 		Password:  password,
-		Order:     order,
-		UpdatedAt: updatedAt,
 	}
 }
 
@@ -113,24 +121,34 @@ func NewDeleteUserInput(id string) DeleteUserInput {
 type DeleteUserOutput struct {
 }
 
-type CreateOrderInput struct {
-	ID        string
-	UserID    string
-	ProductID string
-	Products  []model.OrderProduct
-	TimeStamp time.Time
+type AuthenticateInput struct {
+	ID       string
+	Password string
 }
 
-func NewCreateOrderInput(id string, userID string, productID string, products []model.OrderProduct, timestamp time.Time) CreateOrderInput {
-	return CreateOrderInput{
-		ID:        id,
-		UserID:    userID,
-		ProductID: productID,
-		Products:  products,
-		TimeStamp: timestamp,
+func NewAuthenticateInput(id, password string) AuthenticateInput {
+	return AuthenticateInput{
+		ID:       id,
+		Password: password,
 	}
 }
 
-type CreateOrderOutput struct {
-	Order model.Order
+type AuthenticateOutput struct {
+	User model.User
+}
+
+type UpdateRoleInput struct {
+	ID   string
+	Role string
+}
+
+func NewUpdateRoleInput(id, role string) UpdateRoleInput {
+	return UpdateRoleInput{
+		ID:   id,
+		Role: role,
+	}
+}
+
+type UpdateRoleOutput struct {
+	User model.User
 }
@@ -2,9 +2,15 @@ package user
 
 import (
 	"context"
+	"github.com/Amore14rn/888Starz_test/internal/apperror"
+	"github.com/Amore14rn/888Starz_test/internal/domain/policy/access"
 	"github.com/Amore14rn/888Starz_test/internal/domain/user/model"
 	"github.com/Amore14rn/888Starz_test/internal/domain/user/service"
+	"github.com/Amore14rn/888Starz_test/pkg/common/auth"
 	"github.com/Amore14rn/888Starz_test/pkg/common/core/clock"
+	"github.com/Amore14rn/888Starz_test/pkg/common/core/pagination"
+	"github.com/Amore14rn/888Starz_test/pkg/common/core/password"
+	"github.com/Amore14rn/888Starz_test/pkg/common/logging"
 	"github.com/Amore14rn/888Starz_test/pkg/errors"
 	"time"
 )
@@ -17,30 +23,35 @@ type Clock interface {
 	Now() time.Time
 }
 
+type PasswordHasher interface {
+	Hash(password string) (string, error)
+	Verify(hash, password string) error
+	NeedsRehash(hash string) bool
+}
+
+var ErrInvalidCredentials = errors.New("invalid credentials")
+
 type Policy struct {
 	userService *service.UserService
 
 	identity IdentityGenerator
 	clock    Clock
+	hasher   PasswordHasher
 }
 
-func NewUserPolicy(userService *service.UserService, identity IdentityGenerator, clock clock.Clock) *Policy {
+func NewUserPolicy(userService *service.UserService, identity IdentityGenerator, clock clock.Clock, hasher PasswordHasher) *Policy {
 	return &Policy{
 		userService: userService,
 		identity:    identity,
 		clock:       clock,
+		hasher:      hasher,
 	}
 }
 
 func (u *Policy) CreateUser(ctx context.Context, input CreateUserInput) (CreateUserOutput, error) {
-	// Check user's age
-	if input.Age < 18 {
-		return CreateUserOutput{}, errors.New("Пользователь должен быть не младше 18 лет")
-	}
-
-	// Validate password
-	if err := u.validatePassword(input.Password); err != nil {
-		return CreateUserOutput{}, err
+	passwordHash, err := u.hasher.Hash(input.Password)
+	if err != nil {
+		return CreateUserOutput{}, apperror.Wrap(err, "Error when hashing password")
 	}
 
 	createUser := model.NewCreateUser(
@@ -49,14 +60,13 @@ func (u *Policy) CreateUser(ctx context.Context, input CreateUserInput) (CreateU
 		input.LastName,
 		input.Age,
 		input.IsMarried,
-		input.Password,
-		input.Order,
+		passwordHash,
 		u.clock.Now(),
 	)
 
 	user, err := u.userService.CreateUser(ctx, createUser)
 	if err != nil {
-		return CreateUserOutput{}, errors.Wrap(err, "Error when creating a user")
+		return CreateUserOutput{}, apperror.Wrap(err, "Error when creating a user")
 	}
 
 	return CreateUserOutput{
@@ -64,52 +74,40 @@ func (u *Policy) CreateUser(ctx context.Context, input CreateUserInput) (CreateU
 	}, nil
 }
 
-func (u *Policy) validatePassword(password string) error {
-	// Check password length
-	if len(password) < 8 {
-		return errors.New("Пароль должен содержать не менее 8 символов")
+func (u *Policy) All(ctx context.Context, input AllUsersInput) (AllUsersOutput, error) {
+	ctx, err := access.IncludeDeleted(ctx, input.IncludeDeleted)
+	if err != nil {
+		return AllUsersOutput{}, err
 	}
 
-	// Check for the presence of digits in the password
-	var hasDigit bool
-	for _, char := range password {
-		if char >= '0' && char <= '9' {
-			hasDigit = true
-			break
-		}
-	}
-	if !hasDigit {
-		return errors.New("Пароль должен содержать хотя бы одну цифру")
-	}
+	filter := model.NewUserFilter(
+		input.IsMarried,
+		input.MinAge,
+		input.MaxAge,
+		input.CreatedAfter,
+		pagination.NewParams(input.Limit, input.Cursor, pagination.Sort(input.Sort)),
+	)
 
-	// Check for the presence of uppercase letters in the password
-	var hasUpper bool
-	for _, char := range password {
-		if char >= 'A' && char <= 'Z' {
-			hasUpper = true
-			break
-		}
-	}
-	if !hasUpper {
-		return errors.New("Пароль должен содержать хотя бы одну заглавную букву")
+	users, next, err := u.userService.All(ctx, filter)
+	if err != nil {
+		return AllUsersOutput{}, apperror.Wrap(err, "Error when getting all users")
 	}
 
-	return nil
+	return AllUsersOutput{
+		Users:      users,
+		NextCursor: next,
+	}, nil
 }
 
-func (u *Policy) All(ctx context.Context) ([]model.User, error) {
-	users, err := u.userService.All(ctx)
+func (u *Policy) GetUser(ctx context.Context, input GetUserInput) (GetUserOutput, error) {
+	ctx, err := access.IncludeDeleted(ctx, input.IncludeDeleted)
 	if err != nil {
-		return nil, errors.Wrap(err, "Error when getting all users")
+		return GetUserOutput{}, err
 	}
 
-	return users, nil
-}
-
-func (u *Policy) GetUser(ctx context.Context, input GetUserInput) (GetUserOutput, error) {
 	user, err := u.userService.GetUser(ctx, input.ID)
 	if err != nil {
-		return GetUserOutput{}, errors.Wrap(err, "Error when getting user")
+		return GetUserOutput{}, apperror.Wrap(err, "Error when getting user")
 	}
 
 	return GetUserOutput{
@@ -120,7 +118,7 @@ func (u *Policy) GetUser(ctx context.Context, input GetUserInput) (GetUserOutput
 func (u *Policy) GetUserByName(ctx context.Context, input GetUserByNameInput) (GetUserByNameOutput, error) {
 	user, err := u.userService.GetUserByName(ctx, input.FirstName)
 	if err != nil {
-		return GetUserByNameOutput{}, errors.Wrap(err, "Error when getting user")
+		return GetUserByNameOutput{}, apperror.Wrap(err, "Error when getting user")
 	}
 
 	return GetUserByNameOutput{
@@ -128,31 +126,59 @@ func (u *Policy) GetUserByName(ctx context.Context, input GetUserByNameInput) (G
 	}, nil
 }
 
+// UpdateUser merges input into the caller's profile if it still has
+// input.Version. The password is only rehashed when it is part of the patch
+// and the invariants are checked on the merged user.
 func (u *Policy) UpdateUser(ctx context.Context, input UpdateUserInput) (UpdateUserOutput, error) {
-	// Check user's age
-	if input.Age < 18 {
-		return UpdateUserOutput{}, errors.New("Пользователь должен быть не младше 18 лет")
+	// Users may only update themselves
+	callerID, err := authorizeSelf(ctx, input.ID)
+	if err != nil {
+		return UpdateUserOutput{}, err
 	}
+	input.ID = callerID
 
-	// Validate password
-	if err := u.validatePassword(input.Password); err != nil {
-		return UpdateUserOutput{}, err
+	current, err := u.userService.GetUser(ctx, input.ID)
+	if err != nil {
+		return UpdateUserOutput{}, apperror.Wrap(err, "Error when getting user")
+	}
+
+	// A patch made against an older version can't be checked against this one
+	if current.Version != input.Version {
+		return UpdateUserOutput{}, apperror.ErrPrecondition
+	}
+
+	var passwordHash *string
+	if input.Password != nil {
+		hash, err := u.hasher.Hash(*input.Password)
+		if err != nil {
+			return UpdateUserOutput{}, apperror.Wrap(err, "Error when hashing password")
+		}
+		passwordHash = &hash
 	}
 
 	updateUser := model.NewUpdateUser(
+		input.ID,
 		input.FirstName,
 		input.LastName,
-		input.FullName,
 		input.Age,
 		input.IsMarried,
-		input.Password,
-		input.Order,
-		input.UpdatedAt,
+		passwordHash,
+		u.clock.Now(),
+		input.Version,
 	)
 
+	merged := updateUser.Apply(current)
+	if fields := merged.Validate(); fields != nil {
+		return UpdateUserOutput{}, apperror.ErrValidation.WithDetails(fields)
+	}
+
+	if input.FirstName != nil || input.LastName != nil {
+		updateUser.FullName = &merged.FullName
+	}
+
 	user, err := u.userService.UpdateUser(ctx, updateUser)
 	if err != nil {
-		return UpdateUserOutput{}, errors.Wrap(err, "Error when updating user")
+		return UpdateUserOutput{}, apperror.Wrap(err, "Error when updating user")
 	}
 
 	return UpdateUserOutput{
@@ -161,36 +187,81 @@ func (u *Policy) UpdateUser(ctx context.Context, input UpdateUserInput) (UpdateU
 }
 
 func (u *Policy) DeleteUser(ctx context.Context, input DeleteUserInput) error {
-	err := u.userService.DeleteUser(ctx, input.ID)
+	// Only admins may delete users
+	if _, err := access.RequireRole(ctx, auth.RoleAdmin); err != nil {
+		return err
+	}
+
+	err := u.userService.DeleteUser(ctx, input.ID, u.clock.Now())
 	if err != nil {
-		return errors.Wrap(err, "Error when deleting user")
+		return apperror.Wrap(err, "Error when deleting user")
 	}
 
 	return nil
 }
 
-func (u *Policy) CreateOrder(ctx context.Context, input CreateOrderInput) (CreateOrderOutput, error) {
+func (u *Policy) UpdateRole(ctx context.Context, input UpdateRoleInput) (UpdateRoleOutput, error) {
+	// Only admins may grant or revoke roles
+	if _, err := access.RequireRole(ctx, auth.RoleAdmin); err != nil {
+		return UpdateRoleOutput{}, err
+	}
 
-	if !u.userService.AreProductsAvailable(ctx, input.ProductID, input.Products) {
-		return CreateOrderOutput{}, errors.New("Products not available in stock")
+	if err := u.userService.UpdateRole(ctx, input.ID, auth.Role(input.Role), u.clock.Now()); err != nil {
+		return UpdateRoleOutput{}, apperror.Wrap(err, "Error when updating user role")
 	}
 
-	createOrder := model.NewCreateOrder(
-		input.ID,
-		input.UserID,
-		input.ProductID,
-		input.Products,
-		input.TimeStamp,
-	)
+	user, err := u.userService.GetUser(ctx, input.ID)
+	if err != nil {
+		return UpdateRoleOutput{}, apperror.Wrap(err, "Error when getting user")
+	}
 
-	createdOrder, err := u.userService.CreateOrder(ctx, createOrder)
+	return UpdateRoleOutput{
+		User: user,
+	}, nil
+}
+
+// Authenticate checks the password of the user and transparently rehashes it
+// when the stored hash was produced with outdated hashing settings.
+func (u *Policy) Authenticate(ctx context.Context, input AuthenticateInput) (AuthenticateOutput, error) {
+	user, err := u.userService.GetUser(ctx, input.ID)
 	if err != nil {
-		return CreateOrderOutput{}, errors.Wrap(err, "Error when creating an order")
+		return AuthenticateOutput{}, apperror.Wrap(err, "Error when getting user")
+	}
+
+	if err = u.hasher.Verify(user.PasswordHash, input.Password); err != nil {
+		if errors.Is(err, password.ErrMismatch) {
+			return AuthenticateOutput{}, ErrInvalidCredentials
+		}
+
+		return AuthenticateOutput{}, apperror.Wrap(err, "Error when verifying password")
 	}
 
-	order := createdOrder.ToOrder()
+	if u.hasher.NeedsRehash(user.PasswordHash) {
+		if passwordHash, hashErr := u.hasher.Hash(input.Password); hashErr == nil {
+			if err = u.userService.UpdatePassword(ctx, user.ID, passwordHash, u.clock.Now()); err != nil {
+				logging.WithError(ctx, err).Warn("failed to rehash password")
+			} else {
+				user.PasswordHash = passwordHash
+			}
+		}
+	}
 
-	return CreateOrderOutput{
-		Order: order,
+	return AuthenticateOutput{
+		User: user,
 	}, nil
 }
+
+// authorizeSelf returns the ID of the authenticated caller, rejecting the
+// request when id is set and refers to another user.
+func authorizeSelf(ctx context.Context, id string) (string, error) {
+	principal, ok := auth.PrincipalFromContext(ctx)
+	if !ok {
+		return "", apperror.ErrUnauthorized
+	}
+
+	if id != "" && id != principal.UserID {
+		return "", apperror.ErrForbidden
+	}
+
+	return principal.UserID, nil
+}
@@ -0,0 +1,202 @@
+package user
+
+import (
+	"context"
+	"github.com/Amore14rn/888Starz_test/internal/apperror"
+	"github.com/Amore14rn/888Starz_test/internal/domain/user/model"
+	"github.com/Amore14rn/888Starz_test/internal/domain/user/service"
+	"github.com/Amore14rn/888Starz_test/pkg/common/auth"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockRepository struct {
+	mock.Mock
+}
+
+func (m *MockRepository) All(ctx context.Context, filter model.UserFilter) ([]model.User, string, error) {
+	args := m.Called(ctx, filter)
+	return args.Get(0).([]model.User), args.String(1), args.Error(2)
+}
+
+func (m *MockRepository) Create(ctx context.Context, req model.CreateUser) error {
+	args := m.Called(ctx, req)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetUser(ctx context.Context, id string) (model.User, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(model.User), args.Error(1)
+}
+
+func (m *MockRepository) GetUserByName(ctx context.Context, name string) (model.User, error) {
+	args := m.Called(ctx, name)
+	return args.Get(0).(model.User), args.Error(1)
+}
+
+func (m *MockRepository) Update(ctx context.Context, req model.UpdateUser) error {
+	args := m.Called(ctx, req)
+	return args.Error(0)
+}
+
+func (m *MockRepository) Delete(ctx context.Context, id string, at time.Time) error {
+	args := m.Called(ctx, id, at)
+	return args.Error(0)
+}
+
+func (m *MockRepository) PurgeDeleted(ctx context.Context, before, at time.Time) (int64, error) {
+	args := m.Called(ctx, before, at)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockRepository) UpdatePassword(ctx context.Context, id, passwordHash string, updatedAt time.Time) error {
+	args := m.Called(ctx, id, passwordHash, updatedAt)
+	return args.Error(0)
+}
+
+func (m *MockRepository) UpdateRole(ctx context.Context, id string, role auth.Role, updatedAt time.Time) error {
+	args := m.Called(ctx, id, role, updatedAt)
+	return args.Error(0)
+}
+
+type MockIdentityGenerator struct {
+}
+
+func (m MockIdentityGenerator) GenerateUUIDv4String() string {
+	return "some_mocked_uuid"
+}
+
+type MockClock struct {
+	now time.Time
+}
+
+func (m MockClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+func (m MockClock) Since(t time.Time) time.Duration { return m.now.Sub(t) }
+
+func (m MockClock) Until(t time.Time) time.Duration { return t.Sub(m.now) }
+
+func (m MockClock) Sleep(d time.Duration) {}
+
+func (m MockClock) Tick(d time.Duration) <-chan time.Time { return time.Tick(d) }
+
+func (m MockClock) Now() time.Time { return m.now }
+
+// MockHasher "hashes" by prefixing the password.
+type MockHasher struct {
+}
+
+func (m MockHasher) Hash(password string) (string, error) { return "hashed:" + password, nil }
+
+func (m MockHasher) Verify(hash, password string) error { return nil }
+
+func (m MockHasher) NeedsRehash(hash string) bool { return false }
+
+var now = time.Date(2023, 9, 10, 12, 0, 0, 0, time.UTC)
+
+func principalContext(userID string, role auth.Role) context.Context {
+	return auth.ContextWithPrincipal(context.Background(), auth.Principal{UserID: userID, Role: role})
+}
+
+func newPolicy(repo *MockRepository) *Policy {
+	return NewUserPolicy(service.NewUserService(repo), MockIdentityGenerator{}, MockClock{now: now}, MockHasher{})
+}
+
+func TestAdminOnlyActions(t *testing.T) {
+	tests := map[string]struct {
+		ctx  context.Context
+		want error
+	}{
+		"anonymous": {ctx: context.Background(), want: apperror.ErrUnauthorized},
+		"customer":  {ctx: principalContext("c1", auth.RoleCustomer), want: apperror.ErrForbidden},
+		"manager":   {ctx: principalContext("m1", auth.RoleManager), want: apperror.ErrForbidden},
+		"admin":     {ctx: principalContext("a1", auth.RoleAdmin)},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			mockRepo := new(MockRepository)
+			mockRepo.On("Delete", mock.Anything, "u1", now).Return(nil)
+			mockRepo.On("UpdateRole", mock.Anything, "u1", auth.RoleManager, now).Return(nil)
+			mockRepo.On("GetUser", mock.Anything, "u1").Return(model.User{ID: "u1", Role: auth.RoleManager}, nil)
+			policy := newPolicy(mockRepo)
+
+			deleteErr := policy.DeleteUser(tt.ctx, NewDeleteUserInput("u1"))
+			_, roleErr := policy.UpdateRole(tt.ctx, NewUpdateRoleInput("u1", string(auth.RoleManager)))
+
+			if tt.want == nil {
+				assert.NoError(t, deleteErr)
+				assert.NoError(t, roleErr)
+				mockRepo.AssertExpectations(t)
+
+				return
+			}
+
+			assert.ErrorIs(t, deleteErr, tt.want)
+			assert.ErrorIs(t, roleErr, tt.want)
+			mockRepo.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything, mock.Anything)
+			mockRepo.AssertNotCalled(t, "UpdateRole", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestUpdateUserSelfOnly(t *testing.T) {
+	age := uint32(30)
+	current := model.User{ID: "u1", FirstName: "Ann", LastName: "Lee", FullName: "Ann Lee", Age: 25, Version: 2}
+
+	tests := map[string]struct {
+		ctx  context.Context
+		id   string
+		want error
+	}{
+		"anonymous":         {ctx: context.Background(), id: "u1", want: apperror.ErrUnauthorized},
+		"self by id":        {ctx: principalContext("u1", auth.RoleCustomer), id: "u1"},
+		"self without id":   {ctx: principalContext("u1", auth.RoleCustomer)},
+		"another user":      {ctx: principalContext("u2", auth.RoleCustomer), id: "u1", want: apperror.ErrForbidden},
+		"admin for another": {ctx: principalContext("a1", auth.RoleAdmin), id: "u1", want: apperror.ErrForbidden},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			mockRepo := new(MockRepository)
+			mockRepo.On("GetUser", mock.Anything, "u1").Return(current, nil)
+			mockRepo.On("Update", mock.Anything, mock.MatchedBy(func(req model.UpdateUser) bool {
+				return req.ID == "u1" && req.Age != nil && *req.Age == age && req.Version == 2
+			})).Return(nil)
+			policy := newPolicy(mockRepo)
+
+			input := NewUpdateUserInput(tt.id, nil, nil, &age, nil, nil)
+			input.Version = 2
+
+			output, err := policy.UpdateUser(tt.ctx, input)
+
+			if tt.want != nil {
+				assert.ErrorIs(t, err, tt.want)
+				mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, "u1", output.User.ID)
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestUpdateUserStaleVersion(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockRepo.On("GetUser", mock.Anything, "u1").Return(model.User{ID: "u1", Version: 3}, nil)
+
+	input := NewUpdateUserInput("", nil, nil, nil, nil, nil)
+	input.Version = 2
+
+	_, err := newPolicy(mockRepo).UpdateUser(principalContext("u1", auth.RoleCustomer), input)
+
+	assert.ErrorIs(t, err, apperror.ErrPrecondition)
+	mockRepo.AssertNotCalled(t, "Update", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,69 @@
+package webhooks
+
+import (
+	"github.com/Amore14rn/888Starz_test/internal/domain/webhooks/model"
+)
+
+type CreateSubscriptionInput struct {
+	URL        string
+	EventTypes []string
+	Secret     string
+}
+
+func NewCreateSubscriptionInput(url string, eventTypes []string, secret string) CreateSubscriptionInput {
+	return CreateSubscriptionInput{
+		URL:        url,
+		EventTypes: eventTypes,
+		Secret:     secret,
+	}
+}
+
+type SubscriptionOutput struct {
+	Subscription model.Subscription
+}
+
+type SubscriptionsOutput struct {
+	Subscriptions []model.Subscription
+}
+
+type DeleteSubscriptionInput struct {
+	ID string
+}
+
+func NewDeleteSubscriptionInput(id string) DeleteSubscriptionInput {
+	return DeleteSubscriptionInput{
+		ID: id,
+	}
+}
+
+type DeliveriesInput struct {
+	SubscriptionID string
+	Limit          int
+}
+
+func NewDeliveriesInput(subscriptionID string, limit int) DeliveriesInput {
+	return DeliveriesInput{
+		SubscriptionID: subscriptionID,
+		Limit:          limit,
+	}
+}
+
+type DeliveriesOutput struct {
+	Deliveries []model.Delivery
+}
+
+type DeliveryInput struct {
+	SubscriptionID string
+	DeliveryID     int64
+}
+
+func NewDeliveryInput(subscriptionID string, deliveryID int64) DeliveryInput {
+	return DeliveryInput{
+		SubscriptionID: subscriptionID,
+		DeliveryID:     deliveryID,
+	}
+}
+
+type DeliveryOutput struct {
+	Delivery model.Delivery
+}
@@ -0,0 +1,188 @@
+package webhooks
+
+import (
+	"context"
+	"github.com/Amore14rn/888Starz_test/internal/apperror"
+	"github.com/Amore14rn/888Starz_test/internal/domain/policy/access"
+	"github.com/Amore14rn/888Starz_test/internal/domain/webhooks/model"
+	"github.com/Amore14rn/888Starz_test/internal/domain/webhooks/service"
+	"github.com/Amore14rn/888Starz_test/pkg/common/auth"
+	"github.com/Amore14rn/888Starz_test/pkg/common/core/pagination"
+	"time"
+)
+
+type IdentityGenerator interface {
+	GenerateUUIDv4String() string
+}
+
+type Clock interface {
+	Now() time.Time
+}
+
+type Policy struct {
+	webhookService *service.WebhookService
+
+	identity IdentityGenerator
+	clock    Clock
+}
+
+func NewWebhookPolicy(webhookService *service.WebhookService, identity IdentityGenerator, clock Clock) *Policy {
+	return &Policy{
+		webhookService: webhookService,
+		identity:       identity,
+		clock:          clock,
+	}
+}
+
+// CreateSubscription stores a subscription and returns it with its secret,
+// which is generated when the caller didn't pass one. The secret is never
+// returned again.
+func (p *Policy) CreateSubscription(ctx context.Context, input CreateSubscriptionInput) (SubscriptionOutput, error) {
+	// Подписками партнёров управляют только администраторы
+	principal, err := access.RequireRole(ctx, auth.RoleAdmin)
+	if err != nil {
+		return SubscriptionOutput{}, err
+	}
+
+	secret := input.Secret
+	if secret == "" {
+		if secret, err = model.NewSecret(); err != nil {
+			return SubscriptionOutput{}, apperror.Wrap(err, "Error when generating webhook secret")
+		}
+	}
+
+	sub := model.NewSubscription(
+		p.identity.GenerateUUIDv4String(),
+		input.URL,
+		uniqueEventTypes(input.EventTypes),
+		secret,
+		principal.UserID,
+		p.clock.Now(),
+	)
+
+	if err = p.webhookService.CreateSubscription(ctx, sub); err != nil {
+		return SubscriptionOutput{}, apperror.Wrap(err, "Error when creating webhook subscription")
+	}
+
+	return SubscriptionOutput{
+		Subscription: sub,
+	}, nil
+}
+
+func (p *Policy) Subscriptions(ctx context.Context) (SubscriptionsOutput, error) {
+	if _, err := access.RequireRole(ctx, auth.RoleAdmin); err != nil {
+		return SubscriptionsOutput{}, err
+	}
+
+	subs, err := p.webhookService.Subscriptions(ctx)
+	if err != nil {
+		return SubscriptionsOutput{}, apperror.Wrap(err, "Error when getting webhook subscriptions")
+	}
+
+	for i := range subs {
+		subs[i] = subs[i].Redacted()
+	}
+
+	return SubscriptionsOutput{
+		Subscriptions: subs,
+	}, nil
+}
+
+func (p *Policy) DeleteSubscription(ctx context.Context, input DeleteSubscriptionInput) (SubscriptionOutput, error) {
+	sub, err := p.subscription(ctx, input.ID)
+	if err != nil {
+		return SubscriptionOutput{}, err
+	}
+
+	if err = p.webhookService.DeleteSubscription(ctx, input.ID); err != nil {
+		return SubscriptionOutput{}, apperror.Wrap(err, "Error when deleting webhook subscription")
+	}
+
+	return SubscriptionOutput{
+		Subscription: sub,
+	}, nil
+}
+
+// Deliveries returns the latest deliveries of the subscription, newest first.
+func (p *Policy) Deliveries(ctx context.Context, input DeliveriesInput) (DeliveriesOutput, error) {
+	if _, err := p.subscription(ctx, input.SubscriptionID); err != nil {
+		return DeliveriesOutput{}, err
+	}
+
+	limit := input.Limit
+	if limit <= 0 {
+		limit = pagination.DefaultLimit
+	}
+	if limit > pagination.MaxLimit {
+		limit = pagination.MaxLimit
+	}
+
+	deliveries, err := p.webhookService.Deliveries(ctx, input.SubscriptionID, limit)
+	if err != nil {
+		return DeliveriesOutput{}, apperror.Wrap(err, "Error when getting webhook deliveries")
+	}
+
+	return DeliveriesOutput{
+		Deliveries: deliveries,
+	}, nil
+}
+
+// GetDelivery returns the delivery together with the log of its attempts.
+func (p *Policy) GetDelivery(ctx context.Context, input DeliveryInput) (DeliveryOutput, error) {
+	if _, err := access.RequireRole(ctx, auth.RoleAdmin); err != nil {
+		return DeliveryOutput{}, err
+	}
+
+	delivery, err := p.webhookService.Delivery(ctx, input.SubscriptionID, input.DeliveryID)
+	if err != nil {
+		return DeliveryOutput{}, apperror.Wrap(err, "Error when getting webhook delivery")
+	}
+
+	return DeliveryOutput{
+		Delivery: delivery,
+	}, nil
+}
+
+// Redeliver schedules the delivery to be sent again right away, also when it
+// was already delivered or gave up.
+func (p *Policy) Redeliver(ctx context.Context, input DeliveryInput) (DeliveryOutput, error) {
+	if _, err := access.RequireRole(ctx, auth.RoleAdmin); err != nil {
+		return DeliveryOutput{}, err
+	}
+
+	err := p.webhookService.Redeliver(ctx, input.SubscriptionID, input.DeliveryID, p.clock.Now())
+	if err != nil {
+		return DeliveryOutput{}, apperror.Wrap(err, "Error when redelivering webhook")
+	}
+
+	return p.GetDelivery(ctx, input)
+}
+
+func (p *Policy) subscription(ctx context.Context, id string) (model.Subscription, error) {
+	if _, err := access.RequireRole(ctx, auth.RoleAdmin); err != nil {
+		return model.Subscription{}, err
+	}
+
+	sub, err := p.webhookService.GetSubscription(ctx, id)
+	if err != nil {
+		return model.Subscription{}, apperror.Wrap(err, "Error when getting webhook subscription")
+	}
+
+	return sub.Redacted(), nil
+}
+
+func uniqueEventTypes(types []string) []string {
+	seen := make(map[string]struct{}, len(types))
+	unique := make([]string, 0, len(types))
+
+	for _, t := range types {
+		if _, ok := seen[t]; ok {
+			continue
+		}
+
+		seen[t] = struct{}{}
+		unique = append(unique, t)
+	}
+
+	return unique
+}
@@ -0,0 +1,160 @@
+package webhooks
+
+import (
+	"context"
+	"github.com/Amore14rn/888Starz_test/internal/apperror"
+	"github.com/Amore14rn/888Starz_test/internal/dal"
+	"github.com/Amore14rn/888Starz_test/internal/domain/webhooks/model"
+	"github.com/Amore14rn/888Starz_test/internal/domain/webhooks/service"
+	"github.com/Amore14rn/888Starz_test/pkg/common/auth"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockRepository struct {
+	mock.Mock
+}
+
+func (m *MockRepository) Create(ctx context.Context, sub model.Subscription) error {
+	args := m.Called(ctx, sub)
+	return args.Error(0)
+}
+
+func (m *MockRepository) All(ctx context.Context) ([]model.Subscription, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]model.Subscription), args.Error(1)
+}
+
+func (m *MockRepository) Get(ctx context.Context, id string) (model.Subscription, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(model.Subscription), args.Error(1)
+}
+
+func (m *MockRepository) Delete(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockRepository) Enqueue(ctx context.Context, eventID int64, eventType string, payload []byte, at time.Time) (int64, error) {
+	args := m.Called(ctx, eventID, eventType, payload, at)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockRepository) Claim(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]model.PendingDelivery, error) {
+	args := m.Called(ctx, now, lease, limit)
+	return args.Get(0).([]model.PendingDelivery), args.Error(1)
+}
+
+func (m *MockRepository) Record(ctx context.Context, result model.DeliveryResult) error {
+	args := m.Called(ctx, result)
+	return args.Error(0)
+}
+
+func (m *MockRepository) Deliveries(ctx context.Context, subscriptionID string, limit int) ([]model.Delivery, error) {
+	args := m.Called(ctx, subscriptionID, limit)
+	return args.Get(0).([]model.Delivery), args.Error(1)
+}
+
+func (m *MockRepository) Delivery(ctx context.Context, subscriptionID string, id int64) (model.Delivery, error) {
+	args := m.Called(ctx, subscriptionID, id)
+	return args.Get(0).(model.Delivery), args.Error(1)
+}
+
+func (m *MockRepository) Redeliver(ctx context.Context, subscriptionID string, id int64, at time.Time) error {
+	args := m.Called(ctx, subscriptionID, id, at)
+	return args.Error(0)
+}
+
+type MockIdentityGenerator struct {
+}
+
+func (m MockIdentityGenerator) GenerateUUIDv4String() string {
+	return "some_mocked_uuid"
+}
+
+type MockClock struct {
+	now time.Time
+}
+
+func (m MockClock) Now() time.Time {
+	return m.now
+}
+
+func adminContext() context.Context {
+	return auth.ContextWithPrincipal(context.Background(), auth.Principal{UserID: "a1", Role: auth.RoleAdmin})
+}
+
+func newPolicy(repo *MockRepository, now time.Time) *Policy {
+	return NewWebhookPolicy(service.NewWebhookService(repo), MockIdentityGenerator{}, MockClock{now: now})
+}
+
+func TestCreateSubscription(t *testing.T) {
+	now := time.Date(2023, 9, 10, 12, 0, 0, 0, time.UTC)
+
+	mockRepo := new(MockRepository)
+	mockRepo.On("Create", mock.Anything, mock.Anything).Return(nil)
+
+	output, err := newPolicy(mockRepo, now).CreateSubscription(adminContext(), NewCreateSubscriptionInput(
+		"https://partner.example/hooks",
+		[]string{"order.status_changed", "order.created", "order.status_changed"},
+		"",
+	))
+
+	assert.NoError(t, err)
+	sub := output.Subscription
+	assert.Equal(t, "some_mocked_uuid", sub.ID)
+	assert.Equal(t, []string{"order.status_changed", "order.created"}, sub.EventTypes)
+	assert.Len(t, sub.Secret, 64, "a secret is generated when none is passed")
+	assert.Equal(t, "a1", sub.CreatedBy)
+	mockRepo.AssertCalled(t, "Create", mock.Anything, sub)
+}
+
+func TestSubscriptionsHideSecrets(t *testing.T) {
+	mockRepo := new(MockRepository)
+	mockRepo.On("All", mock.Anything).Return([]model.Subscription{{ID: "s1", Secret: "topsecret"}}, nil)
+
+	output, err := newPolicy(mockRepo, time.Now()).Subscriptions(adminContext())
+
+	assert.NoError(t, err)
+	assert.Equal(t, []model.Subscription{{ID: "s1"}}, output.Subscriptions)
+}
+
+func TestRedeliver(t *testing.T) {
+	now := time.Date(2023, 9, 10, 12, 0, 0, 0, time.UTC)
+	delivery := model.Delivery{ID: 7, SubscriptionID: "s1", Status: model.DeliveryPending}
+
+	mockRepo := new(MockRepository)
+	mockRepo.On("Redeliver", mock.Anything, "s1", int64(7), now).Return(nil)
+	mockRepo.On("Delivery", mock.Anything, "s1", int64(7)).Return(delivery, nil)
+	mockRepo.On("Redeliver", mock.Anything, "s1", int64(8), now).Return(dal.ErrNotFound)
+
+	policy := newPolicy(mockRepo, now)
+
+	output, err := policy.Redeliver(adminContext(), NewDeliveryInput("s1", 7))
+	assert.NoError(t, err)
+	assert.Equal(t, delivery, output.Delivery)
+
+	_, err = policy.Redeliver(adminContext(), NewDeliveryInput("s1", 8))
+	assert.ErrorIs(t, err, apperror.ErrNotFound)
+}
+
+func TestWebhooksRequireAdmin(t *testing.T) {
+	mockRepo := new(MockRepository)
+	policy := newPolicy(mockRepo, time.Now())
+	manager := auth.ContextWithPrincipal(context.Background(), auth.Principal{UserID: "m1", Role: auth.RoleManager})
+
+	_, err := policy.Subscriptions(context.Background())
+	assert.ErrorIs(t, err, apperror.ErrUnauthorized)
+
+	_, err = policy.CreateSubscription(manager, NewCreateSubscriptionInput("https://partner.example", []string{"order.created"}, ""))
+	assert.ErrorIs(t, err, apperror.ErrForbidden)
+
+	_, err = policy.Deliveries(manager, NewDeliveriesInput("s1", 0))
+	assert.ErrorIs(t, err, apperror.ErrForbidden)
+
+	mockRepo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	mockRepo.AssertNotCalled(t, "Get", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,21 @@
+package dao
+
+import (
+	auditmodel "github.com/Amore14rn/888Starz_test/internal/domain/audit/model"
+	"github.com/Amore14rn/888Starz_test/internal/domain/products/model"
+	"time"
+)
+
+// productState is what the audit log records of a product.
+func productState(p model.Products) map[string]any {
+	return map[string]any{
+		"description": p.Description,
+		"quantity":    p.Quantity,
+		"price":       p.Price,
+		"tags":        p.Tags,
+	}
+}
+
+func productEntry(action auditmodel.Action, id string, diff auditmodel.Diff, at time.Time) (auditmodel.Entry, error) {
+	return auditmodel.NewEntry(action, auditmodel.EntityProduct, id, diff, at)
+}
@@ -0,0 +1,43 @@
+package dao
+
+import (
+	outboxmodel "github.com/Amore14rn/888Starz_test/internal/domain/outbox/model"
+	"time"
+)
+
+// productEvents describes the move of a product from before to the given
+// quantity and price, nothing is returned for values that didn't change.
+func productEvents(id string, before ProductStockStorage, quantity int, price float64, reason string, at time.Time) ([]outboxmodel.Event, error) {
+	var events []outboxmodel.Event
+
+	if quantity != before.Quantity {
+		event, err := outboxmodel.NewEvent(outboxmodel.AggregateProduct, id, outboxmodel.EventProductStockChanged, outboxmodel.StockChanged{
+			ProductID: id,
+			Quantity:  quantity,
+			Delta:     quantity - before.Quantity,
+			Reason:    reason,
+			ChangedAt: at,
+		}, at)
+		if err != nil {
+			return nil, err
+		}
+
+		events = append(events, event)
+	}
+
+	if price != before.Price {
+		event, err := outboxmodel.NewEvent(outboxmodel.AggregateProduct, id, outboxmodel.EventProductPriceChanged, outboxmodel.PriceChanged{
+			ProductID: id,
+			Price:     price,
+			OldPrice:  before.Price,
+			ChangedAt: at,
+		}, at)
+		if err != nil {
+			return nil, err
+		}
+
+		events = append(events, event)
+	}
+
+	return events, nil
+}
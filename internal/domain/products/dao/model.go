@@ -6,11 +6,14 @@ import (
 )
 
 type ProductStorage struct {
-	ID          string    `json:"id"`
-	Description string    `json:"description"`
-	Quantity    int       `json:"quantity"`
-	Tags        []string  `json:"tags"`
-	CreatedAt   time.Time `json:"created_at"`
+	ID          string     `json:"id"`
+	Description string     `json:"description"`
+	Quantity    int        `json:"quantity"`
+	Price       float64    `json:"price"`
+	Tags        []string   `json:"tags"`
+	CreatedAt   time.Time  `json:"created_at"`
+	Version     int64      `json:"version"`
+	DeletedAt   *time.Time `json:"deleted_at"`
 }
 
 func (ps *ProductStorage) ToDomain() model.Products {
@@ -18,7 +21,66 @@ func (ps *ProductStorage) ToDomain() model.Products {
 		ID:          ps.ID,
 		Description: ps.Description,
 		Quantity:    ps.Quantity,
+		Price:       ps.Price,
 		Tags:        ps.Tags,
 		CreatedAt:   ps.CreatedAt,
+		Version:     ps.Version,
+		DeletedAt:   ps.DeletedAt,
+	}
+}
+
+// ProductStockStorage is the editable state and version of a product locked
+// for update.
+type ProductStockStorage struct {
+	Description string   `json:"description"`
+	Quantity    int      `json:"quantity"`
+	Price       float64  `json:"price"`
+	Tags        []string `json:"tags"`
+	Version     int64    `json:"version"`
+}
+
+func (ps *ProductStockStorage) ToDomain() model.Products {
+	return model.Products{
+		Description: ps.Description,
+		Quantity:    ps.Quantity,
+		Price:       ps.Price,
+		Tags:        ps.Tags,
+		Version:     ps.Version,
+	}
+}
+
+type ProductHistoryStorage struct {
+	ProductID string    `json:"product_id"`
+	Price     float64   `json:"price"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+func (ph *ProductHistoryStorage) ToDomain() model.ProductHistory {
+	return model.NewProductHistory(ph.ProductID, ph.Price, ph.Timestamp)
+}
+
+type TagCountStorage struct {
+	Tag   string `json:"tag"`
+	Count int    `json:"count"`
+}
+
+func (tc *TagCountStorage) ToDomain() model.TagCount {
+	return model.TagCount{
+		Tag:   tc.Tag,
+		Count: tc.Count,
+	}
+}
+
+type ProductSearchStorage struct {
+	ProductStorage
+	Rank     float32 `json:"rank"`
+	Headline string  `json:"headline"`
+}
+
+func (ps *ProductSearchStorage) ToDomain() model.ProductSearchResult {
+	return model.ProductSearchResult{
+		Product:  ps.ProductStorage.ToDomain(),
+		Rank:     ps.Rank,
+		Headline: ps.Headline,
 	}
 }
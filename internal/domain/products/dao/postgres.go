@@ -2,39 +2,63 @@ package dao
 
 import (
 	"context"
+	"github.com/Amore14rn/888Starz_test/internal/dal"
 	"github.com/Amore14rn/888Starz_test/internal/dal/postgres"
+	auditmodel "github.com/Amore14rn/888Starz_test/internal/domain/audit/model"
+	outboxmodel "github.com/Amore14rn/888Starz_test/internal/domain/outbox/model"
 	"github.com/Amore14rn/888Starz_test/internal/domain/products/model"
-	"github.com/Amore14rn/888Starz_test/pkg/errors"
+	"github.com/Amore14rn/888Starz_test/pkg/common/core/pagination"
 	psql "github.com/Amore14rn/888Starz_test/pkg/postgresql"
 	"github.com/Amore14rn/888Starz_test/pkg/tracing"
 	sq "github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
 	"strconv"
+	"strings"
+	"time"
 )
 
+type outbox interface {
+	Append(ctx context.Context, tx pgx.Tx, events ...outboxmodel.Event) error
+}
+
+type audit interface {
+	Append(ctx context.Context, tx pgx.Tx, entries ...auditmodel.Entry) error
+}
+
 type ProductDAO struct {
 	qb     sq.StatementBuilderType
 	client psql.Client
+	outbox outbox
+	audit  audit
 }
 
-func NewProductDAO(client psql.Client) *ProductDAO {
+func NewProductDAO(client psql.Client, outbox outbox, audit audit) *ProductDAO {
 	return &ProductDAO{
 		qb:     sq.StatementBuilder.PlaceholderFormat(sq.Dollar),
 		client: client,
+		outbox: outbox,
+		audit:  audit,
 	}
 }
 
-func (repo *ProductDAO) All(ctx context.Context) ([]model.Products, error) {
-	all, err := repo.findBy(ctx)
+// All returns one page of products matching filter together with the
+// cursor of the next page, which is empty on the last page.
+func (repo *ProductDAO) All(ctx context.Context, filter model.ProductFilter) ([]model.Products, string, error) {
+	all, err := repo.findBy(ctx, filter)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
+	all, next := postgres.NextCursor(all, filter.Page, func(e ProductStorage) pagination.Cursor {
+		return pagination.Cursor{CreatedAt: e.CreatedAt, ID: e.ID}
+	})
+
 	resp := make([]model.Products, len(all))
 	for i, e := range all {
 		resp[i] = e.ToDomain()
 	}
 
-	return resp, nil
+	return resp, next, nil
 }
 
 func (repo *ProductDAO) Create(ctx context.Context, req model.CreateProducts) (model.Products, error) {
@@ -44,12 +68,16 @@ func (repo *ProductDAO) Create(ctx context.Context, req model.CreateProducts) (m
 			"id",
 			"description",
 			"quantity",
+			"price",
+			"tags",
 			"created_at",
 		).
 		Values(
 			req.ID,
 			req.Description,
 			req.Quantity,
+			req.Price,
+			model.NormalizeTags(req.Tags),
 			req.CreatedAt,
 		).ToSql()
 	if err != nil {
@@ -65,25 +93,58 @@ func (repo *ProductDAO) Create(ctx context.Context, req model.CreateProducts) (m
 		tracing.TraceIVal(ctx, "arg-"+strconv.Itoa(i), arg)
 	}
 
-	cmd, execErr := repo.client.Exec(ctx, sql, args...)
-	if execErr != nil {
-		execErr = psql.ErrDoQuery(execErr)
-		tracing.Error(ctx, execErr)
+	err = psql.WithTx(ctx, repo.client, pgx.TxOptions{}, func(tx pgx.Tx) error {
+		cmd, execErr := tx.Exec(ctx, sql, args...)
+		if execErr != nil {
+			return psql.ErrDoQuery(dal.FromPg(execErr))
+		}
 
-		return model.Products{}, execErr
-	}
+		if cmd.RowsAffected() == 0 {
+			return dal.ErrNothingInserted
+		}
 
-	if cmd.RowsAffected() == 0 {
-		return model.Products{}, errors.New("nothing inserted")
+		if err := repo.insertHistory(ctx, tx, model.NewProductHistory(req.ID, req.Price, req.CreatedAt)); err != nil {
+			return err
+		}
+
+		events, err := productEvents(req.ID, ProductStockStorage{}, req.Quantity, req.Price, outboxmodel.StockReasonCreated, req.CreatedAt)
+		if err != nil {
+			return err
+		}
+
+		if err = repo.outbox.Append(ctx, tx, events...); err != nil {
+			return err
+		}
+
+		entry, err := productEntry(auditmodel.ActionCreate, req.ID, auditmodel.Changes(nil, productState(model.Products{
+			Description: req.Description,
+			Quantity:    req.Quantity,
+			Price:       req.Price,
+			Tags:        model.NormalizeTags(req.Tags),
+		})), req.CreatedAt)
+		if err != nil {
+			return err
+		}
+
+		return repo.audit.Append(ctx, tx, entry)
+	})
+	if err != nil {
+		tracing.Error(ctx, err)
+
+		return model.Products{}, err
 	}
 
-	return model.NewProduct(
+	product := model.NewProduct(
 		req.ID,
 		req.Description,
 		req.Quantity,
-		req.Tags,
+		req.Price,
+		model.NormalizeTags(req.Tags),
 		req.CreatedAt,
-		nil), nil
+		nil)
+	product.Version = 1
+
+	return product, nil
 }
 
 func (repo *ProductDAO) GetByID(ctx context.Context, id string) (model.Products, error) {
@@ -92,10 +153,15 @@ func (repo *ProductDAO) GetByID(ctx context.Context, id string) (model.Products,
 			"id",
 			"description",
 			"quantity",
+			"price",
+			"tags",
 			"created_at",
+			"version",
+			"deleted_at",
 		).
 		From(postgres.ProductTable).
 		Where(sq.Eq{"id": id})
+	statement = postgres.NotDeleted(ctx, statement, "deleted_at")
 
 	query, args, err := statement.ToSql()
 	if err != nil {
@@ -113,7 +179,7 @@ func (repo *ProductDAO) GetByID(ctx context.Context, id string) (model.Products,
 
 	rows, err := repo.client.Query(ctx, query, args...)
 	if err != nil {
-		err = psql.ErrDoQuery(err)
+		err = psql.ErrDoQuery(dal.FromPg(err))
 		tracing.Error(ctx, err)
 
 		return model.Products{}, err
@@ -121,33 +187,77 @@ func (repo *ProductDAO) GetByID(ctx context.Context, id string) (model.Products,
 
 	defer rows.Close()
 
-	var e ProductStorage
-	if rows.Next() {
-		if err = rows.Scan(
-			&e.ID,
-			&e.Description,
-			&e.Quantity,
-			&e.CreatedAt,
-		); err != nil {
-			err = psql.ErrScan(psql.ParsePgError(err))
+	if !rows.Next() {
+		if err = rows.Err(); err != nil {
+			err = psql.ErrDoQuery(dal.FromPg(err))
 			tracing.Error(ctx, err)
 
 			return model.Products{}, err
 		}
+
+		return model.Products{}, dal.ErrNotFound
+	}
+
+	var e ProductStorage
+	if err = rows.Scan(
+		&e.ID,
+		&e.Description,
+		&e.Quantity,
+		&e.Price,
+		&e.Tags,
+		&e.CreatedAt,
+		&e.Version,
+		&e.DeletedAt,
+	); err != nil {
+		err = psql.ErrScan(dal.FromPg(err))
+		tracing.Error(ctx, err)
+
+		return model.Products{}, err
 	}
 
 	return e.ToDomain(), nil
 }
 
-func (repo *ProductDAO) findBy(ctx context.Context) ([]ProductStorage, error) {
+func (repo *ProductDAO) findBy(ctx context.Context, filter model.ProductFilter) ([]ProductStorage, error) {
 	statement := repo.qb.
 		Select(
 			"id",
 			"description",
 			"quantity",
+			"price",
+			"tags",
 			"created_at",
+			"version",
+			"deleted_at",
 		).
 		From(postgres.ProductTable)
+	statement = postgres.NotDeleted(ctx, statement, "deleted_at")
+
+	if len(filter.Tags) > 0 {
+		switch filter.TagMatch {
+		case model.TagMatchAll:
+			statement = statement.Where(sq.Expr("tags @> ?", filter.Tags))
+		default:
+			statement = statement.Where(sq.Expr("tags && ?", filter.Tags))
+		}
+	}
+
+	if filter.MinQuantity != nil {
+		statement = statement.Where(sq.GtOrEq{"quantity": *filter.MinQuantity})
+	}
+	if filter.MaxQuantity != nil {
+		statement = statement.Where(sq.LtOrEq{"quantity": *filter.MaxQuantity})
+	}
+	if filter.CreatedAfter != nil {
+		statement = statement.Where(sq.Gt{"created_at": *filter.CreatedAfter})
+	}
+
+	statement, err := postgres.Paginate(statement, filter.Page)
+	if err != nil {
+		tracing.Error(ctx, err)
+
+		return nil, err
+	}
 
 	query, args, err := statement.ToSql()
 	if err != nil {
@@ -165,7 +275,7 @@ func (repo *ProductDAO) findBy(ctx context.Context) ([]ProductStorage, error) {
 
 	rows, err := repo.client.Query(ctx, query, args...)
 	if err != nil {
-		err = psql.ErrDoQuery(err)
+		err = psql.ErrDoQuery(dal.FromPg(err))
 		tracing.Error(ctx, err)
 
 		return nil, err
@@ -173,7 +283,7 @@ func (repo *ProductDAO) findBy(ctx context.Context) ([]ProductStorage, error) {
 
 	defer rows.Close()
 
-	entities := make([]ProductStorage, rows.CommandTag().RowsAffected())
+	entities := make([]ProductStorage, 0, filter.Page.Limit+1)
 
 	for rows.Next() {
 		var e ProductStorage
@@ -181,9 +291,13 @@ func (repo *ProductDAO) findBy(ctx context.Context) ([]ProductStorage, error) {
 			&e.ID,
 			&e.Description,
 			&e.Quantity,
+			&e.Price,
+			&e.Tags,
 			&e.CreatedAt,
+			&e.Version,
+			&e.DeletedAt,
 		); err != nil {
-			err = psql.ErrScan(psql.ParsePgError(err))
+			err = psql.ErrScan(dal.FromPg(err))
 			tracing.Error(ctx, err)
 
 			return nil, err
@@ -192,6 +306,13 @@ func (repo *ProductDAO) findBy(ctx context.Context) ([]ProductStorage, error) {
 		entities = append(entities, e)
 	}
 
+	if err = rows.Err(); err != nil {
+		err = psql.ErrDoQuery(dal.FromPg(err))
+		tracing.Error(ctx, err)
+
+		return nil, err
+	}
+
 	return entities, nil
 }
 
@@ -201,10 +322,15 @@ func (repo *ProductDAO) GetProduct(ctx context.Context, id string) (model.Produc
 			"id",
 			"description",
 			"quantity",
+			"price",
+			"tags",
 			"created_at",
+			"version",
+			"deleted_at",
 		).
 		From(postgres.ProductTable).
 		Where(sq.Eq{"id": id})
+	statement = postgres.NotDeleted(ctx, statement, "deleted_at")
 
 	query, args, err := statement.ToSql()
 	if err != nil {
@@ -222,7 +348,7 @@ func (repo *ProductDAO) GetProduct(ctx context.Context, id string) (model.Produc
 
 	rows, err := repo.client.Query(ctx, query, args...)
 	if err != nil {
-		err = psql.ErrDoQuery(err)
+		err = psql.ErrDoQuery(dal.FromPg(err))
 		tracing.Error(ctx, err)
 
 		return model.Products{}, err
@@ -230,31 +356,62 @@ func (repo *ProductDAO) GetProduct(ctx context.Context, id string) (model.Produc
 
 	defer rows.Close()
 
-	var e ProductStorage
-	if rows.Next() {
-		if err = rows.Scan(
-			&e.ID,
-			&e.Description,
-			&e.Quantity,
-			&e.CreatedAt,
-		); err != nil {
-			err = psql.ErrScan(psql.ParsePgError(err))
+	if !rows.Next() {
+		if err = rows.Err(); err != nil {
+			err = psql.ErrDoQuery(dal.FromPg(err))
 			tracing.Error(ctx, err)
 
 			return model.Products{}, err
 		}
+
+		return model.Products{}, dal.ErrNotFound
+	}
+
+	var e ProductStorage
+	if err = rows.Scan(
+		&e.ID,
+		&e.Description,
+		&e.Quantity,
+		&e.Price,
+		&e.Tags,
+		&e.CreatedAt,
+		&e.Version,
+		&e.DeletedAt,
+	); err != nil {
+		err = psql.ErrScan(dal.FromPg(err))
+		tracing.Error(ctx, err)
+
+		return model.Products{}, err
 	}
 
 	return e.ToDomain(), nil
 }
 
+// Update changes only the fields set in req and appends a product_history
+// row when the price differs from the one currently stored. Changes of stock
+// and price are written to the outbox in the same transaction.
+//
+// The update is conditioned on req.Version: dal.ErrVersionMismatch is returned
+// when the product was changed since the caller read it.
 func (repo *ProductDAO) Update(ctx context.Context, req model.UpdateProducts) error {
 	statement := repo.qb.
 		Update(postgres.ProductTable).
-		Set("description", req.Description).
-		Set("quantity", req.Quantity).
 		Set("updated_at", req.UpdatedAt).
-		Where(sq.Eq{"id": req.ID})
+		Set("version", sq.Expr("version + 1")).
+		Where(sq.Eq{"id": req.ID, "version": req.Version})
+
+	if req.Description != nil {
+		statement = statement.Set("description", *req.Description)
+	}
+	if req.Quantity != nil {
+		statement = statement.Set("quantity", *req.Quantity)
+	}
+	if req.Price != nil {
+		statement = statement.Set("price", *req.Price)
+	}
+	if req.Tags != nil {
+		statement = statement.Set("tags", model.NormalizeTags(*req.Tags))
+	}
 
 	query, args, err := statement.ToSql()
 	if err != nil {
@@ -270,25 +427,186 @@ func (repo *ProductDAO) Update(ctx context.Context, req model.UpdateProducts) er
 		tracing.TraceIVal(ctx, "arg-"+strconv.Itoa(i), arg)
 	}
 
-	cmd, execErr := repo.client.Exec(ctx, query, args...)
-	if execErr != nil {
-		execErr = psql.ErrDoQuery(execErr)
-		tracing.Error(ctx, execErr)
+	err = psql.WithTx(ctx, repo.client, pgx.TxOptions{}, func(tx pgx.Tx) error {
+		current, err := repo.lockProduct(ctx, tx, req.ID)
+		if err != nil {
+			return err
+		}
+
+		if current.Version != req.Version {
+			return dal.ErrVersionMismatch
+		}
+
+		cmd, execErr := tx.Exec(ctx, query, args...)
+		if execErr != nil {
+			return psql.ErrDoQuery(dal.FromPg(execErr))
+		}
+
+		if cmd.RowsAffected() == 0 {
+			return dal.ErrNotFound
+		}
+
+		before := current.ToDomain()
+		after := req.Apply(before)
+
+		if before.Price != after.Price {
+			if err = repo.insertHistory(ctx, tx, model.NewProductHistory(req.ID, after.Price, req.UpdatedAt)); err != nil {
+				return err
+			}
+		}
+
+		events, err := productEvents(req.ID, current, after.Quantity, after.Price, outboxmodel.StockReasonUpdated, req.UpdatedAt)
+		if err != nil {
+			return err
+		}
 
-		return execErr
+		if err = repo.outbox.Append(ctx, tx, events...); err != nil {
+			return err
+		}
+
+		entry, err := productEntry(auditmodel.ActionUpdate, req.ID, auditmodel.Changes(productState(before), productState(after)), req.UpdatedAt)
+		if err != nil {
+			return err
+		}
+
+		return repo.audit.Append(ctx, tx, entry)
+	})
+	if err != nil {
+		tracing.Error(ctx, err)
+
+		return err
 	}
 
-	if cmd.RowsAffected() == 0 {
-		return errors.New("nothing updated")
+	return nil
+}
+
+func (repo *ProductDAO) lockProduct(ctx context.Context, tx pgx.Tx, id string) (ProductStockStorage, error) {
+	query, args, err := repo.qb.
+		Select(
+			"description",
+			"quantity",
+			"price",
+			"tags",
+			"version",
+		).
+		From(postgres.ProductTable).
+		Where(sq.Eq{"id": id, "deleted_at": nil}).
+		Suffix("FOR UPDATE").
+		ToSql()
+	if err != nil {
+		return ProductStockStorage{}, psql.ErrCreateQuery(err)
+	}
+
+	tracing.SpanEvent(ctx, "Lock Product")
+	tracing.TraceVal(ctx, "SQL", query)
+
+	var e ProductStockStorage
+	if err = tx.QueryRow(ctx, query, args...).Scan(&e.Description, &e.Quantity, &e.Price, &e.Tags, &e.Version); err != nil {
+		return ProductStockStorage{}, psql.ErrScan(dal.FromPg(err))
+	}
+
+	return e, nil
+}
+
+func (repo *ProductDAO) insertHistory(ctx context.Context, tx pgx.Tx, history model.ProductHistory) error {
+	sql, args, err := repo.qb.
+		Insert(postgres.ProductHistoryTable).
+		Columns(
+			"product_id",
+			"price",
+			"timestamp",
+		).
+		Values(
+			history.ProductID,
+			history.Price,
+			history.Timestamp,
+		).ToSql()
+	if err != nil {
+		return psql.ErrCreateQuery(err)
+	}
+
+	tracing.SpanEvent(ctx, "Insert Product History query")
+	tracing.TraceVal(ctx, "sql", sql)
+
+	if _, err = tx.Exec(ctx, sql, args...); err != nil {
+		return psql.ErrDoQuery(dal.FromPg(err))
 	}
 
 	return nil
 }
 
-func (repo *ProductDAO) Delete(ctx context.Context, id string) error {
+func (repo *ProductDAO) PriceHistory(ctx context.Context, id string) ([]model.ProductHistory, error) {
+	statement := repo.qb.
+		Select(
+			"product_id",
+			"price",
+			"timestamp",
+		).
+		From(postgres.ProductHistoryTable).
+		Where(sq.Eq{"product_id": id}).
+		OrderBy("timestamp DESC")
+
+	query, args, err := statement.ToSql()
+	if err != nil {
+		err = psql.ErrCreateQuery(err)
+		tracing.Error(ctx, err)
+
+		return nil, err
+	}
+
+	tracing.SpanEvent(ctx, "Select Product History")
+	tracing.TraceVal(ctx, "SQL", query)
+	for i, arg := range args {
+		tracing.TraceIVal(ctx, "arg-"+strconv.Itoa(i), arg)
+	}
+
+	rows, err := repo.client.Query(ctx, query, args...)
+	if err != nil {
+		err = psql.ErrDoQuery(dal.FromPg(err))
+		tracing.Error(ctx, err)
+
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	history := make([]model.ProductHistory, 0)
+
+	for rows.Next() {
+		var e ProductHistoryStorage
+		if err = rows.Scan(
+			&e.ProductID,
+			&e.Price,
+			&e.Timestamp,
+		); err != nil {
+			err = psql.ErrScan(dal.FromPg(err))
+			tracing.Error(ctx, err)
+
+			return nil, err
+		}
+
+		history = append(history, e.ToDomain())
+	}
+
+	if err = rows.Err(); err != nil {
+		err = psql.ErrDoQuery(dal.FromPg(err))
+		tracing.Error(ctx, err)
+
+		return nil, err
+	}
+
+	return history, nil
+}
+
+// Delete marks the product as deleted at the given time. The row is kept,
+// so orders still reference it, until PurgeDeleted removes it.
+func (repo *ProductDAO) Delete(ctx context.Context, id string, at time.Time) error {
 	sql, args, err := repo.qb.
-		Delete(postgres.ProductTable).
-		Where(sq.Eq{"id": id}).
+		Update(postgres.ProductTable).
+		Set("deleted_at", at).
+		Set("updated_at", at).
+		Set("version", sq.Expr("version + 1")).
+		Where(sq.Eq{"id": id, "deleted_at": nil}).
 		ToSql()
 	if err != nil {
 		err = psql.ErrCreateQuery(err)
@@ -303,17 +621,357 @@ func (repo *ProductDAO) Delete(ctx context.Context, id string) error {
 		tracing.TraceIVal(ctx, "arg-"+strconv.Itoa(i), arg)
 	}
 
-	cmd, execErr := repo.client.Exec(ctx, sql, args...)
-	if execErr != nil {
-		execErr = psql.ErrDoQuery(execErr)
-		tracing.Error(ctx, execErr)
+	err = psql.WithTx(ctx, repo.client, pgx.TxOptions{}, func(tx pgx.Tx) error {
+		cmd, execErr := tx.Exec(ctx, sql, args...)
+		if execErr != nil {
+			return psql.ErrDoQuery(dal.FromPg(execErr))
+		}
+
+		if cmd.RowsAffected() == 0 {
+			return dal.ErrNotFound
+		}
+
+		entry, err := productEntry(auditmodel.ActionDelete, id, auditmodel.Diff{
+			"deleted_at": {From: nil, To: at},
+		}, at)
+		if err != nil {
+			return err
+		}
+
+		return repo.audit.Append(ctx, tx, entry)
+	})
+	if err != nil {
+		tracing.Error(ctx, err)
+
+		return err
+	}
+
+	return nil
+}
+
+// Restore clears the deletion mark of the product. Restoring a product that
+// isn't deleted changes nothing, dal.ErrNotFound is returned only when there
+// is no such product at all.
+func (repo *ProductDAO) Restore(ctx context.Context, id string, at time.Time) error {
+	lockSQL, lockArgs, err := repo.qb.
+		Select("deleted_at").
+		From(postgres.ProductTable).
+		Where(sq.Eq{"id": id}).
+		Suffix("FOR UPDATE").
+		ToSql()
+	if err != nil {
+		err = psql.ErrCreateQuery(err)
+		tracing.Error(ctx, err)
+
+		return err
+	}
+
+	sql, args, err := repo.qb.
+		Update(postgres.ProductTable).
+		Set("deleted_at", nil).
+		Set("updated_at", at).
+		Set("version", sq.Expr("version + 1")).
+		Where(sq.Eq{"id": id}).
+		ToSql()
+	if err != nil {
+		err = psql.ErrCreateQuery(err)
+		tracing.Error(ctx, err)
 
-		return execErr
+		return err
 	}
 
-	if cmd.RowsAffected() == 0 {
-		return errors.New("nothing deleted")
+	tracing.SpanEvent(ctx, "Restore Product")
+	tracing.TraceVal(ctx, "SQL", sql)
+	for i, arg := range args {
+		tracing.TraceIVal(ctx, "arg-"+strconv.Itoa(i), arg)
+	}
+
+	err = psql.WithTx(ctx, repo.client, pgx.TxOptions{}, func(tx pgx.Tx) error {
+		var deletedAt *time.Time
+		if err := tx.QueryRow(ctx, lockSQL, lockArgs...).Scan(&deletedAt); err != nil {
+			return psql.ErrScan(dal.FromPg(err))
+		}
+
+		if deletedAt == nil {
+			return nil
+		}
+
+		if _, err := tx.Exec(ctx, sql, args...); err != nil {
+			return psql.ErrDoQuery(dal.FromPg(err))
+		}
+
+		entry, err := productEntry(auditmodel.ActionRestore, id, auditmodel.Diff{
+			"deleted_at": {From: *deletedAt, To: nil},
+		}, at)
+		if err != nil {
+			return err
+		}
+
+		return repo.audit.Append(ctx, tx, entry)
+	})
+	if err != nil {
+		tracing.Error(ctx, err)
+
+		return err
 	}
 
 	return nil
 }
+
+// PurgeDeleted removes, at the given time, the products deleted before
+// before together with their price history. Products that orders still
+// reference are kept.
+func (repo *ProductDAO) PurgeDeleted(ctx context.Context, before, at time.Time) (int64, error) {
+	var purged int64
+	err := psql.WithTx(ctx, repo.client, pgx.TxOptions{}, func(tx pgx.Tx) error {
+		ids, err := repo.lockPurgeable(ctx, tx, before)
+		if err != nil || len(ids) == 0 {
+			return err
+		}
+
+		sql, args, err := repo.qb.
+			Delete(postgres.ProductHistoryTable).
+			Where(sq.Eq{"product_id": ids}).
+			ToSql()
+		if err != nil {
+			return psql.ErrCreateQuery(err)
+		}
+
+		tracing.SpanEvent(ctx, "Delete purged Products History query")
+		tracing.TraceVal(ctx, "sql", sql)
+
+		if _, err = tx.Exec(ctx, sql, args...); err != nil {
+			return psql.ErrDoQuery(dal.FromPg(err))
+		}
+
+		sql, args, err = repo.qb.
+			Delete(postgres.ProductTable).
+			Where(sq.Eq{"id": ids}).
+			ToSql()
+		if err != nil {
+			return psql.ErrCreateQuery(err)
+		}
+
+		tracing.SpanEvent(ctx, "Delete purged Products query")
+		tracing.TraceVal(ctx, "sql", sql)
+
+		cmd, err := tx.Exec(ctx, sql, args...)
+		if err != nil {
+			return psql.ErrDoQuery(dal.FromPg(err))
+		}
+
+		purged = cmd.RowsAffected()
+
+		entries := make([]auditmodel.Entry, 0, len(ids))
+		for _, id := range ids {
+			entry, err := productEntry(auditmodel.ActionPurge, id, auditmodel.Diff{}, at)
+			if err != nil {
+				return err
+			}
+
+			entries = append(entries, entry)
+		}
+
+		return repo.audit.Append(ctx, tx, entries...)
+	})
+	if err != nil {
+		tracing.Error(ctx, err)
+
+		return 0, err
+	}
+
+	return purged, nil
+}
+
+// lockPurgeable locks and returns the IDs of the products deleted before
+// before that no order references. Rows locked by another purge are skipped.
+func (repo *ProductDAO) lockPurgeable(ctx context.Context, tx pgx.Tx, before time.Time) ([]string, error) {
+	sql, args, err := repo.qb.
+		Select("p.id").
+		From(postgres.ProductTable + " p").
+		Where(sq.Lt{"p.deleted_at": before}).
+		Where("NOT EXISTS (SELECT 1 FROM " + postgres.OrderProductTable + " op WHERE op.product_id = p.id)").
+		Where("NOT EXISTS (SELECT 1 FROM " + postgres.UserOrderProductTable + " uop WHERE uop.product_id = p.id)").
+		Suffix("FOR UPDATE SKIP LOCKED").
+		ToSql()
+	if err != nil {
+		return nil, psql.ErrCreateQuery(err)
+	}
+
+	tracing.SpanEvent(ctx, "Lock purgeable Products query")
+	tracing.TraceVal(ctx, "sql", sql)
+	for i, arg := range args {
+		tracing.TraceIVal(ctx, "arg-"+strconv.Itoa(i), arg)
+	}
+
+	rows, err := tx.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, psql.ErrDoQuery(dal.FromPg(err))
+	}
+
+	ids, err := pgx.CollectRows(rows, pgx.RowTo[string])
+	if err != nil {
+		return nil, psql.ErrDoQuery(dal.FromPg(err))
+	}
+
+	return ids, nil
+}
+
+func (repo *ProductDAO) Tags(ctx context.Context) ([]model.TagCount, error) {
+	statement := repo.qb.
+		Select(
+			"tag",
+			"count(*)",
+		).
+		From(postgres.ProductTable+", unnest(tags) AS tag").
+		GroupBy("tag").
+		OrderBy("count(*) DESC", "tag")
+	statement = postgres.NotDeleted(ctx, statement, "deleted_at")
+
+	query, args, err := statement.ToSql()
+	if err != nil {
+		err = psql.ErrCreateQuery(err)
+		tracing.Error(ctx, err)
+
+		return nil, err
+	}
+
+	tracing.SpanEvent(ctx, "Select Product Tags")
+	tracing.TraceVal(ctx, "SQL", query)
+
+	rows, err := repo.client.Query(ctx, query, args...)
+	if err != nil {
+		err = psql.ErrDoQuery(dal.FromPg(err))
+		tracing.Error(ctx, err)
+
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	tags := make([]model.TagCount, 0)
+
+	for rows.Next() {
+		var e TagCountStorage
+		if err = rows.Scan(
+			&e.Tag,
+			&e.Count,
+		); err != nil {
+			err = psql.ErrScan(dal.FromPg(err))
+			tracing.Error(ctx, err)
+
+			return nil, err
+		}
+
+		tags = append(tags, e.ToDomain())
+	}
+
+	if err = rows.Err(); err != nil {
+		err = psql.ErrDoQuery(dal.FromPg(err))
+		tracing.Error(ctx, err)
+
+		return nil, err
+	}
+
+	return tags, nil
+}
+
+// headlineOptions highlights matches in the description with <b> tags.
+const headlineOptions = "StartSel=<b>, StopSel=</b>, MaxWords=35, MinWords=15, MaxFragments=2"
+
+// Search ranks products whose description or tags match every search term,
+// the last term is matched as a prefix.
+func (repo *ProductDAO) Search(ctx context.Context, search model.ProductSearch) ([]model.ProductSearchResult, error) {
+	tsQuery := prefixQuery(search.Terms)
+
+	statement := repo.qb.
+		Select(
+			"id",
+			"description",
+			"quantity",
+			"price",
+			"tags",
+			"created_at",
+			"version",
+			"deleted_at",
+		).
+		Column("ts_rank(search_vector, to_tsquery('simple', ?)) AS rank", tsQuery).
+		Column("ts_headline('simple', description, to_tsquery('simple', ?), ?) AS headline", tsQuery, headlineOptions).
+		From(postgres.ProductTable).
+		Where("search_vector @@ to_tsquery('simple', ?)", tsQuery).
+		OrderBy("rank DESC", "id").
+		Limit(uint64(search.Limit)).
+		Offset(uint64(search.Offset))
+	statement = postgres.NotDeleted(ctx, statement, "deleted_at")
+
+	query, args, err := statement.ToSql()
+	if err != nil {
+		err = psql.ErrCreateQuery(err)
+		tracing.Error(ctx, err)
+
+		return nil, err
+	}
+
+	tracing.SpanEvent(ctx, "Search Product")
+	tracing.TraceVal(ctx, "SQL", query)
+	for i, arg := range args {
+		tracing.TraceIVal(ctx, "arg-"+strconv.Itoa(i), arg)
+	}
+
+	rows, err := repo.client.Query(ctx, query, args...)
+	if err != nil {
+		err = psql.ErrDoQuery(dal.FromPg(err))
+		tracing.Error(ctx, err)
+
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	results := make([]model.ProductSearchResult, 0, search.Limit)
+
+	for rows.Next() {
+		var e ProductSearchStorage
+		if err = rows.Scan(
+			&e.ID,
+			&e.Description,
+			&e.Quantity,
+			&e.Price,
+			&e.Tags,
+			&e.CreatedAt,
+			&e.Version,
+			&e.DeletedAt,
+			&e.Rank,
+			&e.Headline,
+		); err != nil {
+			err = psql.ErrScan(dal.FromPg(err))
+			tracing.Error(ctx, err)
+
+			return nil, err
+		}
+
+		results = append(results, e.ToDomain())
+	}
+
+	if err = rows.Err(); err != nil {
+		err = psql.ErrDoQuery(dal.FromPg(err))
+		tracing.Error(ctx, err)
+
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// prefixQuery joins terms into a tsquery, e.g. "red & sho:*". Terms are
+// letters and digits only, see model.SearchTerms.
+func prefixQuery(terms []string) string {
+	parts := make([]string, len(terms))
+	copy(parts, terms)
+
+	if len(parts) > 0 {
+		parts[len(parts)-1] += ":*"
+	}
+
+	return strings.Join(parts, " & ")
+}
@@ -1,21 +1,30 @@
 package model
 
-import "time"
+import (
+	"github.com/Amore14rn/888Starz_test/pkg/common/core/pagination"
+	"strings"
+	"time"
+	"unicode"
+)
 
 type Products struct {
 	ID          string
 	Description string
 	Quantity    int
+	Price       float64
 	Tags        []string
 	CreatedAt   time.Time
 	UpdatedAt   *time.Time // Если есть поле "updated_at"
+	Version     int64      // Растёт на каждое изменение строки, отдаётся как ETag
+	DeletedAt   *time.Time // Продукт удалён, но строка ещё не очищена
 }
 
-func NewProduct(id, description string, quantity int, tags []string, createdAt time.Time, updatedAt *time.Time) Products {
+func NewProduct(id, description string, quantity int, price float64, tags []string, createdAt time.Time, updatedAt *time.Time) Products {
 	return Products{
 		ID:          id,
 		Description: description,
 		Quantity:    quantity,
+		Price:       price,
 		Tags:        tags,
 		CreatedAt:   createdAt,
 		UpdatedAt:   updatedAt,
@@ -26,15 +35,17 @@ type CreateProducts struct {
 	ID          string
 	Description string
 	Quantity    int
+	Price       float64
 	Tags        []string
 	CreatedAt   time.Time
 }
 
-func NewCreateProducts(id, description string, quantity int, tags []string, createdAt time.Time) CreateProducts {
+func NewCreateProducts(id, description string, quantity int, price float64, tags []string, createdAt time.Time) CreateProducts {
 	return CreateProducts{
 		ID:          id,
 		Description: description,
 		Quantity:    quantity,
+		Price:       price,
 		Tags:        tags,
 		CreatedAt:   createdAt,
 	}
@@ -46,41 +57,177 @@ type ProductHistory struct {
 	Timestamp time.Time
 }
 
-type Order struct {
-	ID        string
-	UserID    string
-	Products  []OrderProduct
-	Timestamp time.Time
-}
-
-type OrderProduct struct {
-	ProductID string
-	Quantity  int
-	Price     float64
-}
-
-func (o *Order) AddProduct(product OrderProduct) {
-	o.Products = append(o.Products, product)
+func NewProductHistory(productID string, price float64, timestamp time.Time) ProductHistory {
+	return ProductHistory{
+		ProductID: productID,
+		Price:     price,
+		Timestamp: timestamp,
+	}
 }
 
+// UpdateProducts is a partial update of a product: nil fields keep their
+// current value.
 type UpdateProducts struct {
 	ID          string
-	Description string
-	Quantity    int
-	Tags        []string
+	Description *string
+	Quantity    *int
+	Price       *float64
+	Tags        *[]string
 	UpdatedAt   time.Time
+	Version     int64 // Версия, которую видел клиент
 }
 
-func NewUpdateProducts(id,
-	description string,
-	quantity int,
-	tags []string,
-	updatedAt time.Time) UpdateProducts {
+func NewUpdateProducts(id string,
+	description *string,
+	quantity *int,
+	price *float64,
+	tags *[]string,
+	updatedAt time.Time,
+	version int64) UpdateProducts {
 	return UpdateProducts{
 		ID:          id,
 		Description: description,
 		Quantity:    quantity,
+		Price:       price,
 		Tags:        tags,
 		UpdatedAt:   updatedAt,
+		Version:     version,
+	}
+}
+
+// Apply returns product with the update merged in.
+func (u UpdateProducts) Apply(product Products) Products {
+	if u.Description != nil {
+		product.Description = *u.Description
+	}
+	if u.Quantity != nil {
+		product.Quantity = *u.Quantity
 	}
+	if u.Price != nil {
+		product.Price = *u.Price
+	}
+	if u.Tags != nil {
+		product.Tags = NormalizeTags(*u.Tags)
+	}
+
+	return product
+}
+
+// Validate checks the invariants of a product and returns the violated ones
+// by field, or nil.
+func (p Products) Validate() map[string]string {
+	fields := map[string]string{}
+
+	if strings.TrimSpace(p.Description) == "" {
+		fields["description"] = "must not be empty"
+	}
+	if p.Quantity < 0 {
+		fields["quantity"] = "must be greater than or equal to 0"
+	}
+	if p.Price < 0 {
+		fields["price"] = "must be greater than or equal to 0"
+	}
+
+	if len(fields) == 0 {
+		return nil
+	}
+
+	return fields
+}
+
+// TagMatch defines how a product's tags are matched against a filter.
+type TagMatch string
+
+const (
+	TagMatchAny TagMatch = "any"
+	TagMatchAll TagMatch = "all"
+)
+
+type ProductFilter struct {
+	Tags         []string
+	TagMatch     TagMatch
+	MinQuantity  *int
+	MaxQuantity  *int
+	CreatedAfter *time.Time
+	Page         pagination.Params
+}
+
+func NewProductFilter(tags []string, match TagMatch, minQuantity, maxQuantity *int, createdAfter *time.Time, page pagination.Params) ProductFilter {
+	if match == "" {
+		match = TagMatchAny
+	}
+
+	return ProductFilter{
+		Tags:         NormalizeTags(tags),
+		TagMatch:     match,
+		MinQuantity:  minQuantity,
+		MaxQuantity:  maxQuantity,
+		CreatedAfter: createdAfter,
+		Page:         page,
+	}
+}
+
+type TagCount struct {
+	Tag   string
+	Count int
+}
+
+// NormalizeTags trims tags and drops empty and repeated ones, keeping the
+// original order. It never returns nil so the value can be stored as-is.
+func NormalizeTags(tags []string) []string {
+	seen := make(map[string]struct{}, len(tags))
+	normalized := make([]string, 0, len(tags))
+
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" {
+			continue
+		}
+
+		if _, ok := seen[tag]; ok {
+			continue
+		}
+
+		seen[tag] = struct{}{}
+		normalized = append(normalized, tag)
+	}
+
+	return normalized
+}
+
+type ProductSearch struct {
+	Terms  []string
+	Limit  int
+	Offset int
+}
+
+// NewProductSearch splits query into search terms, the last one is matched
+// as a prefix so the search works for typeahead.
+func NewProductSearch(query string, limit, offset int) ProductSearch {
+	if limit <= 0 {
+		limit = pagination.DefaultLimit
+	}
+	if limit > pagination.MaxLimit {
+		limit = pagination.MaxLimit
+	}
+
+	return ProductSearch{
+		Terms:  SearchTerms(query),
+		Limit:  limit,
+		Offset: offset,
+	}
+}
+
+// SearchTerms lowercases query and keeps only runs of letters and digits,
+// so tsquery operators typed by the user are never passed to Postgres.
+func SearchTerms(query string) []string {
+	return strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+type ProductSearchResult struct {
+	Product  Products
+	Rank     float32
+	Headline string
 }
@@ -4,14 +4,20 @@ import (
 	"context"
 	"github.com/Amore14rn/888Starz_test/internal/domain/products/model"
 	"github.com/Amore14rn/888Starz_test/pkg/errors"
+	"time"
 )
 
 type repository interface {
-	All(ctx context.Context) ([]model.Products, error)
+	All(ctx context.Context, filter model.ProductFilter) ([]model.Products, string, error)
 	Create(ctx context.Context, req model.CreateProducts) (model.Products, error)
 	GetProduct(ctx context.Context, id string) (model.Products, error)
 	Update(ctx context.Context, req model.UpdateProducts) error
-	Delete(ctx context.Context, id string) error
+	Delete(ctx context.Context, id string, at time.Time) error
+	Restore(ctx context.Context, id string, at time.Time) error
+	PurgeDeleted(ctx context.Context, before, at time.Time) (int64, error)
+	PriceHistory(ctx context.Context, id string) ([]model.ProductHistory, error)
+	Tags(ctx context.Context) ([]model.TagCount, error)
+	Search(ctx context.Context, search model.ProductSearch) ([]model.ProductSearchResult, error)
 }
 
 type ProductService struct {
@@ -24,13 +30,13 @@ func NewProductService(repository repository) *ProductService {
 	}
 }
 
-func (s *ProductService) All(ctx context.Context) ([]model.Products, error) {
-	products, err := s.repository.All(ctx)
+func (s *ProductService) All(ctx context.Context, filter model.ProductFilter) ([]model.Products, string, error) {
+	products, next, err := s.repository.All(ctx, filter)
 	if err != nil {
-		return nil, errors.Wrap(err, "repository.All")
+		return nil, "", errors.Wrap(err, "repository.All")
 	}
 
-	return products, nil
+	return products, next, nil
 }
 
 func (s *ProductService) CreateProduct(ctx context.Context, req model.CreateProducts) (model.Products, error) {
@@ -59,10 +65,54 @@ func (s *ProductService) UpdateProduct(ctx context.Context, req model.UpdateProd
 	return nil
 }
 
-func (s *ProductService) DeleteProduct(ctx context.Context, id string) error {
-	if err := s.repository.Delete(ctx, id); err != nil {
+func (s *ProductService) DeleteProduct(ctx context.Context, id string, at time.Time) error {
+	if err := s.repository.Delete(ctx, id, at); err != nil {
 		return errors.Wrap(err, "repository.DeleteProduct")
 	}
 
 	return nil
 }
+
+func (s *ProductService) RestoreProduct(ctx context.Context, id string, at time.Time) error {
+	if err := s.repository.Restore(ctx, id, at); err != nil {
+		return errors.Wrap(err, "repository.RestoreProduct")
+	}
+
+	return nil
+}
+
+func (s *ProductService) PurgeDeleted(ctx context.Context, before, at time.Time) (int64, error) {
+	purged, err := s.repository.PurgeDeleted(ctx, before, at)
+	if err != nil {
+		return 0, errors.Wrap(err, "repository.PurgeDeleted")
+	}
+
+	return purged, nil
+}
+
+func (s *ProductService) PriceHistory(ctx context.Context, id string) ([]model.ProductHistory, error) {
+	history, err := s.repository.PriceHistory(ctx, id)
+	if err != nil {
+		return nil, errors.Wrap(err, "repository.PriceHistory")
+	}
+
+	return history, nil
+}
+
+func (s *ProductService) Tags(ctx context.Context) ([]model.TagCount, error) {
+	tags, err := s.repository.Tags(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "repository.Tags")
+	}
+
+	return tags, nil
+}
+
+func (s *ProductService) Search(ctx context.Context, search model.ProductSearch) ([]model.ProductSearchResult, error) {
+	results, err := s.repository.Search(ctx, search)
+	if err != nil {
+		return nil, errors.Wrap(err, "repository.Search")
+	}
+
+	return results, nil
+}
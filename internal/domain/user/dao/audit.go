@@ -0,0 +1,27 @@
+package dao
+
+import (
+	auditmodel "github.com/Amore14rn/888Starz_test/internal/domain/audit/model"
+	"github.com/Amore14rn/888Starz_test/internal/domain/user/model"
+	"time"
+)
+
+// userState is what the audit log records of a user, the password hash is
+// never part of it.
+func userState(u model.User) map[string]any {
+	return map[string]any{
+		"first_name": u.FirstName,
+		"last_name":  u.LastName,
+		"full_name":  u.FullName,
+		"age":        u.Age,
+		"is_married": u.IsMarried,
+		"role":       string(u.Role),
+	}
+}
+
+// passwordChange records that the password changed without its hashes.
+var passwordChange = auditmodel.Change{From: auditmodel.Redacted, To: auditmodel.Redacted}
+
+func userEntry(action auditmodel.Action, id string, diff auditmodel.Diff, at time.Time) (auditmodel.Entry, error) {
+	return auditmodel.NewEntry(action, auditmodel.EntityUser, id, diff, at)
+}
@@ -2,40 +2,56 @@ package dao
 
 import (
 	"context"
-	"encoding/json"
+	"github.com/Amore14rn/888Starz_test/internal/dal"
 	"github.com/Amore14rn/888Starz_test/internal/dal/postgres"
+	auditmodel "github.com/Amore14rn/888Starz_test/internal/domain/audit/model"
 	"github.com/Amore14rn/888Starz_test/internal/domain/user/model"
-	"github.com/Amore14rn/888Starz_test/pkg/errors"
+	"github.com/Amore14rn/888Starz_test/pkg/common/auth"
+	"github.com/Amore14rn/888Starz_test/pkg/common/core/pagination"
 	psql "github.com/Amore14rn/888Starz_test/pkg/postgresql"
 	"github.com/Amore14rn/888Starz_test/pkg/tracing"
 	sq "github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
 	"strconv"
+	"time"
 )
 
+type audit interface {
+	Append(ctx context.Context, tx pgx.Tx, entries ...auditmodel.Entry) error
+}
+
 type UserDAO struct {
 	qb     sq.StatementBuilderType
 	client psql.Client
+	audit  audit
 }
 
-func NewUserStorage(client psql.Client) *UserDAO {
+func NewUserStorage(client psql.Client, audit audit) *UserDAO {
 	return &UserDAO{
 		qb:     sq.StatementBuilder.PlaceholderFormat(sq.Dollar),
 		client: client,
+		audit:  audit,
 	}
 }
 
-func (repo *UserDAO) All(ctx context.Context) ([]model.User, error) {
-	all, err := repo.findBy(ctx)
+// All returns one page of users matching filter together with the cursor
+// of the next page, which is empty on the last page.
+func (repo *UserDAO) All(ctx context.Context, filter model.UserFilter) ([]model.User, string, error) {
+	all, err := repo.findBy(ctx, filter)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
+	all, next := postgres.NextCursor(all, filter.Page, func(e UserStorage) pagination.Cursor {
+		return pagination.Cursor{CreatedAt: e.CreatedAt, ID: e.ID}
+	})
+
 	resp := make([]model.User, len(all))
 	for i, e := range all {
 		resp[i] = e.ToDomain()
 	}
 
-	return resp, nil
+	return resp, next, nil
 }
 
 func (u *UserDAO) Create(ctx context.Context, req model.CreateUser) error {
@@ -49,6 +65,8 @@ func (u *UserDAO) Create(ctx context.Context, req model.CreateUser) error {
 			"age",
 			"is_married",
 			"password",
+			"role",
+			"created_at",
 		).
 		Values(
 			req.ID,
@@ -57,7 +75,9 @@ func (u *UserDAO) Create(ctx context.Context, req model.CreateUser) error {
 			req.FullName,
 			req.Age,
 			req.IsMarried,
-			req.Password,
+			tracing.Secret(req.PasswordHash),
+			string(req.Role),
+			req.CreatedAt,
 		).ToSql()
 	if err != nil {
 		err = psql.ErrCreateQuery(err)
@@ -71,34 +91,77 @@ func (u *UserDAO) Create(ctx context.Context, req model.CreateUser) error {
 		tracing.TraceIVal(ctx, "arg-"+strconv.Itoa(i), arg)
 	}
 
-	cmd, execErr := u.client.Exec(ctx, sql, args...)
-	if execErr != nil {
-		execErr = psql.ErrDoQuery(execErr)
-		tracing.Error(ctx, execErr)
+	err = psql.WithTx(ctx, u.client, pgx.TxOptions{}, func(tx pgx.Tx) error {
+		cmd, execErr := tx.Exec(ctx, sql, args...)
+		if execErr != nil {
+			return psql.ErrDoQuery(dal.FromPg(execErr))
+		}
 
-		return execErr
-	}
+		if cmd.RowsAffected() == 0 {
+			return dal.ErrNothingInserted
+		}
 
-	if cmd.RowsAffected() == 0 {
-		return errors.New("nothing inserted")
+		entry, err := userEntry(auditmodel.ActionCreate, req.ID, auditmodel.Changes(nil, userState(model.User{
+			FirstName: req.FirstName,
+			LastName:  req.LastName,
+			FullName:  req.FullName,
+			Age:       req.Age,
+			IsMarried: req.IsMarried,
+			Role:      req.Role,
+		})), req.CreatedAt)
+		if err != nil {
+			return err
+		}
+
+		return u.audit.Append(ctx, tx, entry)
+	})
+	if err != nil {
+		tracing.Error(ctx, err)
+
+		return err
 	}
 
 	return nil
 }
 
-func (repo *UserDAO) findBy(ctx context.Context) ([]UserStorage, error) {
+func (repo *UserDAO) findBy(ctx context.Context, filter model.UserFilter) ([]UserStorage, error) {
 	statement := repo.qb.
 		Select(
 			"id",
 			"first_name",
 			"last_name",
+			"full_name",
 			"age",
 			"is_married",
 			"password",
+			"role",
 			"created_at",
 			"updated_at",
+			"version",
+			"deleted_at",
 		).
 		From(postgres.UserTable + " u")
+	statement = postgres.NotDeleted(ctx, statement, "deleted_at")
+
+	if filter.IsMarried != nil {
+		statement = statement.Where(sq.Eq{"is_married": *filter.IsMarried})
+	}
+	if filter.MinAge != nil {
+		statement = statement.Where(sq.GtOrEq{"age": *filter.MinAge})
+	}
+	if filter.MaxAge != nil {
+		statement = statement.Where(sq.LtOrEq{"age": *filter.MaxAge})
+	}
+	if filter.CreatedAfter != nil {
+		statement = statement.Where(sq.Gt{"created_at": *filter.CreatedAfter})
+	}
+
+	statement, err := postgres.Paginate(statement, filter.Page)
+	if err != nil {
+		tracing.Error(ctx, err)
+
+		return nil, err
+	}
 
 	query, args, err := statement.ToSql()
 	if err != nil {
@@ -116,7 +179,7 @@ func (repo *UserDAO) findBy(ctx context.Context) ([]UserStorage, error) {
 
 	rows, err := repo.client.Query(ctx, query, args...)
 	if err != nil {
-		err = psql.ErrDoQuery(err)
+		err = psql.ErrDoQuery(dal.FromPg(err))
 		tracing.Error(ctx, err)
 
 		return nil, err
@@ -124,7 +187,7 @@ func (repo *UserDAO) findBy(ctx context.Context) ([]UserStorage, error) {
 
 	defer rows.Close()
 
-	entities := make([]UserStorage, rows.CommandTag().RowsAffected())
+	entities := make([]UserStorage, 0, filter.Page.Limit+1)
 
 	for rows.Next() {
 		var e UserStorage
@@ -135,11 +198,14 @@ func (repo *UserDAO) findBy(ctx context.Context) ([]UserStorage, error) {
 			&e.FullName,
 			&e.Age,
 			&e.IsMarried,
-			&e.Password,
+			&e.PasswordHash,
+			&e.Role,
 			&e.CreatedAt,
-			&e.Orders,
+			&e.UpdateAt,
+			&e.Version,
+			&e.DeletedAt,
 		); err != nil {
-			err = psql.ErrScan(psql.ParsePgError(err))
+			err = psql.ErrScan(dal.FromPg(err))
 			tracing.Error(ctx, err)
 
 			return nil, err
@@ -148,6 +214,13 @@ func (repo *UserDAO) findBy(ctx context.Context) ([]UserStorage, error) {
 		entities = append(entities, e)
 	}
 
+	if err = rows.Err(); err != nil {
+		err = psql.ErrDoQuery(dal.FromPg(err))
+		tracing.Error(ctx, err)
+
+		return nil, err
+	}
+
 	return entities, nil
 }
 
@@ -166,14 +239,19 @@ func (u *UserDAO) findByID(ctx context.Context, id string) (UserStorage, error)
 			"id",
 			"first_name",
 			"last_name",
+			"full_name",
 			"age",
 			"is_married",
 			"password",
+			"role",
 			"created_at",
 			"updated_at",
+			"version",
+			"deleted_at",
 		).
 		From(postgres.UserTable + " u").
 		Where(sq.Eq{"id": id})
+	statement = postgres.NotDeleted(ctx, statement, "deleted_at")
 
 	query, args, err := statement.ToSql()
 	if err != nil {
@@ -199,11 +277,14 @@ func (u *UserDAO) findByID(ctx context.Context, id string) (UserStorage, error)
 		&e.FullName,
 		&e.Age,
 		&e.IsMarried,
-		&e.Password,
+		&e.PasswordHash,
+		&e.Role,
 		&e.CreatedAt,
-		&e.Orders,
+		&e.UpdateAt,
+		&e.Version,
+		&e.DeletedAt,
 	); err != nil {
-		err = psql.ErrScan(psql.ParsePgError(err))
+		err = psql.ErrScan(dal.FromPg(err))
 		tracing.Error(ctx, err)
 
 		return UserStorage{}, err
@@ -227,14 +308,19 @@ func (u *UserDAO) findByName(ctx context.Context, name string) (UserStorage, err
 			"id",
 			"first_name",
 			"last_name",
+			"full_name",
 			"age",
 			"is_married",
 			"password",
+			"role",
 			"created_at",
 			"updated_at",
+			"version",
+			"deleted_at",
 		).
 		From(postgres.UserTable + " u").
 		Where(sq.Eq{"first_name": name})
+	statement = postgres.NotDeleted(ctx, statement, "deleted_at")
 
 	query, args, err := statement.ToSql()
 	if err != nil {
@@ -260,11 +346,14 @@ func (u *UserDAO) findByName(ctx context.Context, name string) (UserStorage, err
 		&e.FullName,
 		&e.Age,
 		&e.IsMarried,
-		&e.Password,
+		&e.PasswordHash,
+		&e.Role,
 		&e.CreatedAt,
-		&e.Orders,
+		&e.UpdateAt,
+		&e.Version,
+		&e.DeletedAt,
 	); err != nil {
-		err = psql.ErrScan(psql.ParsePgError(err))
+		err = psql.ErrScan(dal.FromPg(err))
 		tracing.Error(ctx, err)
 
 		return UserStorage{}, err
@@ -273,18 +362,36 @@ func (u *UserDAO) findByName(ctx context.Context, name string) (UserStorage, err
 	return e, nil
 }
 
+// Update changes only the fields set in req if the user still has
+// req.Version, otherwise it returns dal.ErrVersionMismatch (or
+// dal.ErrNotFound when there is no user).
 func (u *UserDAO) Update(ctx context.Context, req model.UpdateUser) error {
-	sql, args, err := u.qb.
+	statement := u.qb.
 		Update(postgres.UserTable).
-		Set("first_name", req.FirstName).
-		Set("last_name", req.LastName).
-		Set("full_name", req.FullName).
-		Set("age", req.Age).
-		Set("is_married", req.IsMarried).
-		Set("password", req.Password).
 		Set("updated_at", req.UpdatedAt).
-		Where(sq.Eq{"id": req.ID}).
-		ToSql()
+		Set("version", sq.Expr("version + 1")).
+		Where(sq.Eq{"id": req.ID, "version": req.Version})
+
+	if req.FirstName != nil {
+		statement = statement.Set("first_name", *req.FirstName)
+	}
+	if req.LastName != nil {
+		statement = statement.Set("last_name", *req.LastName)
+	}
+	if req.FullName != nil {
+		statement = statement.Set("full_name", *req.FullName)
+	}
+	if req.Age != nil {
+		statement = statement.Set("age", *req.Age)
+	}
+	if req.IsMarried != nil {
+		statement = statement.Set("is_married", *req.IsMarried)
+	}
+	if req.PasswordHash != nil {
+		statement = statement.Set("password", tracing.Secret(*req.PasswordHash))
+	}
+
+	sql, args, err := statement.ToSql()
 	if err != nil {
 		err = psql.ErrCreateQuery(err)
 		tracing.Error(ctx, err)
@@ -297,25 +404,95 @@ func (u *UserDAO) Update(ctx context.Context, req model.UpdateUser) error {
 		tracing.TraceIVal(ctx, "arg-"+strconv.Itoa(i), arg)
 	}
 
-	cmd, execErr := u.client.Exec(ctx, sql, args...)
-	if execErr != nil {
-		execErr = psql.ErrDoQuery(execErr)
-		tracing.Error(ctx, execErr)
+	err = psql.WithTx(ctx, u.client, pgx.TxOptions{}, func(tx pgx.Tx) error {
+		current, err := u.lockUser(ctx, tx, req.ID)
+		if err != nil {
+			return err
+		}
+
+		if current.Version != req.Version {
+			return dal.ErrVersionMismatch
+		}
 
-		return execErr
-	}
+		if _, err = tx.Exec(ctx, sql, args...); err != nil {
+			return psql.ErrDoQuery(dal.FromPg(err))
+		}
+
+		before := current.ToDomain()
+		after := req.Apply(before)
+		if req.FullName == nil {
+			after.FullName = before.FullName
+		} else {
+			after.FullName = *req.FullName
+		}
+
+		diff := auditmodel.Changes(userState(before), userState(after))
+		if req.PasswordHash != nil {
+			diff["password"] = passwordChange
+		}
 
-	if cmd.RowsAffected() == 0 {
-		return errors.New("nothing updated")
+		entry, err := userEntry(auditmodel.ActionUpdate, req.ID, diff, req.UpdatedAt)
+		if err != nil {
+			return err
+		}
+
+		return u.audit.Append(ctx, tx, entry)
+	})
+	if err != nil {
+		tracing.Error(ctx, err)
+
+		return err
 	}
 
 	return nil
 }
 
-func (u *UserDAO) Delete(ctx context.Context, id string) error {
+// lockUser locks a user that isn't deleted for update.
+func (u *UserDAO) lockUser(ctx context.Context, tx pgx.Tx, id string) (UserStorage, error) {
+	query, args, err := u.qb.
+		Select(
+			"first_name",
+			"last_name",
+			"full_name",
+			"age",
+			"is_married",
+			"role",
+			"version",
+		).
+		From(postgres.UserTable).
+		Where(sq.Eq{"id": id, "deleted_at": nil}).
+		Suffix("FOR UPDATE").
+		ToSql()
+	if err != nil {
+		return UserStorage{}, psql.ErrCreateQuery(err)
+	}
+
+	tracing.SpanEvent(ctx, "Lock User")
+	tracing.TraceVal(ctx, "SQL", query)
+
+	e := UserStorage{ID: id}
+	if err = tx.QueryRow(ctx, query, args...).Scan(
+		&e.FirstName,
+		&e.LastName,
+		&e.FullName,
+		&e.Age,
+		&e.IsMarried,
+		&e.Role,
+		&e.Version,
+	); err != nil {
+		return UserStorage{}, psql.ErrScan(dal.FromPg(err))
+	}
+
+	return e, nil
+}
+
+func (u *UserDAO) UpdatePassword(ctx context.Context, id, passwordHash string, updatedAt time.Time) error {
 	sql, args, err := u.qb.
-		Delete(postgres.UserTable).
-		Where(sq.Eq{"id": id}).
+		Update(postgres.UserTable).
+		Set("password", tracing.Secret(passwordHash)).
+		Set("updated_at", updatedAt).
+		Set("version", sq.Expr("version + 1")).
+		Where(sq.Eq{"id": id, "deleted_at": nil}).
 		ToSql()
 	if err != nil {
 		err = psql.ErrCreateQuery(err)
@@ -323,76 +500,190 @@ func (u *UserDAO) Delete(ctx context.Context, id string) error {
 
 		return err
 	}
-	tracing.SpanEvent(ctx, "Delete User query")
+	tracing.SpanEvent(ctx, "Update User password query")
 	tracing.TraceVal(ctx, "sql", sql)
 	for i, arg := range args {
 		tracing.TraceIVal(ctx, "arg-"+strconv.Itoa(i), arg)
 	}
 
-	cmd, execErr := u.client.Exec(ctx, sql, args...)
-	if execErr != nil {
-		execErr = psql.ErrDoQuery(execErr)
-		tracing.Error(ctx, execErr)
+	err = psql.WithTx(ctx, u.client, pgx.TxOptions{}, func(tx pgx.Tx) error {
+		cmd, execErr := tx.Exec(ctx, sql, args...)
+		if execErr != nil {
+			return psql.ErrDoQuery(dal.FromPg(execErr))
+		}
 
-		return execErr
-	}
+		if cmd.RowsAffected() == 0 {
+			return dal.ErrNotFound
+		}
+
+		entry, err := userEntry(auditmodel.ActionUpdate, id, auditmodel.Diff{"password": passwordChange}, updatedAt)
+		if err != nil {
+			return err
+		}
+
+		return u.audit.Append(ctx, tx, entry)
+	})
+	if err != nil {
+		tracing.Error(ctx, err)
 
-	if cmd.RowsAffected() == 0 {
-		return errors.New("nothing deleted")
+		return err
 	}
 
 	return nil
 }
 
-func (u *UserDAO) CreateOrder(ctx context.Context, req model.CreateOrder) error {
-	productsJSON, err := json.Marshal(req.Products)
+func (u *UserDAO) UpdateRole(ctx context.Context, id string, role auth.Role, updatedAt time.Time) error {
+	sql, args, err := u.qb.
+		Update(postgres.UserTable).
+		Set("role", string(role)).
+		Set("updated_at", updatedAt).
+		Set("version", sq.Expr("version + 1")).
+		Where(sq.Eq{"id": id}).
+		ToSql()
 	if err != nil {
+		err = psql.ErrCreateQuery(err)
+		tracing.Error(ctx, err)
+
 		return err
 	}
+	tracing.SpanEvent(ctx, "Update User role query")
+	tracing.TraceVal(ctx, "sql", sql)
+	for i, arg := range args {
+		tracing.TraceIVal(ctx, "arg-"+strconv.Itoa(i), arg)
+	}
 
-	sql, args, err := sq.
-		Insert(postgres.OrderTable).
-		Columns(
-			"id",
-			"user_id",
-			"product_id",
-			"products",
-			"time_stamp",
-		).
-		Values(
-			req.ID,
-			req.UserID,
-			req.ProductID,
-			string(productsJSON),
-			req.TimeStamp,
-		).ToSql()
+	err = psql.WithTx(ctx, u.client, pgx.TxOptions{}, func(tx pgx.Tx) error {
+		current, err := u.lockUser(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+
+		if _, err = tx.Exec(ctx, sql, args...); err != nil {
+			return psql.ErrDoQuery(dal.FromPg(err))
+		}
+
+		entry, err := userEntry(auditmodel.ActionUpdate, id, auditmodel.Changes(
+			map[string]any{"role": current.Role},
+			map[string]any{"role": string(role)},
+		), updatedAt)
+		if err != nil {
+			return err
+		}
+
+		return u.audit.Append(ctx, tx, entry)
+	})
+	if err != nil {
+		tracing.Error(ctx, err)
+
+		return err
+	}
+
+	return nil
+}
+
+// Delete marks the user as deleted at the given time. The row is kept, so
+// orders still reference it, until PurgeDeleted removes it.
+func (u *UserDAO) Delete(ctx context.Context, id string, at time.Time) error {
+	sql, args, err := u.qb.
+		Update(postgres.UserTable).
+		Set("deleted_at", at).
+		Set("updated_at", at).
+		Set("version", sq.Expr("version + 1")).
+		Where(sq.Eq{"id": id, "deleted_at": nil}).
+		ToSql()
 	if err != nil {
 		err = psql.ErrCreateQuery(err)
 		tracing.Error(ctx, err)
 
 		return err
 	}
-	tracing.SpanEvent(ctx, "Insert Order query")
+	tracing.SpanEvent(ctx, "Delete User query")
 	tracing.TraceVal(ctx, "sql", sql)
 	for i, arg := range args {
 		tracing.TraceIVal(ctx, "arg-"+strconv.Itoa(i), arg)
 	}
 
-	cmd, execErr := u.client.Exec(ctx, sql, args...)
-	if execErr != nil {
-		execErr = psql.ErrDoQuery(execErr)
-		tracing.Error(ctx, execErr)
+	err = psql.WithTx(ctx, u.client, pgx.TxOptions{}, func(tx pgx.Tx) error {
+		cmd, execErr := tx.Exec(ctx, sql, args...)
+		if execErr != nil {
+			return psql.ErrDoQuery(dal.FromPg(execErr))
+		}
 
-		return execErr
-	}
+		if cmd.RowsAffected() == 0 {
+			return dal.ErrNotFound
+		}
 
-	if cmd.RowsAffected() == 0 {
-		return errors.New("nothing inserted")
+		entry, err := userEntry(auditmodel.ActionDelete, id, auditmodel.Diff{
+			"deleted_at": {From: nil, To: at},
+		}, at)
+		if err != nil {
+			return err
+		}
+
+		return u.audit.Append(ctx, tx, entry)
+	})
+	if err != nil {
+		tracing.Error(ctx, err)
+
+		return err
 	}
 
 	return nil
 }
 
-func (u *UserDAO) AreProductsAvailable(ctx context.Context, productID string, products []model.OrderProduct) bool {
-	return true
+// PurgeDeleted removes, at the given time, the users deleted before before,
+// their carts go with them. Users that orders still reference are kept.
+func (u *UserDAO) PurgeDeleted(ctx context.Context, before, at time.Time) (int64, error) {
+	sql, args, err := u.qb.
+		Delete(postgres.UserTable + " u").
+		Where(sq.Lt{"u.deleted_at": before}).
+		Where("NOT EXISTS (SELECT 1 FROM " + postgres.OrderTable + " o WHERE o.user_id = u.id)").
+		Where("NOT EXISTS (SELECT 1 FROM " + postgres.UserOrderProductTable + " uop WHERE uop.user_id = u.id)").
+		Suffix("RETURNING u.id").
+		ToSql()
+	if err != nil {
+		err = psql.ErrCreateQuery(err)
+		tracing.Error(ctx, err)
+
+		return 0, err
+	}
+	tracing.SpanEvent(ctx, "Purge deleted Users query")
+	tracing.TraceVal(ctx, "sql", sql)
+	for i, arg := range args {
+		tracing.TraceIVal(ctx, "arg-"+strconv.Itoa(i), arg)
+	}
+
+	var purged int64
+	err = psql.WithTx(ctx, u.client, pgx.TxOptions{}, func(tx pgx.Tx) error {
+		rows, queryErr := tx.Query(ctx, sql, args...)
+		if queryErr != nil {
+			return psql.ErrDoQuery(dal.FromPg(queryErr))
+		}
+
+		ids, queryErr := pgx.CollectRows(rows, pgx.RowTo[string])
+		if queryErr != nil {
+			return psql.ErrDoQuery(dal.FromPg(queryErr))
+		}
+
+		purged = int64(len(ids))
+
+		entries := make([]auditmodel.Entry, 0, len(ids))
+		for _, id := range ids {
+			entry, err := userEntry(auditmodel.ActionPurge, id, auditmodel.Diff{}, at)
+			if err != nil {
+				return err
+			}
+
+			entries = append(entries, entry)
+		}
+
+		return u.audit.Append(ctx, tx, entries...)
+	})
+	if err != nil {
+		tracing.Error(ctx, err)
+
+		return 0, err
+	}
+
+	return purged, nil
 }
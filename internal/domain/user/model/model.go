@@ -1,20 +1,28 @@
 package model
 
 import (
+	"github.com/Amore14rn/888Starz_test/pkg/common/auth"
+	"github.com/Amore14rn/888Starz_test/pkg/common/core/pagination"
+	"strconv"
 	"time"
 )
 
+// MinAge is the youngest age a user may have.
+const MinAge = 18
+
 type User struct {
-	ID        string
-	FirstName string
-	LastName  string
-	FullName  string
-	Age       uint32
-	IsMarried bool
-	Password  string
-	Orders    []Order
-	CreatedAt time.Time
-	UpdatedAt *time.Time
+	ID           string
+	FirstName    string
+	LastName     string
+	FullName     string
+	Age          uint32
+	IsMarried    bool
+	PasswordHash string `json:"-" trace:"-"`
+	Role         auth.Role
+	CreatedAt    time.Time
+	UpdatedAt    *time.Time
+	Version      int64      // Incremented on every change, exposed as the ETag
+	DeletedAt    *time.Time // Set while the user is soft deleted
 }
 
 func NewUser(
@@ -23,54 +31,34 @@ func NewUser(
 	lastName string,
 	age uint32,
 	isMarried bool,
-	password string,
-	orders Order,
+	passwordHash string,
 	createdAt time.Time,
 	updatedAt *time.Time,
 ) User {
 	fullName := firstName + " " + lastName
 	return User{
-		ID:        ID,
-		FirstName: firstName,
-		LastName:  lastName,
-		FullName:  fullName,
-		Age:       age,
-		IsMarried: isMarried,
-		Password:  password,
-		Orders:    []Order{orders},
-		CreatedAt: createdAt,
-		UpdatedAt: updatedAt,
+		ID:           ID,
+		FirstName:    firstName,
+		LastName:     lastName,
+		FullName:     fullName,
+		Age:          age,
+		IsMarried:    isMarried,
+		PasswordHash: passwordHash,
+		CreatedAt:    createdAt,
+		UpdatedAt:    updatedAt,
 	}
 }
 
-type Order struct {
-	ID        string
-	UserID    string
-	ProductID string
-	Products  []OrderProduct
-	Timestamp time.Time
-}
-
-type OrderProduct struct {
-	ProductID string
-	Quantity  int
-	Price     float64
-}
-
-func (u *User) AddOrder(order Order) {
-	u.Orders = append(u.Orders, order)
-}
-
 type CreateUser struct {
-	ID        string
-	FirstName string
-	LastName  string
-	FullName  string
-	Age       uint32
-	IsMarried bool
-	Password  string
-	Order     Order
-	CreatedAt time.Time
+	ID           string
+	FirstName    string
+	LastName     string
+	FullName     string
+	Age          uint32
+	IsMarried    bool
+	PasswordHash string `json:"-" trace:"-"`
+	Role         auth.Role
+	CreatedAt    time.Time
 }
 
 func NewCreateUser(
@@ -79,91 +67,118 @@ func NewCreateUser(
 	lastName string,
 	age uint32,
 	isMarried bool,
-	password string,
-	order Order,
+	passwordHash string,
 	createdAt time.Time,
 ) CreateUser {
 	fullName := firstName + " " + lastName
 	return CreateUser{
-		ID:        ID,
-		FirstName: firstName,
-		LastName:  lastName,
-		FullName:  fullName,
-		Age:       age,
-		IsMarried: isMarried,
-		Order:     order,
-		Password:  password,
+		ID:           ID,
+		FirstName:    firstName,
+		LastName:     lastName,
+		FullName:     fullName,
+		Age:          age,
+		IsMarried:    isMarried,
+		PasswordHash: passwordHash,
+		// New users always start as customers, higher roles are granted by an admin.
+		Role:      auth.RoleCustomer,
 		CreatedAt: createdAt,
 	}
 }
 
+// UpdateUser is a partial update of a user: nil fields keep their current
+// value. FullName is derived from the merged names, see Apply.
 type UpdateUser struct {
-	ID        string
-	FirstName string
-	LastName  string
-	FullName  string
-	Age       uint32
-	IsMarried bool
-	Password  string
-	Order     Order
-	UpdatedAt time.Time
+	ID           string
+	FirstName    *string
+	LastName     *string
+	FullName     *string
+	Age          *uint32
+	IsMarried    *bool
+	PasswordHash *string `json:"-" trace:"-"`
+	UpdatedAt    time.Time
+	Version      int64 // Version the client has seen
 }
 
 func NewUpdateUser(
 	ID string,
-	firstName string,
-	lastName string,
-	age uint32,
-	isMarried bool,
-	password string,
-	order Order,
+	firstName *string,
+	lastName *string,
+	age *uint32,
+	isMarried *bool,
+	passwordHash *string,
 	updatedAt time.Time,
+	version int64,
 ) UpdateUser {
-	fullName := firstName + " " + lastName
 	return UpdateUser{
-		ID:        ID,
-		FirstName: firstName,
-		LastName:  lastName,
-		FullName:  fullName,
-		Age:       age,
-		IsMarried: isMarried,
-		Password:  password,
-		Order:     order,
-		UpdatedAt: updatedAt,
+		ID:           ID,
+		FirstName:    firstName,
+		LastName:     lastName,
+		Age:          age,
+		IsMarried:    isMarried,
+		PasswordHash: passwordHash,
+		UpdatedAt:    updatedAt,
+		Version:      version,
 	}
 }
 
-type CreateOrder struct {
-	ID        string
-	UserID    string
-	ProductID string
-	Products  []OrderProduct
-	Timestamp time.Time
-	TimeStamp time.Time
+// Apply returns user with the update merged in and its full name rebuilt.
+func (u UpdateUser) Apply(user User) User {
+	if u.FirstName != nil {
+		user.FirstName = *u.FirstName
+	}
+	if u.LastName != nil {
+		user.LastName = *u.LastName
+	}
+	if u.Age != nil {
+		user.Age = *u.Age
+	}
+	if u.IsMarried != nil {
+		user.IsMarried = *u.IsMarried
+	}
+	if u.PasswordHash != nil {
+		user.PasswordHash = *u.PasswordHash
+	}
+	user.FullName = user.FirstName + " " + user.LastName
+
+	return user
 }
 
-func (co CreateOrder) ToOrder() Order {
-	return Order{
-		ID:        co.ID,
-		UserID:    co.UserID,
-		ProductID: co.ProductID,
-		Products:  co.Products,
-		Timestamp: co.Timestamp,
+// Validate checks the invariants of a user and returns the violated ones by
+// field, or nil.
+func (u User) Validate() map[string]string {
+	fields := map[string]string{}
+
+	if u.FirstName == "" {
+		fields["first_name"] = "must not be empty"
+	}
+	if u.LastName == "" {
+		fields["last_name"] = "must not be empty"
+	}
+	if u.Age < MinAge {
+		fields["age"] = "must be at least " + strconv.Itoa(MinAge)
+	}
+
+	if len(fields) == 0 {
+		return nil
 	}
+
+	return fields
 }
 
-func NewCreateOrder(
-	ID string,
-	userID string,
-	productID string,
-	products []OrderProduct,
-	timeStamp time.Time,
-) CreateOrder {
-	return CreateOrder{
-		ID:        ID,
-		UserID:    userID,
-		ProductID: productID,
-		Products:  products,
-		TimeStamp: timeStamp,
+type UserFilter struct {
+	IsMarried    *bool
+	MinAge       *uint32
+	MaxAge       *uint32
+	CreatedAfter *time.Time
+	Page         pagination.Params
+}
+
+func NewUserFilter(isMarried *bool, minAge, maxAge *uint32, createdAfter *time.Time, page pagination.Params) UserFilter {
+	return UserFilter{
+		IsMarried:    isMarried,
+		MinAge:       minAge,
+		MaxAge:       maxAge,
+		CreatedAfter: createdAfter,
+		Page:         page,
 	}
 }
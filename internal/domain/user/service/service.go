@@ -3,18 +3,21 @@ package service
 import (
 	"context"
 	"github.com/Amore14rn/888Starz_test/internal/domain/user/model"
+	"github.com/Amore14rn/888Starz_test/pkg/common/auth"
 	"github.com/Amore14rn/888Starz_test/pkg/errors"
+	"time"
 )
 
 type repository interface {
-	All(ctx context.Context) ([]model.User, error)
+	All(ctx context.Context, filter model.UserFilter) ([]model.User, string, error)
 	Create(ctx context.Context, req model.CreateUser) error
 	GetUser(ctx context.Context, id string) (model.User, error)
 	GetUserByName(ctx context.Context, name string) (model.User, error)
 	Update(ctx context.Context, req model.UpdateUser) error
-	Delete(ctx context.Context, id string) error
-	CreateOrder(ctx context.Context, req model.CreateOrder) error
-	AreProductsAvailable(ctx context.Context, productID string, products []model.OrderProduct) bool
+	Delete(ctx context.Context, id string, at time.Time) error
+	PurgeDeleted(ctx context.Context, before, at time.Time) (int64, error)
+	UpdatePassword(ctx context.Context, id, passwordHash string, updatedAt time.Time) error
+	UpdateRole(ctx context.Context, id string, role auth.Role, updatedAt time.Time) error
 }
 
 type UserService struct {
@@ -27,64 +30,33 @@ func NewUserService(repository repository) *UserService {
 	}
 }
 
-func (s *UserService) All(ctx context.Context) ([]model.User, error) {
-	users, err := s.repository.All(ctx)
+func (s *UserService) All(ctx context.Context, filter model.UserFilter) ([]model.User, string, error) {
+	users, next, err := s.repository.All(ctx, filter)
 	if err != nil {
-		return nil, errors.Wrap(err, "repository.All")
+		return nil, "", errors.Wrap(err, "repository.All")
 	}
 
-	return users, nil
+	return users, next, nil
 }
 
 func (u *UserService) CreateUser(ctx context.Context, req model.CreateUser) (model.User, error) {
-	// Проверка возраста пользователя
-	if req.Age < 18 {
-		return model.User{}, errors.New("Пользователь должен быть не младше 18 лет")
-	}
-
-	// Проверка длины пароля
-	if len(req.Password) < 8 {
-		return model.User{}, errors.New("Пароль должен содержать не менее 8 символов")
-	}
-
-	// Проверка наличия цифр в пароле
-	var isDigit bool
-	for _, char := range req.Password {
-		if char >= '0' && char <= '9' {
-			isDigit = true
-			break
-		}
-	}
-	if !isDigit {
-		return model.User{}, errors.New("Пароль должен содержать хотя бы одну цифру")
-	}
-
-	// Проверка наличия заглавных букв в пароле
-	var isUpper bool
-	for _, char := range req.Password {
-		if char >= 'A' && char <= 'Z' {
-			isUpper = true
-			break
-		}
-	}
-	if !isUpper {
-		return model.User{}, errors.New("Пароль должен содержать хотя бы одну заглавную букву")
-	}
-
 	err := u.repository.Create(ctx, req)
 	if err != nil {
 		return model.User{}, err
 	}
-	return model.NewUser(
+	user := model.NewUser(
 		req.ID,
 		req.FirstName,
 		req.LastName,
 		req.Age,
 		req.IsMarried,
-		req.Password,
-		req.Order,
+		req.PasswordHash,
 		req.CreatedAt,
-		nil), nil
+		nil)
+	user.Role = req.Role
+	user.Version = 1
+
+	return user, nil
 }
 
 func (u *UserService) GetUser(ctx context.Context, id string) (model.User, error) {
@@ -103,46 +75,44 @@ func (u *UserService) GetUserByName(ctx context.Context, name string) (model.Use
 	return user, nil
 }
 
+// UpdateUser applies the partial update and returns the user as stored.
 func (u *UserService) UpdateUser(ctx context.Context, req model.UpdateUser) (model.User, error) {
 	err := u.repository.Update(ctx, req)
 	if err != nil {
 		return model.User{}, err
 	}
-	return model.NewUser(
-		req.ID,
-		req.FirstName,
-		req.LastName,
-		req.Age,
-		req.IsMarried,
-		req.Password,
-		req.Order,
-		req.UpdatedAt,
-		nil), nil
+	return u.repository.GetUser(ctx, req.ID)
 }
 
-func (u *UserService) DeleteUser(ctx context.Context, id string) error {
-	err := u.repository.Delete(ctx, id)
+func (u *UserService) DeleteUser(ctx context.Context, id string, at time.Time) error {
+	err := u.repository.Delete(ctx, id, at)
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
-func (u *UserService) CreateOrder(ctx context.Context, req model.CreateOrder) (model.CreateOrder, error) {
-	err := u.repository.CreateOrder(ctx, req)
+func (u *UserService) PurgeDeleted(ctx context.Context, before, at time.Time) (int64, error) {
+	purged, err := u.repository.PurgeDeleted(ctx, before, at)
 	if err != nil {
-		return model.CreateOrder{}, err
+		return 0, errors.Wrap(err, "repository.PurgeDeleted")
 	}
-	return model.NewCreateOrder(
-		req.ID,
-		req.UserID,
-		req.ProductID,
-		req.Products,
-		req.TimeStamp,
-	), nil
 
+	return purged, nil
 }
 
-func (u *UserService) AreProductsAvailable(ctx context.Context, productID string, products []model.OrderProduct) bool {
-	return u.repository.AreProductsAvailable(ctx, productID, products)
+func (u *UserService) UpdatePassword(ctx context.Context, id, passwordHash string, updatedAt time.Time) error {
+	if err := u.repository.UpdatePassword(ctx, id, passwordHash, updatedAt); err != nil {
+		return errors.Wrap(err, "repository.UpdatePassword")
+	}
+
+	return nil
+}
+
+func (u *UserService) UpdateRole(ctx context.Context, id string, role auth.Role, updatedAt time.Time) error {
+	if err := u.repository.UpdateRole(ctx, id, role, updatedAt); err != nil {
+		return errors.Wrap(err, "repository.UpdateRole")
+	}
+
+	return nil
 }
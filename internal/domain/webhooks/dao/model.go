@@ -0,0 +1,77 @@
+package dao
+
+import (
+	"github.com/Amore14rn/888Starz_test/internal/domain/webhooks/model"
+	"time"
+)
+
+type SubscriptionStorage struct {
+	ID         string    `json:"id"`
+	URL        string    `json:"url"`
+	EventTypes []string  `json:"event_types"`
+	Secret     string    `json:"secret"`
+	CreatedBy  string    `json:"created_by"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func (ss *SubscriptionStorage) ToDomain() model.Subscription {
+	return model.NewSubscription(ss.ID, ss.URL, ss.EventTypes, ss.Secret, ss.CreatedBy, ss.CreatedAt)
+}
+
+type DeliveryStorage struct {
+	ID             int64      `json:"id"`
+	SubscriptionID string     `json:"subscription_id"`
+	EventID        int64      `json:"event_id"`
+	EventType      string     `json:"event_type"`
+	Payload        []byte     `json:"payload"`
+	Status         string     `json:"status"`
+	Attempts       int        `json:"attempts"`
+	NextAttemptAt  time.Time  `json:"next_attempt_at"`
+	ResponseCode   *int       `json:"response_code"`
+	LastError      *string    `json:"last_error"`
+	CreatedAt      time.Time  `json:"created_at"`
+	DeliveredAt    *time.Time `json:"delivered_at"`
+}
+
+func (ds *DeliveryStorage) ToDomain() model.Delivery {
+	var lastError string
+	if ds.LastError != nil {
+		lastError = *ds.LastError
+	}
+
+	return model.Delivery{
+		ID:             ds.ID,
+		SubscriptionID: ds.SubscriptionID,
+		EventID:        ds.EventID,
+		EventType:      ds.EventType,
+		Payload:        ds.Payload,
+		Status:         model.DeliveryStatus(ds.Status),
+		Attempts:       ds.Attempts,
+		NextAttemptAt:  ds.NextAttemptAt,
+		ResponseCode:   ds.ResponseCode,
+		LastError:      lastError,
+		CreatedAt:      ds.CreatedAt,
+		DeliveredAt:    ds.DeliveredAt,
+	}
+}
+
+type DeliveryAttemptStorage struct {
+	DeliveryID   int64     `json:"delivery_id"`
+	ResponseCode *int      `json:"response_code"`
+	Error        *string   `json:"error"`
+	AttemptedAt  time.Time `json:"attempted_at"`
+}
+
+func (da *DeliveryAttemptStorage) ToDomain() model.DeliveryAttempt {
+	var errText string
+	if da.Error != nil {
+		errText = *da.Error
+	}
+
+	return model.DeliveryAttempt{
+		DeliveryID:   da.DeliveryID,
+		ResponseCode: da.ResponseCode,
+		Error:        errText,
+		AttemptedAt:  da.AttemptedAt,
+	}
+}
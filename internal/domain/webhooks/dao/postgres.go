@@ -0,0 +1,633 @@
+package dao
+
+import (
+	"context"
+	"github.com/Amore14rn/888Starz_test/internal/dal"
+	"github.com/Amore14rn/888Starz_test/internal/dal/postgres"
+	"github.com/Amore14rn/888Starz_test/internal/domain/webhooks/model"
+	psql "github.com/Amore14rn/888Starz_test/pkg/postgresql"
+	"github.com/Amore14rn/888Starz_test/pkg/tracing"
+	sq "github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var deliveryColumns = []string{
+	"d.id",
+	"d.subscription_id",
+	"d.event_id",
+	"d.event_type",
+	"d.payload",
+	"d.status",
+	"d.attempts",
+	"d.next_attempt_at",
+	"d.response_code",
+	"d.last_error",
+	"d.created_at",
+	"d.delivered_at",
+}
+
+type WebhookDAO struct {
+	qb     sq.StatementBuilderType
+	client psql.Client
+}
+
+func NewWebhookDAO(client psql.Client) *WebhookDAO {
+	return &WebhookDAO{
+		qb:     sq.StatementBuilder.PlaceholderFormat(sq.Dollar),
+		client: client,
+	}
+}
+
+func (repo *WebhookDAO) Create(ctx context.Context, sub model.Subscription) error {
+	sql, args, err := repo.qb.
+		Insert(postgres.WebhookTable).
+		Columns(
+			"id",
+			"url",
+			"event_types",
+			"secret",
+			"created_by",
+			"created_at",
+		).
+		Values(
+			sub.ID,
+			sub.URL,
+			sub.EventTypes,
+			tracing.Secret(sub.Secret),
+			sub.CreatedBy,
+			sub.CreatedAt,
+		).ToSql()
+	if err != nil {
+		err = psql.ErrCreateQuery(err)
+		tracing.Error(ctx, err)
+
+		return err
+	}
+
+	tracing.SpanEvent(ctx, "Insert Webhook Subscription query")
+	tracing.TraceVal(ctx, "sql", sql)
+	for i, arg := range args {
+		tracing.TraceIVal(ctx, "arg-"+strconv.Itoa(i), arg)
+	}
+
+	cmd, execErr := repo.client.Exec(ctx, sql, args...)
+	if execErr != nil {
+		execErr = psql.ErrDoQuery(dal.FromPg(execErr))
+		tracing.Error(ctx, execErr)
+
+		return execErr
+	}
+
+	if cmd.RowsAffected() == 0 {
+		return dal.ErrNothingInserted
+	}
+
+	return nil
+}
+
+// All returns every subscription, oldest first.
+func (repo *WebhookDAO) All(ctx context.Context) ([]model.Subscription, error) {
+	return repo.findBy(ctx, sq.Eq{})
+}
+
+func (repo *WebhookDAO) Get(ctx context.Context, id string) (model.Subscription, error) {
+	subs, err := repo.findBy(ctx, sq.Eq{"id": id})
+	if err != nil {
+		return model.Subscription{}, err
+	}
+
+	if len(subs) == 0 {
+		return model.Subscription{}, dal.ErrNotFound
+	}
+
+	return subs[0], nil
+}
+
+func (repo *WebhookDAO) findBy(ctx context.Context, where sq.Eq) ([]model.Subscription, error) {
+	query, args, err := repo.qb.
+		Select(
+			"id",
+			"url",
+			"event_types",
+			"secret",
+			"created_by",
+			"created_at",
+		).
+		From(postgres.WebhookTable).
+		Where(where).
+		OrderBy("created_at", "id").
+		ToSql()
+	if err != nil {
+		err = psql.ErrCreateQuery(err)
+		tracing.Error(ctx, err)
+
+		return nil, err
+	}
+
+	tracing.SpanEvent(ctx, "Select Webhook Subscriptions")
+	tracing.TraceVal(ctx, "SQL", query)
+	for i, arg := range args {
+		tracing.TraceIVal(ctx, "arg-"+strconv.Itoa(i), arg)
+	}
+
+	rows, err := repo.client.Query(ctx, query, args...)
+	if err != nil {
+		err = psql.ErrDoQuery(dal.FromPg(err))
+		tracing.Error(ctx, err)
+
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	subs := make([]model.Subscription, 0)
+
+	for rows.Next() {
+		var e SubscriptionStorage
+		if err = rows.Scan(
+			&e.ID,
+			&e.URL,
+			&e.EventTypes,
+			&e.Secret,
+			&e.CreatedBy,
+			&e.CreatedAt,
+		); err != nil {
+			err = psql.ErrScan(dal.FromPg(err))
+			tracing.Error(ctx, err)
+
+			return nil, err
+		}
+
+		subs = append(subs, e.ToDomain())
+	}
+
+	if err = rows.Err(); err != nil {
+		err = psql.ErrDoQuery(dal.FromPg(err))
+		tracing.Error(ctx, err)
+
+		return nil, err
+	}
+
+	return subs, nil
+}
+
+// Delete drops the subscription together with its deliveries.
+func (repo *WebhookDAO) Delete(ctx context.Context, id string) error {
+	sql, args, err := repo.qb.
+		Delete(postgres.WebhookTable).
+		Where(sq.Eq{"id": id}).
+		ToSql()
+	if err != nil {
+		err = psql.ErrCreateQuery(err)
+		tracing.Error(ctx, err)
+
+		return err
+	}
+
+	tracing.SpanEvent(ctx, "Delete Webhook Subscription query")
+	tracing.TraceVal(ctx, "sql", sql)
+	for i, arg := range args {
+		tracing.TraceIVal(ctx, "arg-"+strconv.Itoa(i), arg)
+	}
+
+	cmd, execErr := repo.client.Exec(ctx, sql, args...)
+	if execErr != nil {
+		execErr = psql.ErrDoQuery(dal.FromPg(execErr))
+		tracing.Error(ctx, execErr)
+
+		return execErr
+	}
+
+	if cmd.RowsAffected() == 0 {
+		return dal.ErrNotFound
+	}
+
+	return nil
+}
+
+// Enqueue schedules a delivery of the event to every subscription listening
+// to its type and returns how many were scheduled. Enqueuing the same event
+// again schedules nothing new, so the outbox may publish it more than once.
+func (repo *WebhookDAO) Enqueue(ctx context.Context, eventID int64, eventType string, payload []byte, at time.Time) (int64, error) {
+	subscribers := sq.
+		Select("id").
+		Column(sq.Expr("?::BIGINT", eventID)).
+		Column(sq.Expr("?::VARCHAR", eventType)).
+		Column(sq.Expr("?::JSONB", payload)).
+		Column(sq.Expr("?::TIMESTAMP", at)).
+		Column(sq.Expr("?::TIMESTAMP", at)).
+		From(postgres.WebhookTable).
+		Where(sq.Expr("?::TEXT = ANY(event_types)", eventType))
+
+	sql, args, err := repo.qb.
+		Insert(postgres.WebhookDeliveryTable).
+		Columns(
+			"subscription_id",
+			"event_id",
+			"event_type",
+			"payload",
+			"next_attempt_at",
+			"created_at",
+		).
+		Select(subscribers).
+		Suffix("ON CONFLICT (subscription_id, event_id) DO NOTHING").
+		ToSql()
+	if err != nil {
+		err = psql.ErrCreateQuery(err)
+		tracing.Error(ctx, err)
+
+		return 0, err
+	}
+
+	tracing.SpanEvent(ctx, "Enqueue Webhook Deliveries query")
+	tracing.TraceVal(ctx, "sql", sql)
+	for i, arg := range args {
+		tracing.TraceIVal(ctx, "arg-"+strconv.Itoa(i), arg)
+	}
+
+	cmd, err := repo.client.Exec(ctx, sql, args...)
+	if err != nil {
+		err = psql.ErrDoQuery(dal.FromPg(err))
+		tracing.Error(ctx, err)
+
+		return 0, err
+	}
+
+	return cmd.RowsAffected(), nil
+}
+
+// Claim leases up to limit pending deliveries due at now until now+lease and
+// returns them with the URL and secret of their subscription. A delivery
+// whose lease runs out before its attempt is recorded is claimed again.
+func (repo *WebhookDAO) Claim(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]model.PendingDelivery, error) {
+	due := sq.
+		Select("id").
+		From(postgres.WebhookDeliveryTable).
+		Where(sq.Eq{"status": string(model.DeliveryPending)}).
+		Where(sq.LtOrEq{"next_attempt_at": now}).
+		OrderBy("next_attempt_at", "id").
+		Limit(uint64(limit)).
+		Suffix("FOR UPDATE SKIP LOCKED")
+
+	columns := append(append([]string{}, deliveryColumns...), "s.url", "s.secret")
+
+	query, args, err := repo.qb.
+		Update(postgres.WebhookDeliveryTable+" d").
+		Set("next_attempt_at", now.Add(lease)).
+		From(postgres.WebhookTable + " s").
+		Where("s.id = d.subscription_id").
+		Where(sq.Expr("d.id IN (?)", due)).
+		Suffix("RETURNING " + strings.Join(columns, ", ")).
+		ToSql()
+	if err != nil {
+		err = psql.ErrCreateQuery(err)
+		tracing.Error(ctx, err)
+
+		return nil, err
+	}
+
+	tracing.SpanEvent(ctx, "Claim Webhook Deliveries")
+	tracing.TraceVal(ctx, "SQL", query)
+	for i, arg := range args {
+		tracing.TraceIVal(ctx, "arg-"+strconv.Itoa(i), arg)
+	}
+
+	rows, err := repo.client.Query(ctx, query, args...)
+	if err != nil {
+		err = psql.ErrDoQuery(dal.FromPg(err))
+		tracing.Error(ctx, err)
+
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	var pending []model.PendingDelivery
+
+	for rows.Next() {
+		var (
+			e           DeliveryStorage
+			url, secret string
+		)
+		if err = rows.Scan(append(deliveryFields(&e), &url, &secret)...); err != nil {
+			err = psql.ErrScan(dal.FromPg(err))
+			tracing.Error(ctx, err)
+
+			return nil, err
+		}
+
+		pending = append(pending, model.PendingDelivery{
+			Delivery: e.ToDomain(),
+			URL:      url,
+			Secret:   secret,
+		})
+	}
+
+	if err = rows.Err(); err != nil {
+		err = psql.ErrDoQuery(dal.FromPg(err))
+		tracing.Error(ctx, err)
+
+		return nil, err
+	}
+
+	return pending, nil
+}
+
+// Record appends the attempt to the delivery log and moves the delivery to
+// the state of result in one transaction.
+func (repo *WebhookDAO) Record(ctx context.Context, result model.DeliveryResult) error {
+	attempt := result.Attempt
+
+	err := psql.WithTx(ctx, repo.client, pgx.TxOptions{}, func(tx pgx.Tx) error {
+		var lastError *string
+		if attempt.Error != "" {
+			lastError = &attempt.Error
+		}
+
+		sql, args, err := repo.qb.
+			Insert(postgres.WebhookAttemptTable).
+			Columns(
+				"delivery_id",
+				"response_code",
+				"error",
+				"attempted_at",
+			).
+			Values(
+				attempt.DeliveryID,
+				attempt.ResponseCode,
+				lastError,
+				attempt.AttemptedAt,
+			).ToSql()
+		if err != nil {
+			return psql.ErrCreateQuery(err)
+		}
+
+		tracing.SpanEvent(ctx, "Insert Webhook Delivery Attempt query")
+		tracing.TraceVal(ctx, "sql", sql)
+		for i, arg := range args {
+			tracing.TraceIVal(ctx, "arg-"+strconv.Itoa(i), arg)
+		}
+
+		if _, err = tx.Exec(ctx, sql, args...); err != nil {
+			return psql.ErrDoQuery(dal.FromPg(err))
+		}
+
+		statement := repo.qb.
+			Update(postgres.WebhookDeliveryTable).
+			Set("status", string(result.Status)).
+			Set("attempts", sq.Expr("attempts + 1")).
+			Set("next_attempt_at", result.NextAttemptAt).
+			Set("response_code", attempt.ResponseCode).
+			Set("last_error", lastError).
+			Where(sq.Eq{"id": attempt.DeliveryID})
+
+		if result.Status == model.DeliveryDelivered {
+			statement = statement.Set("delivered_at", attempt.AttemptedAt)
+		}
+
+		sql, args, err = statement.ToSql()
+		if err != nil {
+			return psql.ErrCreateQuery(err)
+		}
+
+		tracing.SpanEvent(ctx, "Update Webhook Delivery query")
+		tracing.TraceVal(ctx, "sql", sql)
+		for i, arg := range args {
+			tracing.TraceIVal(ctx, "arg-"+strconv.Itoa(i), arg)
+		}
+
+		cmd, err := tx.Exec(ctx, sql, args...)
+		if err != nil {
+			return psql.ErrDoQuery(dal.FromPg(err))
+		}
+
+		if cmd.RowsAffected() == 0 {
+			return dal.ErrNotFound
+		}
+
+		return nil
+	})
+	if err != nil {
+		tracing.Error(ctx, err)
+
+		return err
+	}
+
+	return nil
+}
+
+// Deliveries returns up to limit latest deliveries of the subscription.
+func (repo *WebhookDAO) Deliveries(ctx context.Context, subscriptionID string, limit int) ([]model.Delivery, error) {
+	query, args, err := repo.qb.
+		Select(deliveryColumns...).
+		From(postgres.WebhookDeliveryTable + " d").
+		Where(sq.Eq{"d.subscription_id": subscriptionID}).
+		OrderBy("d.id DESC").
+		Limit(uint64(limit)).
+		ToSql()
+	if err != nil {
+		err = psql.ErrCreateQuery(err)
+		tracing.Error(ctx, err)
+
+		return nil, err
+	}
+
+	tracing.SpanEvent(ctx, "Select Webhook Deliveries")
+	tracing.TraceVal(ctx, "SQL", query)
+	for i, arg := range args {
+		tracing.TraceIVal(ctx, "arg-"+strconv.Itoa(i), arg)
+	}
+
+	rows, err := repo.client.Query(ctx, query, args...)
+	if err != nil {
+		err = psql.ErrDoQuery(dal.FromPg(err))
+		tracing.Error(ctx, err)
+
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	deliveries := make([]model.Delivery, 0, limit)
+
+	for rows.Next() {
+		var e DeliveryStorage
+		if err = rows.Scan(deliveryFields(&e)...); err != nil {
+			err = psql.ErrScan(dal.FromPg(err))
+			tracing.Error(ctx, err)
+
+			return nil, err
+		}
+
+		deliveries = append(deliveries, e.ToDomain())
+	}
+
+	if err = rows.Err(); err != nil {
+		err = psql.ErrDoQuery(dal.FromPg(err))
+		tracing.Error(ctx, err)
+
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+// Delivery returns the delivery of the subscription with its delivery log.
+func (repo *WebhookDAO) Delivery(ctx context.Context, subscriptionID string, id int64) (model.Delivery, error) {
+	query, args, err := repo.qb.
+		Select(deliveryColumns...).
+		From(postgres.WebhookDeliveryTable + " d").
+		Where(sq.Eq{"d.id": id, "d.subscription_id": subscriptionID}).
+		ToSql()
+	if err != nil {
+		err = psql.ErrCreateQuery(err)
+		tracing.Error(ctx, err)
+
+		return model.Delivery{}, err
+	}
+
+	tracing.SpanEvent(ctx, "Select Webhook Delivery")
+	tracing.TraceVal(ctx, "SQL", query)
+	for i, arg := range args {
+		tracing.TraceIVal(ctx, "arg-"+strconv.Itoa(i), arg)
+	}
+
+	var e DeliveryStorage
+	if err = repo.client.QueryRow(ctx, query, args...).Scan(deliveryFields(&e)...); err != nil {
+		err = psql.ErrScan(dal.FromPg(err))
+		tracing.Error(ctx, err)
+
+		return model.Delivery{}, err
+	}
+
+	delivery := e.ToDomain()
+
+	delivery.Log, err = repo.attempts(ctx, id)
+	if err != nil {
+		return model.Delivery{}, err
+	}
+
+	return delivery, nil
+}
+
+func (repo *WebhookDAO) attempts(ctx context.Context, deliveryID int64) ([]model.DeliveryAttempt, error) {
+	query, args, err := repo.qb.
+		Select(
+			"delivery_id",
+			"response_code",
+			"error",
+			"attempted_at",
+		).
+		From(postgres.WebhookAttemptTable).
+		Where(sq.Eq{"delivery_id": deliveryID}).
+		OrderBy("id").
+		ToSql()
+	if err != nil {
+		err = psql.ErrCreateQuery(err)
+		tracing.Error(ctx, err)
+
+		return nil, err
+	}
+
+	tracing.SpanEvent(ctx, "Select Webhook Delivery Attempts")
+	tracing.TraceVal(ctx, "SQL", query)
+	for i, arg := range args {
+		tracing.TraceIVal(ctx, "arg-"+strconv.Itoa(i), arg)
+	}
+
+	rows, err := repo.client.Query(ctx, query, args...)
+	if err != nil {
+		err = psql.ErrDoQuery(dal.FromPg(err))
+		tracing.Error(ctx, err)
+
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	attempts := make([]model.DeliveryAttempt, 0)
+
+	for rows.Next() {
+		var e DeliveryAttemptStorage
+		if err = rows.Scan(
+			&e.DeliveryID,
+			&e.ResponseCode,
+			&e.Error,
+			&e.AttemptedAt,
+		); err != nil {
+			err = psql.ErrScan(dal.FromPg(err))
+			tracing.Error(ctx, err)
+
+			return nil, err
+		}
+
+		attempts = append(attempts, e.ToDomain())
+	}
+
+	if err = rows.Err(); err != nil {
+		err = psql.ErrDoQuery(dal.FromPg(err))
+		tracing.Error(ctx, err)
+
+		return nil, err
+	}
+
+	return attempts, nil
+}
+
+// Redeliver schedules the delivery for an immediate attempt, whatever state
+// it is in.
+func (repo *WebhookDAO) Redeliver(ctx context.Context, subscriptionID string, id int64, at time.Time) error {
+	sql, args, err := repo.qb.
+		Update(postgres.WebhookDeliveryTable).
+		Set("status", string(model.DeliveryPending)).
+		Set("next_attempt_at", at).
+		Set("delivered_at", nil).
+		Where(sq.Eq{"id": id, "subscription_id": subscriptionID}).
+		ToSql()
+	if err != nil {
+		err = psql.ErrCreateQuery(err)
+		tracing.Error(ctx, err)
+
+		return err
+	}
+
+	tracing.SpanEvent(ctx, "Redeliver Webhook Delivery query")
+	tracing.TraceVal(ctx, "sql", sql)
+	for i, arg := range args {
+		tracing.TraceIVal(ctx, "arg-"+strconv.Itoa(i), arg)
+	}
+
+	cmd, execErr := repo.client.Exec(ctx, sql, args...)
+	if execErr != nil {
+		execErr = psql.ErrDoQuery(dal.FromPg(execErr))
+		tracing.Error(ctx, execErr)
+
+		return execErr
+	}
+
+	if cmd.RowsAffected() == 0 {
+		return dal.ErrNotFound
+	}
+
+	return nil
+}
+
+func deliveryFields(e *DeliveryStorage) []any {
+	return []any{
+		&e.ID,
+		&e.SubscriptionID,
+		&e.EventID,
+		&e.EventType,
+		&e.Payload,
+		&e.Status,
+		&e.Attempts,
+		&e.NextAttemptAt,
+		&e.ResponseCode,
+		&e.LastError,
+		&e.CreatedAt,
+		&e.DeliveredAt,
+	}
+}
@@ -0,0 +1,122 @@
+package model
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+	"time"
+)
+
+const (
+	HeaderSignature = "X-Webhook-Signature"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderDelivery  = "X-Webhook-Delivery"
+	HeaderEventType = "X-Event-Type"
+
+	signaturePrefix = "sha256="
+	secretBytes     = 32
+)
+
+// Subscription is a partner endpoint receiving events of the listed types.
+// Secret is only filled in when the subscription is created.
+type Subscription struct {
+	ID         string
+	URL        string
+	EventTypes []string
+	Secret     string `json:",omitempty"`
+	CreatedBy  string
+	CreatedAt  time.Time
+}
+
+func NewSubscription(id, url string, eventTypes []string, secret, createdBy string, createdAt time.Time) Subscription {
+	return Subscription{
+		ID:         id,
+		URL:        url,
+		EventTypes: eventTypes,
+		Secret:     secret,
+		CreatedBy:  createdBy,
+		CreatedAt:  createdAt,
+	}
+}
+
+// Redacted returns the subscription without its secret.
+func (s Subscription) Redacted() Subscription {
+	s.Secret = ""
+
+	return s
+}
+
+// NewSecret returns a random signing secret.
+func NewSecret() (string, error) {
+	b := make([]byte, secretBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
+
+// Sign returns the X-Webhook-Signature value of body sent at timestamp: the
+// hex HMAC-SHA256 of "<timestamp>.<body>" keyed with secret. The timestamp
+// is signed too so a captured request can't be replayed later.
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp.Unix(), 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+type DeliveryStatus string
+
+const (
+	DeliveryPending   DeliveryStatus = "pending"
+	DeliveryDelivered DeliveryStatus = "delivered"
+	DeliveryFailed    DeliveryStatus = "failed"
+)
+
+// Delivery is one event sent to one subscription, retried until it is
+// delivered or runs out of attempts.
+type Delivery struct {
+	ID             int64
+	SubscriptionID string
+	EventID        int64
+	EventType      string
+	Payload        json.RawMessage
+	Status         DeliveryStatus
+	Attempts       int
+	NextAttemptAt  time.Time
+	ResponseCode   *int
+	LastError      string
+	CreatedAt      time.Time
+	DeliveredAt    *time.Time
+	Log            []DeliveryAttempt `json:",omitempty"`
+}
+
+// DeliveryAttempt is an entry of the delivery log. ResponseCode is nil when
+// no response was received.
+type DeliveryAttempt struct {
+	DeliveryID   int64
+	ResponseCode *int
+	Error        string
+	AttemptedAt  time.Time
+}
+
+// PendingDelivery is a claimed delivery together with where to send it.
+type PendingDelivery struct {
+	Delivery
+	URL    string
+	Secret string
+}
+
+// DeliveryResult is the outcome of an attempt and the state the delivery
+// moves to.
+type DeliveryResult struct {
+	Attempt       DeliveryAttempt
+	Status        DeliveryStatus
+	NextAttemptAt time.Time
+}
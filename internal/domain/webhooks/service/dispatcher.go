@@ -0,0 +1,179 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/Amore14rn/888Starz_test/internal/domain/webhooks/model"
+	"github.com/Amore14rn/888Starz_test/pkg/common/core/clock"
+	"github.com/Amore14rn/888Starz_test/pkg/common/logging"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+type DispatcherConfig struct {
+	Interval    time.Duration
+	BatchSize   int
+	Lease       time.Duration
+	Timeout     time.Duration
+	MaxAttempts int
+	MinBackoff  time.Duration
+	MaxBackoff  time.Duration
+}
+
+// Dispatcher sends pending webhook deliveries. Every request is signed with
+// the subscription secret, failed deliveries are retried with exponential
+// backoff and jitter until MaxAttempts is reached.
+type Dispatcher struct {
+	webhooks *WebhookService
+	client   *http.Client
+	clock    clock.Clock
+	cfg      DispatcherConfig
+
+	// jitter returns a random duration in [0, n), replaced in tests
+	jitter func(n int64) int64
+}
+
+func NewDispatcher(webhooks *WebhookService, clock clock.Clock, cfg DispatcherConfig) *Dispatcher {
+	return &Dispatcher{
+		webhooks: webhooks,
+		client:   &http.Client{Timeout: cfg.Timeout},
+		clock:    clock,
+		cfg:      cfg,
+		jitter:   rand.Int63n,
+	}
+}
+
+// Run dispatches deliveries until ctx is done. A full batch is followed by
+// the next one right away, otherwise the dispatcher waits for the interval.
+func (d *Dispatcher) Run(ctx context.Context) error {
+	for {
+		claimed, err := d.DispatchOnce(ctx)
+		if err != nil {
+			logging.WithError(ctx, err).Error("webhook dispatch failed")
+		}
+
+		if err == nil && claimed == d.cfg.BatchSize {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-d.clock.After(d.cfg.Interval):
+		}
+	}
+}
+
+// DispatchOnce sends one batch of due deliveries and returns how many were claimed.
+func (d *Dispatcher) DispatchOnce(ctx context.Context) (int, error) {
+	pending, err := d.webhooks.Claim(ctx, d.clock.Now(), d.cfg.Lease, d.cfg.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, delivery := range pending {
+		if ctx.Err() != nil {
+			// Unsent deliveries are claimed again once their lease runs out
+			return len(pending), nil
+		}
+
+		if err = d.webhooks.Record(ctx, d.deliver(ctx, delivery)); err != nil {
+			return len(pending), err
+		}
+	}
+
+	return len(pending), nil
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, delivery model.PendingDelivery) model.DeliveryResult {
+	now := d.clock.Now()
+	attempt := model.DeliveryAttempt{
+		DeliveryID:  delivery.ID,
+		AttemptedAt: now,
+	}
+
+	code, err := d.send(ctx, delivery, now)
+	if code != 0 {
+		attempt.ResponseCode = &code
+	}
+
+	if err == nil {
+		return model.DeliveryResult{
+			Attempt:       attempt,
+			Status:        model.DeliveryDelivered,
+			NextAttemptAt: now,
+		}
+	}
+
+	attempt.Error = err.Error()
+	logging.WithError(ctx, err).With(
+		logging.Int64Field("delivery_id", delivery.ID),
+		logging.StringField("subscription_id", delivery.SubscriptionID),
+	).Warn("webhook delivery failed")
+
+	if delivery.Attempts+1 >= d.cfg.MaxAttempts {
+		return model.DeliveryResult{
+			Attempt:       attempt,
+			Status:        model.DeliveryFailed,
+			NextAttemptAt: now,
+		}
+	}
+
+	return model.DeliveryResult{
+		Attempt:       attempt,
+		Status:        model.DeliveryPending,
+		NextAttemptAt: now.Add(Backoff(delivery.Attempts, d.cfg.MinBackoff, d.cfg.MaxBackoff, d.jitter)),
+	}
+}
+
+// send POSTs the delivery payload and returns the response code, which is 0
+// when no response was received.
+func (d *Dispatcher) send(ctx context.Context, delivery model.PendingDelivery, now time.Time) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(model.HeaderDelivery, strconv.FormatInt(delivery.ID, 10))
+	req.Header.Set(model.HeaderEventType, delivery.EventType)
+	req.Header.Set(model.HeaderTimestamp, strconv.FormatInt(now.Unix(), 10))
+	req.Header.Set(model.HeaderSignature, model.Sign(delivery.Secret, now, delivery.Payload))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("webhook responded with %d", resp.StatusCode)
+	}
+
+	return resp.StatusCode, nil
+}
+
+// Backoff is the delay before the retry that follows attempts failed
+// attempts: min doubled per attempt and capped at max, of which a random
+// half is taken off so that receivers recovering from an outage aren't hit
+// by every retry at once.
+func Backoff(attempts int, min, max time.Duration, jitter func(n int64) int64) time.Duration {
+	delay := min
+	for i := 0; i < attempts && delay < max; i++ {
+		delay *= 2
+	}
+
+	if delay > max {
+		delay = max
+	}
+
+	half := delay / 2
+
+	return delay - half + time.Duration(jitter(int64(half)+1))
+}
@@ -0,0 +1,210 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/Amore14rn/888Starz_test/internal/domain/webhooks/model"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type MockRepository struct {
+	mock.Mock
+}
+
+func (m *MockRepository) Create(ctx context.Context, sub model.Subscription) error {
+	args := m.Called(ctx, sub)
+	return args.Error(0)
+}
+
+func (m *MockRepository) All(ctx context.Context) ([]model.Subscription, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]model.Subscription), args.Error(1)
+}
+
+func (m *MockRepository) Get(ctx context.Context, id string) (model.Subscription, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(model.Subscription), args.Error(1)
+}
+
+func (m *MockRepository) Delete(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockRepository) Enqueue(ctx context.Context, eventID int64, eventType string, payload []byte, at time.Time) (int64, error) {
+	args := m.Called(ctx, eventID, eventType, payload, at)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockRepository) Claim(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]model.PendingDelivery, error) {
+	args := m.Called(ctx, now, lease, limit)
+	return args.Get(0).([]model.PendingDelivery), args.Error(1)
+}
+
+func (m *MockRepository) Record(ctx context.Context, result model.DeliveryResult) error {
+	args := m.Called(ctx, result)
+	return args.Error(0)
+}
+
+func (m *MockRepository) Deliveries(ctx context.Context, subscriptionID string, limit int) ([]model.Delivery, error) {
+	args := m.Called(ctx, subscriptionID, limit)
+	return args.Get(0).([]model.Delivery), args.Error(1)
+}
+
+func (m *MockRepository) Delivery(ctx context.Context, subscriptionID string, id int64) (model.Delivery, error) {
+	args := m.Called(ctx, subscriptionID, id)
+	return args.Get(0).(model.Delivery), args.Error(1)
+}
+
+func (m *MockRepository) Redeliver(ctx context.Context, subscriptionID string, id int64, at time.Time) error {
+	args := m.Called(ctx, subscriptionID, id, at)
+	return args.Error(0)
+}
+
+type MockClock struct {
+	now time.Time
+}
+
+func (m MockClock) After(d time.Duration) <-chan time.Time { return nil }
+
+func (m MockClock) Now() time.Time { return m.now }
+
+func (m MockClock) Since(t time.Time) time.Duration { return m.now.Sub(t) }
+
+func (m MockClock) Until(t time.Time) time.Duration { return t.Sub(m.now) }
+
+func (m MockClock) Sleep(d time.Duration) {}
+
+func (m MockClock) Tick(d time.Duration) <-chan time.Time { return nil }
+
+var dispatcherConfig = DispatcherConfig{
+	BatchSize:   10,
+	Lease:       time.Minute,
+	Timeout:     time.Second,
+	MaxAttempts: 3,
+	MinBackoff:  10 * time.Second,
+	MaxBackoff:  time.Hour,
+}
+
+func pendingDelivery(url string, attempts int) model.PendingDelivery {
+	return model.PendingDelivery{
+		Delivery: model.Delivery{
+			ID:             7,
+			SubscriptionID: "s1",
+			EventType:      "order.status_changed",
+			Payload:        []byte(`{"type":"order.status_changed"}`),
+			Status:         model.DeliveryPending,
+			Attempts:       attempts,
+		},
+		URL:    url,
+		Secret: "topsecret-topsecret",
+	}
+}
+
+// dispatch runs one dispatch of delivery and returns the recorded result.
+func dispatch(t *testing.T, now time.Time, delivery model.PendingDelivery) model.DeliveryResult {
+	var result model.DeliveryResult
+
+	mockRepo := new(MockRepository)
+	mockRepo.On("Claim", mock.Anything, now, time.Minute, 10).Return([]model.PendingDelivery{delivery}, nil)
+	mockRepo.On("Record", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		result = args.Get(1).(model.DeliveryResult)
+	}).Return(nil)
+
+	dispatcher := NewDispatcher(NewWebhookService(mockRepo), MockClock{now: now}, dispatcherConfig)
+	dispatcher.jitter = func(n int64) int64 { return 0 }
+
+	claimed, err := dispatcher.DispatchOnce(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 1, claimed)
+
+	return result
+}
+
+func TestDispatchSignsDelivery(t *testing.T) {
+	now := time.Date(2023, 9, 10, 12, 0, 0, 0, time.UTC)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		timestamp := r.Header.Get(model.HeaderTimestamp)
+
+		mac := hmac.New(sha256.New, []byte("topsecret-topsecret"))
+		mac.Write([]byte(timestamp + "." + string(body)))
+
+		assert.Equal(t, strconv.FormatInt(now.Unix(), 10), timestamp)
+		assert.Equal(t, "sha256="+hex.EncodeToString(mac.Sum(nil)), r.Header.Get(model.HeaderSignature))
+		assert.Equal(t, "7", r.Header.Get(model.HeaderDelivery))
+		assert.Equal(t, "order.status_changed", r.Header.Get(model.HeaderEventType))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	result := dispatch(t, now, pendingDelivery(server.URL, 0))
+
+	assert.Equal(t, model.DeliveryDelivered, result.Status)
+	if assert.NotNil(t, result.Attempt.ResponseCode) {
+		assert.Equal(t, http.StatusNoContent, *result.Attempt.ResponseCode)
+	}
+	assert.Empty(t, result.Attempt.Error)
+}
+
+func TestDispatchRetriesFailedDelivery(t *testing.T) {
+	now := time.Date(2023, 9, 10, 12, 0, 0, 0, time.UTC)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	t.Run("retried with backoff", func(t *testing.T) {
+		result := dispatch(t, now, pendingDelivery(server.URL, 1))
+
+		assert.Equal(t, model.DeliveryPending, result.Status)
+		// The 20s backoff loses its random half, jitter adds nothing back
+		assert.Equal(t, now.Add(10*time.Second), result.NextAttemptAt)
+		if assert.NotNil(t, result.Attempt.ResponseCode) {
+			assert.Equal(t, http.StatusBadGateway, *result.Attempt.ResponseCode)
+		}
+		assert.Contains(t, result.Attempt.Error, "502")
+	})
+
+	t.Run("gives up after max attempts", func(t *testing.T) {
+		result := dispatch(t, now, pendingDelivery(server.URL, 2))
+
+		assert.Equal(t, model.DeliveryFailed, result.Status)
+	})
+}
+
+func TestDispatchUnreachableReceiver(t *testing.T) {
+	now := time.Date(2023, 9, 10, 12, 0, 0, 0, time.UTC)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	server.Close()
+
+	result := dispatch(t, now, pendingDelivery(server.URL, 0))
+
+	assert.Equal(t, model.DeliveryPending, result.Status)
+	assert.Nil(t, result.Attempt.ResponseCode)
+	assert.NotEmpty(t, result.Attempt.Error)
+}
+
+func TestBackoff(t *testing.T) {
+	noJitter := func(n int64) int64 { return 0 }
+	fullJitter := func(n int64) int64 { return n - 1 }
+
+	assert.Equal(t, 5*time.Second, Backoff(0, 10*time.Second, time.Hour, noJitter))
+	assert.Equal(t, 10*time.Second, Backoff(0, 10*time.Second, time.Hour, fullJitter))
+	assert.Equal(t, 40*time.Second, Backoff(2, 10*time.Second, time.Hour, fullJitter))
+	assert.Equal(t, 30*time.Minute, Backoff(20, 10*time.Second, time.Hour, noJitter))
+}
@@ -0,0 +1,34 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	outboxmodel "github.com/Amore14rn/888Starz_test/internal/domain/outbox/model"
+	"github.com/Amore14rn/888Starz_test/internal/domain/outbox/publisher"
+	"github.com/Amore14rn/888Starz_test/pkg/common/core/clock"
+)
+
+// SubscriptionPublisher is the outbox publisher that schedules a webhook
+// delivery of every event to the subscriptions listening to its type.
+type SubscriptionPublisher struct {
+	webhooks *WebhookService
+	clock    clock.Clock
+}
+
+func NewSubscriptionPublisher(webhooks *WebhookService, clock clock.Clock) *SubscriptionPublisher {
+	return &SubscriptionPublisher{
+		webhooks: webhooks,
+		clock:    clock,
+	}
+}
+
+func (p *SubscriptionPublisher) Publish(ctx context.Context, event outboxmodel.Event) error {
+	payload, err := json.Marshal(publisher.NewEnvelope(event))
+	if err != nil {
+		return err
+	}
+
+	_, err = p.webhooks.Enqueue(ctx, event.ID, string(event.Type), payload, p.clock.Now())
+
+	return err
+}
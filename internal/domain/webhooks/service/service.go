@@ -0,0 +1,117 @@
+package service
+
+import (
+	"context"
+	"github.com/Amore14rn/888Starz_test/internal/domain/webhooks/model"
+	"github.com/Amore14rn/888Starz_test/pkg/errors"
+	"time"
+)
+
+type repository interface {
+	Create(ctx context.Context, sub model.Subscription) error
+	All(ctx context.Context) ([]model.Subscription, error)
+	Get(ctx context.Context, id string) (model.Subscription, error)
+	Delete(ctx context.Context, id string) error
+	Enqueue(ctx context.Context, eventID int64, eventType string, payload []byte, at time.Time) (int64, error)
+	Claim(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]model.PendingDelivery, error)
+	Record(ctx context.Context, result model.DeliveryResult) error
+	Deliveries(ctx context.Context, subscriptionID string, limit int) ([]model.Delivery, error)
+	Delivery(ctx context.Context, subscriptionID string, id int64) (model.Delivery, error)
+	Redeliver(ctx context.Context, subscriptionID string, id int64, at time.Time) error
+}
+
+type WebhookService struct {
+	repository repository
+}
+
+func NewWebhookService(repository repository) *WebhookService {
+	return &WebhookService{
+		repository: repository,
+	}
+}
+
+func (s *WebhookService) CreateSubscription(ctx context.Context, sub model.Subscription) error {
+	if err := s.repository.Create(ctx, sub); err != nil {
+		return errors.Wrap(err, "repository.Create")
+	}
+
+	return nil
+}
+
+func (s *WebhookService) Subscriptions(ctx context.Context) ([]model.Subscription, error) {
+	subs, err := s.repository.All(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "repository.All")
+	}
+
+	return subs, nil
+}
+
+func (s *WebhookService) GetSubscription(ctx context.Context, id string) (model.Subscription, error) {
+	sub, err := s.repository.Get(ctx, id)
+	if err != nil {
+		return model.Subscription{}, errors.Wrap(err, "repository.Get")
+	}
+
+	return sub, nil
+}
+
+func (s *WebhookService) DeleteSubscription(ctx context.Context, id string) error {
+	if err := s.repository.Delete(ctx, id); err != nil {
+		return errors.Wrap(err, "repository.Delete")
+	}
+
+	return nil
+}
+
+func (s *WebhookService) Enqueue(ctx context.Context, eventID int64, eventType string, payload []byte, at time.Time) (int64, error) {
+	enqueued, err := s.repository.Enqueue(ctx, eventID, eventType, payload, at)
+	if err != nil {
+		return 0, errors.Wrap(err, "repository.Enqueue")
+	}
+
+	return enqueued, nil
+}
+
+func (s *WebhookService) Claim(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]model.PendingDelivery, error) {
+	pending, err := s.repository.Claim(ctx, now, lease, limit)
+	if err != nil {
+		return nil, errors.Wrap(err, "repository.Claim")
+	}
+
+	return pending, nil
+}
+
+func (s *WebhookService) Record(ctx context.Context, result model.DeliveryResult) error {
+	if err := s.repository.Record(ctx, result); err != nil {
+		return errors.Wrap(err, "repository.Record")
+	}
+
+	return nil
+}
+
+func (s *WebhookService) Deliveries(ctx context.Context, subscriptionID string, limit int) ([]model.Delivery, error) {
+	deliveries, err := s.repository.Deliveries(ctx, subscriptionID, limit)
+	if err != nil {
+		return nil, errors.Wrap(err, "repository.Deliveries")
+	}
+
+	return deliveries, nil
+}
+
+func (s *WebhookService) Delivery(ctx context.Context, subscriptionID string, id int64) (model.Delivery, error) {
+	delivery, err := s.repository.Delivery(ctx, subscriptionID, id)
+	if err != nil {
+		return model.Delivery{}, errors.Wrap(err, "repository.Delivery")
+	}
+
+	return delivery, nil
+}
+
+func (s *WebhookService) Redeliver(ctx context.Context, subscriptionID string, id int64, at time.Time) error {
+	if err := s.repository.Redeliver(ctx, subscriptionID, id, at); err != nil {
+		return errors.Wrap(err, "repository.Redeliver")
+	}
+
+	return nil
+}
@@ -0,0 +1,25 @@
+package auth
+
+import (
+	"context"
+)
+
+// Principal is the authenticated caller of a request.
+type Principal struct {
+	UserID string
+	Role   Role
+}
+
+type ctxPrincipal struct{}
+
+// ContextWithPrincipal adds the authenticated caller to context
+func ContextWithPrincipal(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, ctxPrincipal{}, p)
+}
+
+// PrincipalFromContext returns the authenticated caller from context
+func PrincipalFromContext(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(ctxPrincipal{}).(Principal)
+
+	return p, ok
+}
@@ -0,0 +1,28 @@
+package auth
+
+// Role is the access level of a user.
+type Role string
+
+const (
+	RoleCustomer Role = "customer"
+	RoleManager  Role = "manager"
+	RoleAdmin    Role = "admin"
+)
+
+// roleRank orders roles so that a higher role inherits the permissions of the lower ones.
+var roleRank = map[Role]int{
+	RoleCustomer: 1,
+	RoleManager:  2,
+	RoleAdmin:    3,
+}
+
+// Grants reports whether r includes the permissions of required.
+// Unknown roles grant nothing.
+func (r Role) Grants(required Role) bool {
+	rank, ok := roleRank[r]
+	if !ok {
+		return false
+	}
+
+	return rank >= roleRank[required]
+}
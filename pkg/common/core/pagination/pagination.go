@@ -0,0 +1,84 @@
+package pagination
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"time"
+
+	"github.com/Amore14rn/888Starz_test/pkg/errors"
+)
+
+const (
+	DefaultLimit = 20
+	MaxLimit     = 100
+)
+
+// Sort is the order of a keyset page. Pages are always ordered by creation
+// time with the ID as a tie breaker, so the cursor stays stable.
+type Sort string
+
+const (
+	SortCreatedAsc  Sort = "created_at"
+	SortCreatedDesc Sort = "-created_at"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Params selects one page of a list.
+type Params struct {
+	Limit  int
+	Cursor string
+	Sort   Sort
+}
+
+// NewParams fills in the default limit and sort and caps the limit at MaxLimit.
+func NewParams(limit int, cursor string, sort Sort) Params {
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+	if limit > MaxLimit {
+		limit = MaxLimit
+	}
+	if sort == "" {
+		sort = SortCreatedDesc
+	}
+
+	return Params{
+		Limit:  limit,
+		Cursor: cursor,
+		Sort:   sort,
+	}
+}
+
+// Descending reports whether the newest rows come first.
+func (p Params) Descending() bool {
+	return p.Sort != SortCreatedAsc
+}
+
+// Cursor is the keyset position of the last row of a page.
+type Cursor struct {
+	CreatedAt time.Time `json:"c"`
+	ID        string    `json:"i"`
+}
+
+// Encode returns the opaque representation handed out to clients.
+func (c Cursor) Encode() string {
+	raw, _ := json.Marshal(c)
+
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// DecodeCursor parses a cursor produced by Encode.
+func DecodeCursor(s string) (Cursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	var c Cursor
+	if err = json.Unmarshal(raw, &c); err != nil || c.ID == "" || c.CreatedAt.IsZero() {
+		return Cursor{}, ErrInvalidCursor
+	}
+
+	return c, nil
+}
@@ -0,0 +1,34 @@
+package pagination
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	c := Cursor{
+		CreatedAt: time.Date(2023, 9, 10, 8, 39, 24, 123456000, time.UTC),
+		ID:        "5f8d0d55-3b5e-4a4f-9d2a-6b1a2b3c4d5e",
+	}
+
+	decoded, err := DecodeCursor(c.Encode())
+	require.NoError(t, err)
+	assert.True(t, c.CreatedAt.Equal(decoded.CreatedAt))
+	assert.Equal(t, c.ID, decoded.ID)
+}
+
+func TestDecodeCursorRejectsGarbage(t *testing.T) {
+	for _, raw := range []string{"", "!!!", "e30", Cursor{ID: "x"}.Encode()} {
+		_, err := DecodeCursor(raw)
+		assert.ErrorIs(t, err, ErrInvalidCursor, raw)
+	}
+}
+
+func TestNewParams(t *testing.T) {
+	assert.Equal(t, Params{Limit: DefaultLimit, Sort: SortCreatedDesc}, NewParams(0, "", ""))
+	assert.Equal(t, MaxLimit, NewParams(MaxLimit+1, "", "").Limit)
+	assert.False(t, NewParams(5, "", SortCreatedAsc).Descending())
+}
@@ -0,0 +1,54 @@
+package password
+
+import (
+	"strings"
+
+	"github.com/Amore14rn/888Starz_test/pkg/errors"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type BcryptParams struct {
+	Cost int
+}
+
+type bcryptAlgorithm struct {
+	cost int
+}
+
+func newBcrypt(p BcryptParams) bcryptAlgorithm {
+	if p.Cost == 0 {
+		p.Cost = bcrypt.DefaultCost
+	}
+
+	return bcryptAlgorithm{cost: p.Cost}
+}
+
+func (a bcryptAlgorithm) hash(password string) (string, error) {
+	b, err := bcrypt.GenerateFromPassword([]byte(password), a.cost)
+	if err != nil {
+		return "", errors.Wrap(err, "bcrypt.GenerateFromPassword")
+	}
+
+	return string(b), nil
+}
+
+func (a bcryptAlgorithm) verify(hash, password string) error {
+	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+	if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+		return ErrMismatch
+	}
+
+	return err
+}
+
+func (a bcryptAlgorithm) owns(hash string) bool {
+	return strings.HasPrefix(hash, "$2a$") ||
+		strings.HasPrefix(hash, "$2b$") ||
+		strings.HasPrefix(hash, "$2y$")
+}
+
+func (a bcryptAlgorithm) outdated(hash string) bool {
+	cost, err := bcrypt.Cost([]byte(hash))
+
+	return err != nil || cost != a.cost
+}
@@ -0,0 +1,77 @@
+package password
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Amore14rn/888Starz_test/pkg/errors"
+)
+
+const (
+	AlgorithmBcrypt   = "bcrypt"
+	AlgorithmArgon2id = "argon2id"
+)
+
+var ErrMismatch = errors.New("password does not match")
+
+type Config struct {
+	Algorithm string
+	Bcrypt    BcryptParams
+	Argon2id  Argon2idParams
+}
+
+// Hasher hashes with the configured algorithm and verifies hashes produced
+// by any supported algorithm, so switching algorithms or costs only needs
+// existing hashes to be rehashed on the next successful login.
+type Hasher struct {
+	primary algorithm
+	all     []algorithm
+}
+
+type algorithm interface {
+	hash(password string) (string, error)
+	verify(hash, password string) error
+	owns(hash string) bool
+	outdated(hash string) bool
+}
+
+func New(cfg Config) (*Hasher, error) {
+	bc := newBcrypt(cfg.Bcrypt)
+	ar := newArgon2id(cfg.Argon2id)
+
+	h := &Hasher{all: []algorithm{bc, ar}}
+
+	switch strings.ToLower(cfg.Algorithm) {
+	case "", AlgorithmBcrypt:
+		h.primary = bc
+	case AlgorithmArgon2id:
+		h.primary = ar
+	default:
+		return nil, fmt.Errorf("unknown password hashing algorithm %q", cfg.Algorithm)
+	}
+
+	return h, nil
+}
+
+func (h *Hasher) Hash(password string) (string, error) {
+	return h.primary.hash(password)
+}
+
+// Verify returns ErrMismatch when password doesn't match hash. A hash no
+// algorithm recognises, such as a plaintext password stored before hashing
+// was introduced, never matches: it is not compared with password at all.
+func (h *Hasher) Verify(hash, password string) error {
+	for _, a := range h.all {
+		if a.owns(hash) {
+			return a.verify(hash, password)
+		}
+	}
+
+	return ErrMismatch
+}
+
+// NeedsRehash reports whether hash was produced by another algorithm or with
+// parameters that differ from the configured ones.
+func (h *Hasher) NeedsRehash(hash string) bool {
+	return !h.primary.owns(hash) || h.primary.outdated(hash)
+}
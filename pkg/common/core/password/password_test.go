@@ -0,0 +1,60 @@
+package password
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHasher(t *testing.T) {
+	for _, algorithm := range []string{AlgorithmBcrypt, AlgorithmArgon2id} {
+		t.Run(algorithm, func(t *testing.T) {
+			h, err := New(Config{
+				Algorithm: algorithm,
+				Bcrypt:    BcryptParams{Cost: 4},
+				Argon2id:  Argon2idParams{Memory: 1024, Iterations: 1, Parallelism: 1},
+			})
+			require.NoError(t, err)
+
+			hash, err := h.Hash("Secret123")
+			require.NoError(t, err)
+			assert.NotContains(t, hash, "Secret123")
+
+			assert.NoError(t, h.Verify(hash, "Secret123"))
+			assert.ErrorIs(t, h.Verify(hash, "Secret124"), ErrMismatch)
+			assert.False(t, h.NeedsRehash(hash))
+		})
+	}
+}
+
+func TestHasherNeedsRehash(t *testing.T) {
+	old, err := New(Config{Algorithm: AlgorithmBcrypt, Bcrypt: BcryptParams{Cost: 4}})
+	require.NoError(t, err)
+
+	hash, err := old.Hash("Secret123")
+	require.NoError(t, err)
+
+	costlier, err := New(Config{Algorithm: AlgorithmBcrypt, Bcrypt: BcryptParams{Cost: 5}})
+	require.NoError(t, err)
+	assert.True(t, costlier.NeedsRehash(hash))
+
+	argon, err := New(Config{Algorithm: AlgorithmArgon2id, Argon2id: Argon2idParams{Memory: 1024, Iterations: 1, Parallelism: 1}})
+	require.NoError(t, err)
+	assert.True(t, argon.NeedsRehash(hash))
+	assert.NoError(t, argon.Verify(hash, "Secret123"), "hashes from a previous algorithm must still verify")
+}
+
+func TestVerifyUnrecognisedHash(t *testing.T) {
+	h, err := New(Config{Bcrypt: BcryptParams{Cost: 4}})
+	require.NoError(t, err)
+
+	// Rows created before passwords were hashed hold the password itself
+	assert.ErrorIs(t, h.Verify("Secret123", "Secret123"), ErrMismatch)
+	assert.ErrorIs(t, h.Verify("", ""), ErrMismatch)
+}
+
+func TestNewUnknownAlgorithm(t *testing.T) {
+	_, err := New(Config{Algorithm: "md5"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,54 @@
+package patch
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// Field is a member of a JSON Merge Patch document (RFC 7396). It tells an
+// absent member, which keeps the current value, from an explicit null, which
+// removes it, and from a new value.
+type Field[T any] struct {
+	Set   bool // the member is present in the document
+	Null  bool // the member is present and null
+	Value T
+}
+
+// Of returns a field set to value.
+func Of[T any](value T) Field[T] {
+	return Field[T]{Set: true, Value: value}
+}
+
+// UnmarshalJSON is only called for members present in the document,
+// including the ones set to null.
+func (f *Field[T]) UnmarshalJSON(data []byte) error {
+	f.Set = true
+
+	if bytes.Equal(bytes.TrimSpace(data), []byte("null")) {
+		var zero T
+		f.Null, f.Value = true, zero
+
+		return nil
+	}
+
+	f.Null = false
+
+	return json.Unmarshal(data, &f.Value)
+}
+
+// IsNull reports whether the member is present and null.
+func (f Field[T]) IsNull() bool {
+	return f.Set && f.Null
+}
+
+// Ptr returns a pointer to a copy of the new value, or nil when the member
+// is absent or null.
+func (f Field[T]) Ptr() *T {
+	if !f.Set || f.Null {
+		return nil
+	}
+
+	value := f.Value
+
+	return &value
+}
@@ -0,0 +1,37 @@
+package patch
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type document struct {
+	Name Field[string]   `json:"name"`
+	Age  Field[int]      `json:"age"`
+	Tags Field[[]string] `json:"tags"`
+}
+
+func TestFieldTellsAbsentFromNull(t *testing.T) {
+	var doc document
+	require.NoError(t, json.Unmarshal([]byte(`{"name": "Ann", "tags": null}`), &doc))
+
+	assert.Equal(t, Of("Ann"), doc.Name)
+	assert.Equal(t, "Ann", *doc.Name.Ptr())
+
+	assert.False(t, doc.Age.Set)
+	assert.False(t, doc.Age.IsNull())
+	assert.Nil(t, doc.Age.Ptr())
+
+	assert.True(t, doc.Tags.Set)
+	assert.True(t, doc.Tags.IsNull())
+	assert.Nil(t, doc.Tags.Ptr())
+}
+
+func TestFieldRejectsWrongType(t *testing.T) {
+	var doc document
+
+	assert.Error(t, json.Unmarshal([]byte(`{"age": "ten"}`), &doc))
+}
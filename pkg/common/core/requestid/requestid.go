@@ -0,0 +1,21 @@
+package requestid
+
+import "context"
+
+// Header carries the request ID in both the request and the response.
+const Header = "X-Request-ID"
+
+type ctxRequestID struct{}
+
+// NewContext adds the ID of the current request to context
+func NewContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, ctxRequestID{}, id)
+}
+
+// FromContext returns the ID of the current request, or an empty string
+// outside of a request.
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(ctxRequestID{}).(string)
+
+	return id
+}
@@ -0,0 +1,60 @@
+package sweeper
+
+import (
+	"context"
+	"time"
+
+	"github.com/Amore14rn/888Starz_test/pkg/common/core/clock"
+	"github.com/Amore14rn/888Starz_test/pkg/common/logging"
+)
+
+// Func drops whatever expired at or before now and returns how many rows
+// were dropped.
+type Func func(ctx context.Context, now time.Time) (int64, error)
+
+// Sweeper periodically drops expired rows, such as carts past their TTL.
+type Sweeper struct {
+	name     string
+	sweep    Func
+	clock    clock.Clock
+	interval time.Duration
+}
+
+func New(name string, sweep Func, clock clock.Clock, interval time.Duration) *Sweeper {
+	return &Sweeper{
+		name:     name,
+		sweep:    sweep,
+		clock:    clock,
+		interval: interval,
+	}
+}
+
+// Run sweeps once per interval until ctx is done. A failed sweep is logged
+// and simply retried on the next tick.
+func (s *Sweeper) Run(ctx context.Context) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-s.clock.After(s.interval):
+		}
+
+		deleted, err := s.Sweep(ctx)
+		if err != nil {
+			logging.WithError(ctx, err).With(logging.StringField("sweeper", s.name)).Error("sweep failed")
+			continue
+		}
+
+		if deleted > 0 {
+			logging.WithFields(ctx,
+				logging.StringField("sweeper", s.name),
+				logging.Int64Field("deleted", deleted),
+			).Info("expired rows swept")
+		}
+	}
+}
+
+// Sweep drops the rows expired by now and returns how many were dropped.
+func (s *Sweeper) Sweep(ctx context.Context) (int64, error) {
+	return s.sweep(ctx, s.clock.Now())
+}
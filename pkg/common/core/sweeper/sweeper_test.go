@@ -0,0 +1,57 @@
+package sweeper
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeClock fires After only when the test sends on ticks.
+type fakeClock struct {
+	now   time.Time
+	ticks chan time.Time
+}
+
+func (c fakeClock) After(d time.Duration) <-chan time.Time { return c.ticks }
+
+func (c fakeClock) Now() time.Time { return c.now }
+
+func (c fakeClock) Since(t time.Time) time.Duration { return c.now.Sub(t) }
+
+func (c fakeClock) Until(t time.Time) time.Duration { return t.Sub(c.now) }
+
+func (c fakeClock) Sleep(d time.Duration) {}
+
+func (c fakeClock) Tick(d time.Duration) <-chan time.Time { return c.ticks }
+
+func TestRunSweepsOnEveryTick(t *testing.T) {
+	now := time.Date(2023, 9, 10, 12, 0, 0, 0, time.UTC)
+	cl := fakeClock{now: now, ticks: make(chan time.Time)}
+
+	var calls []time.Time
+	sweep := func(ctx context.Context, at time.Time) (int64, error) {
+		calls = append(calls, at)
+		if len(calls) == 1 {
+			return 0, errors.New("connection reset")
+		}
+
+		return 2, nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- New("test", sweep, cl, time.Minute).Run(ctx)
+	}()
+
+	// A failed sweep doesn't stop the sweeper
+	cl.ticks <- now
+	cl.ticks <- now
+	cancel()
+
+	assert.NoError(t, <-done)
+	assert.Equal(t, []time.Time{now, now}, calls)
+}
@@ -0,0 +1,171 @@
+package token
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Amore14rn/888Starz_test/pkg/errors"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	AlgorithmHS256 = "HS256"
+	AlgorithmEdDSA = "EdDSA"
+)
+
+// Kind tells access and refresh tokens apart so one can't be used as the other.
+type Kind string
+
+const (
+	KindAccess  Kind = "access"
+	KindRefresh Kind = "refresh"
+)
+
+var ErrInvalidToken = errors.New("invalid token")
+
+type Config struct {
+	Algorithm string
+	// Secret is the HS256 signing key.
+	Secret string
+	// PrivateKey is the base64 encoded Ed25519 seed or private key used with EdDSA.
+	PrivateKey string
+	Issuer     string
+	AccessTTL  time.Duration
+	RefreshTTL time.Duration
+}
+
+type Claims struct {
+	jwt.RegisteredClaims
+	Kind Kind   `json:"kind"`
+	Role string `json:"role,omitempty"`
+}
+
+type Pair struct {
+	AccessToken      string    `json:"access_token"`
+	AccessExpiresAt  time.Time `json:"access_expires_at"`
+	RefreshToken     string    `json:"refresh_token"`
+	RefreshExpiresAt time.Time `json:"refresh_expires_at"`
+}
+
+type Manager struct {
+	method     jwt.SigningMethod
+	signKey    crypto.PrivateKey
+	verifyKey  crypto.PublicKey
+	issuer     string
+	accessTTL  time.Duration
+	refreshTTL time.Duration
+}
+
+func NewManager(cfg Config) (*Manager, error) {
+	m := &Manager{
+		issuer:     cfg.Issuer,
+		accessTTL:  cfg.AccessTTL,
+		refreshTTL: cfg.RefreshTTL,
+	}
+
+	switch strings.ToUpper(cfg.Algorithm) {
+	case "", strings.ToUpper(AlgorithmHS256):
+		if cfg.Secret == "" {
+			return nil, errors.New("HS256 requires a non-empty secret")
+		}
+
+		m.method = jwt.SigningMethodHS256
+		m.signKey = []byte(cfg.Secret)
+		m.verifyKey = []byte(cfg.Secret)
+	case strings.ToUpper(AlgorithmEdDSA):
+		raw, err := base64.StdEncoding.DecodeString(cfg.PrivateKey)
+		if err != nil {
+			return nil, errors.Wrap(err, "decode EdDSA private key")
+		}
+
+		var key ed25519.PrivateKey
+		switch len(raw) {
+		case ed25519.SeedSize:
+			key = ed25519.NewKeyFromSeed(raw)
+		case ed25519.PrivateKeySize:
+			key = raw
+		default:
+			return nil, errors.New("EdDSA private key must be a 32 byte seed or a 64 byte private key")
+		}
+
+		m.method = jwt.SigningMethodEdDSA
+		m.signKey = key
+		m.verifyKey = key.Public()
+	default:
+		return nil, fmt.Errorf("unsupported token algorithm %q", cfg.Algorithm)
+	}
+
+	return m, nil
+}
+
+// Issue signs a new access and refresh token pair for subject. The role is
+// only embedded into the access token; refreshing re-reads it from storage.
+func (m *Manager) Issue(subject, role string, now time.Time) (Pair, error) {
+	access, accessExp, err := m.sign(subject, role, KindAccess, now, m.accessTTL)
+	if err != nil {
+		return Pair{}, err
+	}
+
+	refresh, refreshExp, err := m.sign(subject, "", KindRefresh, now, m.refreshTTL)
+	if err != nil {
+		return Pair{}, err
+	}
+
+	return Pair{
+		AccessToken:      access,
+		AccessExpiresAt:  accessExp,
+		RefreshToken:     refresh,
+		RefreshExpiresAt: refreshExp,
+	}, nil
+}
+
+func (m *Manager) sign(subject, role string, kind Kind, now time.Time, ttl time.Duration) (string, time.Time, error) {
+	expiresAt := now.Add(ttl)
+
+	claims := Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    m.issuer,
+			Subject:   subject,
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+		Kind: kind,
+		Role: role,
+	}
+
+	signed, err := jwt.NewWithClaims(m.method, claims).SignedString(m.signKey)
+	if err != nil {
+		return "", time.Time{}, errors.Wrap(err, "sign token")
+	}
+
+	return signed, expiresAt, nil
+}
+
+// Parse verifies the signature, expiry, issuer and kind of raw and returns
+// its claims. Every failure is reported as ErrInvalidToken.
+func (m *Manager) Parse(raw string, kind Kind, now time.Time) (Claims, error) {
+	var claims Claims
+
+	_, err := jwt.ParseWithClaims(
+		raw,
+		&claims,
+		func(*jwt.Token) (interface{}, error) { return m.verifyKey, nil },
+		jwt.WithValidMethods([]string{m.method.Alg()}),
+		jwt.WithIssuer(m.issuer),
+		jwt.WithTimeFunc(func() time.Time { return now }),
+	)
+	if err != nil {
+		return Claims{}, errors.Wrap(ErrInvalidToken, err.Error())
+	}
+
+	if claims.Kind != kind || claims.Subject == "" {
+		return Claims{}, ErrInvalidToken
+	}
+
+	return claims, nil
+}
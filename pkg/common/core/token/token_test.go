@@ -0,0 +1,67 @@
+package token
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestManager(t *testing.T) {
+	_, key, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+
+	configs := map[string]Config{
+		AlgorithmHS256: {Algorithm: AlgorithmHS256, Secret: "secret"},
+		AlgorithmEdDSA: {Algorithm: AlgorithmEdDSA, PrivateKey: base64.StdEncoding.EncodeToString(key.Seed())},
+	}
+
+	now := time.Date(2023, 9, 10, 12, 0, 0, 0, time.UTC)
+
+	for name, cfg := range configs {
+		t.Run(name, func(t *testing.T) {
+			cfg.Issuer = "888starz"
+			cfg.AccessTTL = time.Minute
+			cfg.RefreshTTL = time.Hour
+
+			m, err := NewManager(cfg)
+			require.NoError(t, err)
+
+			pair, err := m.Issue("user-1", "manager", now)
+			require.NoError(t, err)
+
+			claims, err := m.Parse(pair.AccessToken, KindAccess, now.Add(30*time.Second))
+			require.NoError(t, err)
+			assert.Equal(t, "user-1", claims.Subject)
+			assert.Equal(t, "manager", claims.Role)
+
+			_, err = m.Parse(pair.AccessToken, KindAccess, now.Add(2*time.Minute))
+			assert.ErrorIs(t, err, ErrInvalidToken, "expired access token")
+
+			_, err = m.Parse(pair.RefreshToken, KindAccess, now)
+			assert.ErrorIs(t, err, ErrInvalidToken, "refresh token used as access token")
+
+			claims, err = m.Parse(pair.RefreshToken, KindRefresh, now.Add(30*time.Minute))
+			require.NoError(t, err)
+			assert.Equal(t, "user-1", claims.Subject)
+		})
+	}
+}
+
+func TestManagerRejectsForeignSignature(t *testing.T) {
+	now := time.Now()
+
+	a, err := NewManager(Config{Secret: "a", AccessTTL: time.Minute})
+	require.NoError(t, err)
+	b, err := NewManager(Config{Secret: "b", AccessTTL: time.Minute})
+	require.NoError(t, err)
+
+	pair, err := a.Issue("user-1", "customer", now)
+	require.NoError(t, err)
+
+	_, err = b.Parse(pair.AccessToken, KindAccess, now)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
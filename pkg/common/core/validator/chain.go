@@ -1,12 +1,35 @@
 package validator
 
+import "errors"
+
 type chainValidator []Validator
 
+// Validate runs every validator and merges their ValidationErrors into one,
+// so a single response can list all invalid fields. Any other error stops
+// the chain and is returned as is.
 func (v chainValidator) Validate() error {
+	fields := ErrorFields{}
+
 	for _, validator := range v {
-		if err := validator.Validate(); err != nil {
+		err := validator.Validate()
+		if err == nil {
+			continue
+		}
+
+		var vErr ValidationError
+		if !errors.As(err, &vErr) {
 			return err
 		}
+
+		for field, msg := range vErr.Fields {
+			if _, ok := fields[field]; !ok {
+				fields[field] = msg
+			}
+		}
+	}
+
+	if len(fields) > 0 {
+		return ValidationError{Fields: fields}
 	}
 
 	return nil
@@ -0,0 +1,40 @@
+package validator
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type failingValidator struct{ err error }
+
+func (v failingValidator) Validate() error { return v.err }
+
+func TestChainValidatorMergesFields(t *testing.T) {
+	err := ChainValidator(
+		UUIDValidator("id", "nope"),
+		UUIDValidator("user_id", "5f8d0d55-3b5e-4a4f-9d2a-6b1a2b3c4d5e"),
+		NewTimeValidator("from", "yesterday", "2006-01-02"),
+	).Validate()
+
+	var vErr ValidationError
+	require.ErrorAs(t, err, &vErr)
+	assert.Equal(t, ErrorFields{
+		"id":   "must be uuid",
+		"from": "wrong the time format",
+	}, vErr.Fields)
+}
+
+func TestChainValidatorStopsOnOtherErrors(t *testing.T) {
+	boom := errors.New("boom")
+
+	err := ChainValidator(
+		UUIDValidator("id", "nope"),
+		failingValidator{err: boom},
+	).Validate()
+
+	assert.ErrorIs(t, err, boom)
+	assert.NoError(t, ChainValidator().Validate())
+}
@@ -0,0 +1,32 @@
+package validator
+
+// Nullable is a merge patch member that can be explicitly null.
+type Nullable interface {
+	IsNull() bool
+}
+
+type notNullValidator map[string]Nullable
+
+// Validate rejects every member set to null.
+func (v notNullValidator) Validate() error {
+	fields := ErrorFields{}
+
+	for field, value := range v {
+		if value.IsNull() {
+			fields[field] = "must not be null"
+		}
+	}
+
+	if len(fields) > 0 {
+		return ValidationError{Fields: fields}
+	}
+
+	return nil
+}
+
+// NotNullValidator rejects null for members whose columns can't be removed.
+// Pass pointers to the members, they are read when the chain runs, i.e. after
+// the request was decoded.
+func NotNullValidator(fields map[string]Nullable) Validator {
+	return notNullValidator(fields)
+}
@@ -0,0 +1,54 @@
+package validator
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/Amore14rn/888Starz_test/pkg/common/core/patch"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type patchRequest struct {
+	Name     patch.Field[string] `json:"name" validate:"omitempty,min=1,max=5"`
+	Quantity patch.Field[int]    `json:"quantity" validate:"omitempty,gte=0"`
+}
+
+func (r *patchRequest) notNull() Validator {
+	return NotNullValidator(map[string]Nullable{"name": &r.Name, "quantity": &r.Quantity})
+}
+
+func TestPatchFields(t *testing.T) {
+	require.NoError(t, New(time.DateOnly))
+
+	tests := map[string]struct {
+		body string
+		want ErrorFields
+	}{
+		"absent":       {body: `{}`},
+		"zero allowed": {body: `{"quantity": 0}`},
+		"empty string": {body: `{"name": ""}`, want: ErrorFields{"name": "field validation for 'name' failed on the 'min=1' tag"}},
+		"too long":     {body: `{"name": "abcdef"}`, want: ErrorFields{"name": "field validation for 'name' failed on the 'max=5' tag"}},
+		"null":         {body: `{"name": null, "quantity": -1}`, want: ErrorFields{"name": "must not be null", "quantity": "field validation for 'quantity' failed on the 'gte=0' tag"}},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			var req patchRequest
+			validator := ChainValidator(StructValidator(&req), req.notNull())
+			require.NoError(t, json.Unmarshal([]byte(tt.body), &req))
+
+			err := validator.Validate()
+
+			if tt.want == nil {
+				assert.NoError(t, err)
+				return
+			}
+
+			var vErr ValidationError
+			require.ErrorAs(t, err, &vErr)
+			assert.Equal(t, tt.want, vErr.Fields)
+		})
+	}
+}
@@ -2,6 +2,7 @@ package validator
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/go-playground/validator/v10"
 )
@@ -27,9 +28,14 @@ func (v *structValidator) Validate() error {
 			errFields := ErrorFields{}
 
 			for _, fieldErr := range vErr {
-				errFields[fieldErr.Field()] = fmt.Sprintf(
+				tag := fieldErr.Tag()
+				if fieldErr.Param() != "" {
+					tag += "=" + fieldErr.Param()
+				}
+
+				errFields[fieldPath(fieldErr)] = fmt.Sprintf(
 					"field validation for '%s' failed on the '%s' tag",
-					fieldErr.Field(), fieldErr.Tag(),
+					fieldErr.Field(), tag,
 				)
 			}
 
@@ -39,3 +45,14 @@ func (v *structValidator) Validate() error {
 
 	return nil
 }
+
+// fieldPath returns the namespace of the failed field without the root
+// struct name, e.g. "products[1].quantity", so nested fields stay distinct.
+func fieldPath(fieldErr validator.FieldError) string {
+	ns := fieldErr.Namespace()
+	if i := strings.IndexByte(ns, '.'); i >= 0 {
+		return ns[i+1:]
+	}
+
+	return ns
+}
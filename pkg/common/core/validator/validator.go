@@ -1,6 +1,7 @@
 package validator
 
 import (
+	"github.com/Amore14rn/888Starz_test/pkg/common/core/patch"
 	"github.com/go-playground/validator/v10"
 	"reflect"
 	"strings"
@@ -30,6 +31,15 @@ func New(structDateFormat string) error {
 		return fieldName
 	})
 
+	// Merge patch members are validated by their value, absent and null
+	// members are skipped by omitempty
+	registerPatchField[string](validate)
+	registerPatchField[int](validate)
+	registerPatchField[uint32](validate)
+	registerPatchField[float64](validate)
+	registerPatchField[bool](validate)
+	registerPatchField[[]string](validate)
+
 	err := validate.RegisterValidation("date", func(fl validator.FieldLevel) bool {
 		val := fl.Field().String()
 		if val == "" {
@@ -45,3 +55,11 @@ func New(structDateFormat string) error {
 
 	return nil
 }
+
+// registerPatchField makes patch.Field[T] validate as a *T, so a present
+// zero value is still checked by the tags after omitempty.
+func registerPatchField[T any](v *validator.Validate) {
+	v.RegisterCustomTypeFunc(func(field reflect.Value) interface{} {
+		return field.Interface().(patch.Field[T]).Ptr()
+	}, patch.Field[T]{})
+}
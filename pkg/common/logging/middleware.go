@@ -3,7 +3,7 @@ package logging
 import (
 	"net/http"
 
-	"github.com/Amore14rn/888Starz/pkg/tracing"
+	"github.com/Amore14rn/888Starz_test/pkg/tracing"
 	"go.opentelemetry.io/otel/trace"
 )
 
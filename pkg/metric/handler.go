@@ -4,10 +4,13 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
 const (
-	URL = "/api/heartbeat"
+	URL        = "/api/heartbeat"
+	MetricsURL = "/metrics"
 )
 
 type HandlerFunc func(http.ResponseWriter, *http.Request)
@@ -17,6 +20,8 @@ func (h HandlerFunc) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 }
 
 type Handler struct {
+	// Gatherer is exposed on MetricsURL, the route is not added when it is nil.
+	Gatherer prometheus.Gatherer
 }
 
 // Register adds the routes for the metric handler to the passed router.
@@ -24,6 +29,10 @@ func (h *Handler) Register(router *gin.Engine) {
 	router.Any(URL, func(c *gin.Context) {
 		HandlerFunc(h.Heartbeat).ServeHTTP(c.Writer, c.Request)
 	})
+
+	if h.Gatherer != nil {
+		router.GET(MetricsURL, gin.WrapH(promhttp.HandlerFor(h.Gatherer, promhttp.HandlerOpts{})))
+	}
 }
 
 // Heartbeat handles the heartbeat metric.
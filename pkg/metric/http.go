@@ -0,0 +1,67 @@
+package metric
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// unmatchedRoute labels requests that matched no route, so that scanning
+// random paths doesn't create a series per path.
+const unmatchedRoute = "unmatched"
+
+// HTTP counts requests and measures their latency by method, route template
+// (such as /product/get/:id) and status code.
+type HTTP struct {
+	requests *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+}
+
+func NewHTTP(registerer prometheus.Registerer) (*HTTP, error) {
+	labels := []string{"method", "route", "status"}
+
+	h := &HTTP{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "http_requests_total",
+			Help: "Number of HTTP requests handled.",
+		}, labels),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "http_request_duration_seconds",
+			Help:    "Latency of HTTP requests.",
+			Buckets: prometheus.DefBuckets,
+		}, labels),
+	}
+
+	for _, c := range []prometheus.Collector{h.requests, h.duration} {
+		if err := registerer.Register(c); err != nil {
+			return nil, err
+		}
+	}
+
+	return h, nil
+}
+
+// Middleware records every request once the handlers have run.
+func (h *HTTP) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+
+		labels := prometheus.Labels{
+			"method": c.Request.Method,
+			"route":  route,
+			"status": strconv.Itoa(c.Writer.Status()),
+		}
+
+		h.requests.With(labels).Inc()
+		h.duration.With(labels).Observe(time.Since(start).Seconds())
+	}
+}
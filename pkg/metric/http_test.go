@@ -0,0 +1,69 @@
+package metric
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	registry := prometheus.NewRegistry()
+	httpMetrics, err := NewHTTP(registry)
+	require.NoError(t, err)
+
+	router := gin.New()
+	router.Use(httpMetrics.Middleware())
+	router.GET("/product/get/:id", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	for _, path := range []string{"/product/get/1", "/product/get/2", "/missing/1", "/missing/2"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	assert.Equal(t, 2.0, testutil.ToFloat64(httpMetrics.requests.WithLabelValues("GET", "/product/get/:id", "200")))
+	assert.Equal(t, 2.0, testutil.ToFloat64(httpMetrics.requests.WithLabelValues("GET", unmatchedRoute, "404")))
+	assert.Equal(t, 2, testutil.CollectAndCount(httpMetrics.duration))
+}
+
+func TestMetricsEndpoint(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	registry := prometheus.NewRegistry()
+	orders, err := NewOrders(registry)
+	require.NoError(t, err)
+
+	orders.OrderCreated(24.25)
+	orders.OrderCreated(10)
+	orders.StockOutRejected()
+
+	router := gin.New()
+	handler := Handler{Gatherer: registry}
+	handler.Register(router)
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, MetricsURL, nil))
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.NoError(t, testutil.GatherAndCompare(registry, strings.NewReader(`
+# HELP orders_created_total Number of orders placed.
+# TYPE orders_created_total counter
+orders_created_total 2
+# HELP orders_revenue_total Sum of the totals of placed orders at purchase prices.
+# TYPE orders_revenue_total counter
+orders_revenue_total 34.25
+# HELP orders_stock_out_rejected_total Number of orders rejected because a product was out of stock.
+# TYPE orders_stock_out_rejected_total counter
+orders_stock_out_rejected_total 1
+`)))
+	assert.Contains(t, rec.Body.String(), "orders_revenue_total 34.25")
+}
@@ -0,0 +1,44 @@
+package metric
+
+import "github.com/prometheus/client_golang/prometheus"
+
+// Orders holds the business counters of order placement.
+type Orders struct {
+	created   prometheus.Counter
+	stockOuts prometheus.Counter
+	revenue   prometheus.Counter
+}
+
+func NewOrders(registerer prometheus.Registerer) (*Orders, error) {
+	o := &Orders{
+		created: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "orders_created_total",
+			Help: "Number of orders placed.",
+		}),
+		stockOuts: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "orders_stock_out_rejected_total",
+			Help: "Number of orders rejected because a product was out of stock.",
+		}),
+		revenue: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "orders_revenue_total",
+			Help: "Sum of the totals of placed orders at purchase prices.",
+		}),
+	}
+
+	for _, c := range []prometheus.Collector{o.created, o.stockOuts, o.revenue} {
+		if err := registerer.Register(c); err != nil {
+			return nil, err
+		}
+	}
+
+	return o, nil
+}
+
+func (o *Orders) OrderCreated(total float64) {
+	o.created.Inc()
+	o.revenue.Add(total)
+}
+
+func (o *Orders) StockOutRejected() {
+	o.stockOuts.Inc()
+}
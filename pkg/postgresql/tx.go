@@ -0,0 +1,50 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// WithTx runs fn inside a transaction started on client. The transaction is
+// committed when fn returns nil and rolled back otherwise.
+//
+// When client is a TxManager the transaction is run by its WithinTx, so it
+// becomes a savepoint of the ambient transaction of ctx or, when there is
+// none, is retried on serialization failures. fn has to be safe to run more
+// than once then.
+func WithTx(ctx context.Context, client Client, opts pgx.TxOptions, fn func(tx pgx.Tx) error) (err error) {
+	if m, ok := client.(*TxManager); ok {
+		return m.WithinTx(ctx, opts, func(ctx context.Context) error {
+			tx, _ := TxFromContext(ctx)
+
+			return fn(tx)
+		})
+	}
+
+	tx, err := client.BeginTx(ctx, opts)
+	if err != nil {
+		return ErrCreateTx(err)
+	}
+
+	defer func() {
+		if err == nil {
+			return
+		}
+
+		if rbErr := tx.Rollback(ctx); rbErr != nil && !errors.Is(rbErr, pgx.ErrTxClosed) {
+			err = errors.Join(err, ErrRollback(rbErr))
+		}
+	}()
+
+	if err = fn(tx); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return ErrCommit(err)
+	}
+
+	return nil
+}
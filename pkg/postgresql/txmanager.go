@@ -0,0 +1,159 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// CodeSerializationFailure is the SQLSTATE of a transaction that lost a
+// serialization conflict and can be retried from the start.
+const CodeSerializationFailure = "40001"
+
+type txKey struct{}
+
+// ContextWithTx returns a copy of ctx carrying tx as the ambient transaction.
+func ContextWithTx(ctx context.Context, tx pgx.Tx) context.Context {
+	return context.WithValue(ctx, txKey{}, tx)
+}
+
+// TxFromContext returns the ambient transaction stored in ctx, if any.
+func TxFromContext(ctx context.Context) (pgx.Tx, bool) {
+	tx, ok := ctx.Value(txKey{}).(pgx.Tx)
+
+	return tx, ok
+}
+
+// IsSerializationFailure reports whether err was caused by a serialization
+// failure, i.e. the whole transaction may succeed when run again.
+func IsSerializationFailure(err error) bool {
+	var pgErr *pgconn.PgError
+
+	return errors.As(err, &pgErr) && pgErr.Code == CodeSerializationFailure
+}
+
+// TxManager runs functions inside a transaction stored in their context.
+//
+// TxManager is a Client itself: Exec, Query and QueryRow run on the ambient
+// transaction of the context when there is one, and Begin/BeginTx open a
+// savepoint in it. A DAO built on a TxManager therefore joins the caller's
+// transaction without knowing about it, and its own WithTx blocks become
+// savepoints that are rolled back on error without aborting the caller.
+type TxManager struct {
+	client     Client
+	maxRetries int
+}
+
+// NewTxManager wraps client. A transaction that fails with a serialization
+// failure is run again up to maxRetries times.
+func NewTxManager(client Client, maxRetries int) *TxManager {
+	return &TxManager{
+		client:     client,
+		maxRetries: maxRetries,
+	}
+}
+
+// WithinTx runs fn with a context carrying a transaction started with opts.
+// The transaction is committed when fn returns nil and rolled back otherwise.
+//
+// When ctx already carries a transaction, fn runs in a savepoint of it and
+// opts are ignored: a failed fn rolls back only its own changes. Only the
+// outermost call retries fn on a serialization failure, so fn has to be safe
+// to run more than once.
+func (m *TxManager) WithinTx(ctx context.Context, opts pgx.TxOptions, fn func(ctx context.Context) error) error {
+	if tx, ok := TxFromContext(ctx); ok {
+		return m.run(ctx, func() (pgx.Tx, error) { return tx.Begin(ctx) }, fn)
+	}
+
+	var err error
+	for attempt := 0; attempt <= m.maxRetries; attempt++ {
+		err = m.run(ctx, func() (pgx.Tx, error) { return m.client.BeginTx(ctx, opts) }, fn)
+		if !IsSerializationFailure(err) || ctx.Err() != nil {
+			return err
+		}
+	}
+
+	return err
+}
+
+func (m *TxManager) run(ctx context.Context, begin func() (pgx.Tx, error), fn func(ctx context.Context) error) error {
+	tx, err := begin()
+	if err != nil {
+		return ErrCreateTx(err)
+	}
+
+	if err = fn(ContextWithTx(ctx, tx)); err != nil {
+		if rbErr := tx.Rollback(ctx); rbErr != nil && !errors.Is(rbErr, pgx.ErrTxClosed) {
+			return errors.Join(err, ErrRollback(rbErr))
+		}
+
+		return err
+	}
+
+	if err = tx.Commit(ctx); err != nil {
+		return ErrCommit(err)
+	}
+
+	return nil
+}
+
+func (m *TxManager) Close() {
+	m.client.Close()
+}
+
+func (m *TxManager) Acquire(ctx context.Context) (*pgxpool.Conn, error) {
+	return m.client.Acquire(ctx)
+}
+
+func (m *TxManager) AcquireFunc(ctx context.Context, f func(*pgxpool.Conn) error) error {
+	return m.client.AcquireFunc(ctx, f)
+}
+
+func (m *TxManager) AcquireAllIdle(ctx context.Context) []*pgxpool.Conn {
+	return m.client.AcquireAllIdle(ctx)
+}
+
+func (m *TxManager) Stat() *pgxpool.Stat {
+	return m.client.Stat()
+}
+
+func (m *TxManager) Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error) {
+	if tx, ok := TxFromContext(ctx); ok {
+		return tx.Exec(ctx, sql, arguments...)
+	}
+
+	return m.client.Exec(ctx, sql, arguments...)
+}
+
+func (m *TxManager) Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error) {
+	if tx, ok := TxFromContext(ctx); ok {
+		return tx.Query(ctx, sql, args...)
+	}
+
+	return m.client.Query(ctx, sql, args...)
+}
+
+func (m *TxManager) QueryRow(ctx context.Context, sql string, args ...any) pgx.Row {
+	if tx, ok := TxFromContext(ctx); ok {
+		return tx.QueryRow(ctx, sql, args...)
+	}
+
+	return m.client.QueryRow(ctx, sql, args...)
+}
+
+func (m *TxManager) Begin(ctx context.Context) (pgx.Tx, error) {
+	return m.BeginTx(ctx, pgx.TxOptions{})
+}
+
+// BeginTx starts a transaction, or a savepoint of the ambient one. In the
+// latter case txOptions are ignored.
+func (m *TxManager) BeginTx(ctx context.Context, txOptions pgx.TxOptions) (pgx.Tx, error) {
+	if tx, ok := TxFromContext(ctx); ok {
+		return tx.Begin(ctx)
+	}
+
+	return m.client.BeginTx(ctx, txOptions)
+}
@@ -0,0 +1,246 @@
+package postgresql
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeTx records how it was finished. Begin opens a nested fakeTx the way
+// pgx opens a savepoint.
+type fakeTx struct {
+	pgx.Tx
+
+	savepoints []*fakeTx
+	execs      []string
+	committed  bool
+	rolledBack bool
+	commitErr  error
+}
+
+func (t *fakeTx) Begin(context.Context) (pgx.Tx, error) {
+	sp := &fakeTx{}
+	t.savepoints = append(t.savepoints, sp)
+
+	return sp, nil
+}
+
+func (t *fakeTx) Commit(context.Context) error {
+	t.committed = true
+
+	return t.commitErr
+}
+
+func (t *fakeTx) Rollback(context.Context) error {
+	if t.committed {
+		return pgx.ErrTxClosed
+	}
+	t.rolledBack = true
+
+	return nil
+}
+
+func (t *fakeTx) Exec(_ context.Context, sql string, _ ...any) (pgconn.CommandTag, error) {
+	t.execs = append(t.execs, sql)
+
+	return pgconn.CommandTag{}, nil
+}
+
+type fakeClient struct {
+	Client
+
+	txs  []*fakeTx
+	opts []pgx.TxOptions
+}
+
+func (c *fakeClient) BeginTx(_ context.Context, opts pgx.TxOptions) (pgx.Tx, error) {
+	tx := &fakeTx{}
+	c.txs = append(c.txs, tx)
+	c.opts = append(c.opts, opts)
+
+	return tx, nil
+}
+
+var errSerialization = &pgconn.PgError{Code: CodeSerializationFailure}
+
+func TestWithinTxCommits(t *testing.T) {
+	client := &fakeClient{}
+	m := NewTxManager(client, 3)
+	opts := pgx.TxOptions{IsoLevel: pgx.Serializable}
+
+	err := m.WithinTx(context.Background(), opts, func(ctx context.Context) error {
+		_, err := m.Exec(ctx, "UPDATE t SET a = 1")
+
+		return err
+	})
+
+	require.NoError(t, err)
+	require.Len(t, client.txs, 1)
+	assert.Equal(t, []pgx.TxOptions{opts}, client.opts)
+	assert.True(t, client.txs[0].committed)
+	assert.Equal(t, []string{"UPDATE t SET a = 1"}, client.txs[0].execs)
+}
+
+func TestWithinTxRollsBackOnError(t *testing.T) {
+	client := &fakeClient{}
+	boom := errors.New("boom")
+
+	err := NewTxManager(client, 3).WithinTx(context.Background(), pgx.TxOptions{}, func(context.Context) error {
+		return boom
+	})
+
+	assert.ErrorIs(t, err, boom)
+	require.Len(t, client.txs, 1)
+	assert.True(t, client.txs[0].rolledBack)
+}
+
+func TestWithinTxNestedUsesSavepoint(t *testing.T) {
+	client := &fakeClient{}
+	m := NewTxManager(client, 3)
+	boom := errors.New("boom")
+
+	err := m.WithinTx(context.Background(), pgx.TxOptions{}, func(ctx context.Context) error {
+		nestedErr := m.WithinTx(ctx, pgx.TxOptions{IsoLevel: pgx.Serializable}, func(context.Context) error {
+			return boom
+		})
+		assert.ErrorIs(t, nestedErr, boom)
+
+		// A DAO's own WithTx joins the ambient transaction as well
+		return WithTx(ctx, m, pgx.TxOptions{}, func(tx pgx.Tx) error {
+			_, err := tx.Exec(ctx, "DELETE FROM t")
+
+			return err
+		})
+	})
+
+	require.NoError(t, err)
+	require.Len(t, client.txs, 1)
+	outer := client.txs[0]
+	assert.True(t, outer.committed)
+	require.Len(t, outer.savepoints, 2)
+	assert.True(t, outer.savepoints[0].rolledBack)
+	assert.True(t, outer.savepoints[1].committed)
+	assert.Equal(t, []string{"DELETE FROM t"}, outer.savepoints[1].execs)
+}
+
+func TestWithinTxRetriesSerializationFailure(t *testing.T) {
+	client := &fakeClient{}
+	calls := 0
+
+	err := NewTxManager(client, 3).WithinTx(context.Background(), pgx.TxOptions{}, func(context.Context) error {
+		calls++
+		if calls < 3 {
+			return ErrDoQuery(errSerialization)
+		}
+
+		return nil
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, 3, calls)
+	require.Len(t, client.txs, 3)
+	assert.True(t, client.txs[0].rolledBack)
+	assert.True(t, client.txs[2].committed)
+}
+
+func TestWithinTxRetriesFailedCommit(t *testing.T) {
+	client := &fakeClient{}
+	m := NewTxManager(client, 1)
+	calls := 0
+
+	err := m.WithinTx(context.Background(), pgx.TxOptions{}, func(ctx context.Context) error {
+		calls++
+		if calls == 1 {
+			tx, _ := TxFromContext(ctx)
+			tx.(*fakeTx).commitErr = errSerialization
+		}
+
+		return nil
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, 2, calls)
+}
+
+func TestWithinTxGivesUpAfterMaxRetries(t *testing.T) {
+	client := &fakeClient{}
+	calls := 0
+
+	err := NewTxManager(client, 2).WithinTx(context.Background(), pgx.TxOptions{}, func(context.Context) error {
+		calls++
+
+		return errSerialization
+	})
+
+	assert.True(t, IsSerializationFailure(err))
+	assert.Equal(t, 3, calls)
+}
+
+func TestWithinTxNestedDoesNotRetry(t *testing.T) {
+	client := &fakeClient{}
+	m := NewTxManager(client, 3)
+	calls := 0
+
+	err := m.WithinTx(context.Background(), pgx.TxOptions{}, func(ctx context.Context) error {
+		return m.WithinTx(ctx, pgx.TxOptions{}, func(context.Context) error {
+			calls++
+
+			return errSerialization
+		})
+	})
+
+	assert.True(t, IsSerializationFailure(err))
+	// Only the outermost transaction is retried, the savepoint runs once per attempt
+	assert.Equal(t, 4, calls)
+	assert.Len(t, client.txs, 4)
+}
+
+func TestWithTxOnManagerRetriesSerializationFailure(t *testing.T) {
+	client := &fakeClient{}
+	m := NewTxManager(client, 3)
+	calls := 0
+
+	// DAOs run their mutations through WithTx on the manager
+	err := WithTx(context.Background(), m, pgx.TxOptions{}, func(tx pgx.Tx) error {
+		calls++
+		if _, err := tx.Exec(context.Background(), "UPDATE t SET a = 1"); err != nil {
+			return err
+		}
+		if calls == 1 {
+			return ErrDoQuery(errSerialization)
+		}
+
+		return nil
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, 2, calls)
+	require.Len(t, client.txs, 2)
+	assert.True(t, client.txs[0].rolledBack)
+	assert.True(t, client.txs[1].committed)
+	assert.Equal(t, []string{"UPDATE t SET a = 1"}, client.txs[1].execs)
+}
+
+func TestWithTxOnManagerJoinsAmbientTx(t *testing.T) {
+	client := &fakeClient{}
+	m := NewTxManager(client, 3)
+
+	err := m.WithinTx(context.Background(), pgx.TxOptions{}, func(ctx context.Context) error {
+		return WithTx(ctx, m, pgx.TxOptions{}, func(tx pgx.Tx) error {
+			_, err := tx.Exec(ctx, "DELETE FROM t")
+
+			return err
+		})
+	})
+
+	require.NoError(t, err)
+	require.Len(t, client.txs, 1)
+	require.Len(t, client.txs[0].savepoints, 1)
+	assert.True(t, client.txs[0].savepoints[0].committed)
+	assert.Equal(t, []string{"DELETE FROM t"}, client.txs[0].savepoints[0].execs)
+}
@@ -3,6 +3,7 @@ package tracing
 import (
 	"bytes"
 	"context"
+	"database/sql/driver"
 	"encoding/json"
 	"path"
 	"reflect"
@@ -17,6 +18,21 @@ type Attributed interface {
 	Attributes() []attribute.KeyValue
 }
 
+// Redacted is recorded instead of the value of a Secret.
+const Redacted = "<REMOVED>"
+
+// Secret wraps a value, such as a password hash, that is passed on as-is
+// (including as an SQL argument) but is never recorded in a span.
+type Secret string
+
+func (s Secret) Value() (driver.Value, error) { return string(s), nil }
+
+func (s Secret) String() string { return Redacted }
+
+func (s Secret) MarshalJSON() ([]byte, error) { return json.Marshal(Redacted) }
+
+var secretType = reflect.TypeOf(Secret(""))
+
 func TraceIVal(ctx context.Context, name string, val interface{}) {
 	span := trace.SpanFromContext(ctx)
 	if !span.IsRecording() {
@@ -164,6 +180,10 @@ func prefixAndBuffer(rt reflect.Type) (prefix []byte, buf bytes.Buffer) {
 
 func attributeValue(v reflect.Value) (av attribute.Value, ok bool) {
 SwitchKind:
+	if v.IsValid() && v.Type() == secretType {
+		return attribute.StringValue(Redacted), true
+	}
+
 	switch v.Kind() {
 	case reflect.Struct, reflect.Map, reflect.Interface, reflect.Slice, reflect.Array:
 		b, err := json.Marshal(v.Interface())
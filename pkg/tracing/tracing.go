@@ -3,8 +3,8 @@ package tracing
 import (
 	"context"
 
-	"github.com/Amore14rn/888Starz/pkg/common/core/validator"
-	"github.com/Amore14rn/888Starz/pkg/errors"
+	"github.com/Amore14rn/888Starz_test/pkg/common/core/validator"
+	"github.com/Amore14rn/888Starz_test/pkg/errors"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/exporters/jaeger"
 	"go.opentelemetry.io/otel/propagation"
@@ -100,6 +100,17 @@ func New(cp ...ConfigParam) (*sdk_trace.TracerProvider, error) {
 	return provider, nil
 }
 
+// NewLocal installs a tracer provider that records spans without exporting
+// them, so requests still get trace IDs when no Jaeger agent is configured.
+func NewLocal() *sdk_trace.TracerProvider {
+	provider := sdk_trace.NewTracerProvider()
+
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	return provider
+}
+
 func SpanEvent(ctx context.Context, name string) {
 	span := trace.SpanFromContext(ctx)
 	if !span.IsRecording() {